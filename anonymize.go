@@ -0,0 +1,73 @@
+package grants
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+const (
+	// AnonymizedSourceIDPrefix is the prefix of every SourceID that has
+	// been replaced by an Anonymizer.
+	AnonymizedSourceIDPrefix = "anon:"
+)
+
+// ErrNoAnonymizationKey is returned when an Anonymizer is created without a
+// key to derive pseudonyms from.
+var ErrNoAnonymizationKey = errors.New("a key is required to anonymize grants")
+
+// Anonymizer scrubs the personal data from Grants while keeping them useful as
+// an audit trail.
+//
+// Pseudonyms are HMACs keyed with a secret, so they're stable for as long as
+// the key is: every Grant for the same profile gets the same pseudonymous
+// ProfileID, even when the Grants are anonymized at different times, and the
+// anonymized Grants can still be connected to each other. The key should be
+// stored apart from the Grants and kept as secret as the data they held, as
+// anyone with the key and a list of candidate IDs can tell which pseudonym
+// belongs to which ID. Changing the key gives profiles anonymized afterwards
+// different pseudonyms than the ones they got before.
+type Anonymizer struct {
+	key []byte
+}
+
+// NewAnonymizer returns an Anonymizer that derives pseudonyms from `key`. If
+// `key` is empty, an ErrNoAnonymizationKey error is returned.
+func NewAnonymizer(key []byte) (Anonymizer, error) {
+	if len(key) < 1 {
+		return Anonymizer{}, ErrNoAnonymizationKey
+	}
+	return Anonymizer{key: key}, nil
+}
+
+// Anonymize returns a copy of `grant` with its CreateIP and UseIP blanked, its
+// ProfileID and AccountID replaced by pseudonyms, and its SourceID replaced
+// by a hash. Everything else about the Grant is left intact.
+func (a Anonymizer) Anonymize(grant Grant) Grant {
+	res := grant
+	res.CreateIP = ""
+	res.UseIP = ""
+	res.ProfileID = a.pseudonym("profile", grant.ProfileID)
+	if grant.AccountID != "" {
+		res.AccountID = a.pseudonym("account", grant.AccountID)
+	}
+	res.SourceID = AnonymizedSourceIDPrefix + hex.EncodeToString(a.mac("source", grant.SourceType+"\x00"+grant.SourceID))
+	return res
+}
+
+// pseudonym returns a UUID-formatted pseudonym for `value`, so it fits
+// anywhere the original ID did.
+func (a Anonymizer) pseudonym(kind, value string) string {
+	sum := a.mac(kind, value)
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+func (a Anonymizer) mac(kind, value string) []byte {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(kind))  //nolint:errcheck // hash writes never return errors
+	mac.Write([]byte{0})     //nolint:errcheck // hash writes never return errors
+	mac.Write([]byte(value)) //nolint:errcheck // hash writes never return errors
+	return mac.Sum(nil)
+}
@@ -0,0 +1,37 @@
+package grants_test
+
+import (
+	"errors"
+	"testing"
+
+	"lockbox.dev/grants"
+)
+
+func TestAnonymizerPseudonyms(t *testing.T) {
+	t.Parallel()
+
+	_, err := grants.NewAnonymizer(nil)
+	if !errors.Is(err, grants.ErrNoAnonymizationKey) {
+		t.Fatalf("Expected error %v, got %v", grants.ErrNoAnonymizationKey, err)
+	}
+
+	grant := grants.Grant{ID: "grant", SourceType: "manual", SourceID: "test", ProfileID: "tester", AccountID: "account"}
+	anonymize := func(key string) grants.Grant {
+		t.Helper()
+		anonymizer, err := grants.NewAnonymizer([]byte(key))
+		if err != nil {
+			t.Fatalf("Unexpected error creating anonymizer: %s", err)
+		}
+		return anonymizer.Anonymize(grant)
+	}
+
+	// pseudonyms only depend on the key, not on the Anonymizer
+	first, second := anonymize("key"), anonymize("key")
+	if first.ProfileID != second.ProfileID || first.AccountID != second.AccountID || first.SourceID != second.SourceID {
+		t.Errorf("Expected the same key to give the same pseudonyms, got %+v and %+v", first, second)
+	}
+	other := anonymize("other key")
+	if other.ProfileID == first.ProfileID {
+		t.Errorf("Expected different keys to give different pseudonyms, got %q for both", first.ProfileID)
+	}
+}
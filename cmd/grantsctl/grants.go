@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"sort"
+
+	"lockbox.dev/grants"
+)
+
+// pageSize is the number of Grants requested at a time when listing every
+// Grant that matches something.
+const pageSize = 100
+
+func getCmd(ctx context.Context, e env, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("get", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	grant, err := e.storer.GetGrant(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	return e.printGrant(grant)
+}
+
+func getBySourceCmd(ctx context.Context, e env, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("get-by-source", flag.ContinueOnError), args, 2) //nolint:gomnd // the source type and ID
+	if err != nil {
+		return err
+	}
+	grant, err := e.storer.GetGrantBySource(ctx, positional[0], positional[1])
+	if err != nil {
+		return err
+	}
+	return e.printGrant(grant)
+}
+
+func listCmd(ctx context.Context, e env, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	profileID := flags.String("profile", "", "list the Grants for this profile")
+	clientID := flags.String("client", "", "list the Grants for this client")
+	after := flags.String("after", "", "start after the Grant with this ID")
+	limit := flags.Int("limit", pageSize, "the maximum number of Grants to list")
+	_, err := parseArgs(flags, args, 0)
+	if err != nil {
+		return err
+	}
+	if (*profileID == "") == (*clientID == "") {
+		return fmt.Errorf("%w: exactly one of --profile and --client is required", errUsage)
+	}
+	if *limit < 1 {
+		return fmt.Errorf("%w: --limit must be positive", errUsage)
+	}
+	var results []grants.Grant
+	if *profileID != "" {
+		results, err = e.storer.ListGrantsByProfile(ctx, *profileID, *after, *limit)
+	} else {
+		results, err = e.storer.ListGrantsByClient(ctx, *clientID, *after, *limit)
+	}
+	if err != nil {
+		return err
+	}
+	return e.printGrants(results)
+}
+
+func revokeCmd(ctx context.Context, e env, args []string) error {
+	flags := flag.NewFlagSet("revoke", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "show the Grant that would be revoked without revoking it")
+	positional, err := parseArgs(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	if !*dryRun {
+		grant, err := e.storer.RevokeGrant(ctx, positional[0])
+		if err != nil {
+			return err
+		}
+		return e.printGrant(grant)
+	}
+	grant, err := e.storer.GetGrant(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	_, err = grant.Transition(grants.GrantStateRevoked)
+	if err != nil {
+		return err
+	}
+	e.note("dry run: would revoke grant %s", grant.ID)
+	return e.printGrant(grant)
+}
+
+func revokeFamilyCmd(ctx context.Context, e env, args []string) error {
+	flags := flag.NewFlagSet("revoke-family", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "show the Grants that would be revoked without revoking them")
+	positional, err := parseArgs(flags, args, 1)
+	if err != nil {
+		return err
+	}
+	var revoked []grants.Grant
+	if *dryRun {
+		revoked, err = familyToRevoke(ctx, e, positional[0])
+		if err != nil {
+			return err
+		}
+		e.note("dry run: would revoke %d grant(s)", len(revoked))
+		return e.printGrants(revoked)
+	}
+	ids, err := e.storer.RevokeGrantFamily(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		grant, err := e.storer.GetGrant(ctx, id)
+		if err != nil {
+			return err
+		}
+		revoked = append(revoked, grant)
+	}
+	return e.printGrants(revoked)
+}
+
+func lineageCmd(ctx context.Context, e env, args []string) error {
+	positional, err := parseArgs(flag.NewFlagSet("lineage", flag.ContinueOnError), args, 1)
+	if err != nil {
+		return err
+	}
+	grant, err := e.storer.GetGrant(ctx, positional[0])
+	if err != nil {
+		return err
+	}
+	lineage, err := ancestors(ctx, e, grant)
+	if err != nil {
+		return err
+	}
+	lineage = append(lineage, grant)
+	children, err := descendants(ctx, e, grant.ID)
+	if err != nil {
+		return err
+	}
+	sortByCreation(children)
+	lineage = append(lineage, children...)
+	return e.printGrants(lineage)
+}
+
+// familyToRevoke returns the Grants RevokeGrantFamily would revoke if called
+// with `id`, sorted by ID.
+func familyToRevoke(ctx context.Context, e env, id string) ([]grants.Grant, error) {
+	grant, err := e.storer.GetGrant(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	members, err := ancestors(ctx, e, grant)
+	if err != nil {
+		return nil, err
+	}
+	members = append(members, grant)
+	family := map[string]grants.Grant{}
+	for _, member := range members {
+		family[member.ID] = member
+	}
+	memberIDs := make([]string, 0, len(grant.AncestorIDs)+1)
+	memberIDs = append(memberIDs, grant.AncestorIDs...)
+	memberIDs = append(memberIDs, grant.ID)
+	for _, memberID := range memberIDs {
+		children, err := descendants(ctx, e, memberID)
+		if err != nil {
+			return nil, err
+		}
+		for _, child := range children {
+			family[child.ID] = child
+		}
+	}
+	var res []grants.Grant
+	for _, member := range family {
+		if !member.State.CanTransitionTo(grants.GrantStateRevoked) {
+			continue
+		}
+		res = append(res, member)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res, nil
+}
+
+// ancestors returns the Grants in the AncestorIDs of `grant`, oldest first,
+// skipping any that no longer exist.
+func ancestors(ctx context.Context, e env, grant grants.Grant) ([]grants.Grant, error) {
+	res := make([]grants.Grant, 0, len(grant.AncestorIDs))
+	for _, id := range grant.AncestorIDs {
+		ancestor, err := e.storer.GetGrant(ctx, id)
+		if errors.Is(err, grants.ErrGrantNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, ancestor)
+	}
+	return res, nil
+}
+
+// descendants returns every Grant with `id` in its AncestorIDs.
+func descendants(ctx context.Context, e env, id string) ([]grants.Grant, error) {
+	var res []grants.Grant
+	var after string
+	for {
+		page, err := e.storer.ListGrantDescendants(ctx, id, after, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, page...)
+		if len(page) < pageSize {
+			return res, nil
+		}
+		after = page[len(page)-1].ID
+	}
+}
+
+// sortByCreation sorts `list` by CreatedAt, breaking ties by ID.
+func sortByCreation(list []grants.Grant) {
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].CreatedAt.Equal(list[j].CreatedAt) {
+			return list[i].CreatedAt.Before(list[j].CreatedAt)
+		}
+		return list[i].ID < list[j].ID
+	})
+}
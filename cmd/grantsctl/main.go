@@ -0,0 +1,214 @@
+// Command grantsctl inspects and manages the Grants stored in PostgreSQL.
+//
+// Usage:
+//
+//	grantsctl [flags] <command> [command flags] [arguments]
+//
+// The commands are:
+//
+//	get <id>                         show a Grant
+//	get-by-source <type> <id>        show the Grant created from a source
+//	list --profile <id>              list the Grants for a profile
+//	list --client <id>               list the Grants for a client
+//	revoke [--dry-run] <id>          revoke a Grant
+//	revoke-family [--dry-run] <id>   revoke a Grant and its whole family
+//	lineage <id>                     show a Grant, its ancestors, and its descendants
+//	migrate up [--dry-run] [--steps n] [--contract]
+//	                                 apply the next n pending migrations, or all of them,
+//	                                 skipping contract migrations unless --contract is
+//	                                 passed
+//	migrate down [--dry-run] [--steps n]
+//	                                 roll back the last n migrations
+//	migrate status                   show which migrations have been applied
+//
+// The database to connect to is read from the --db flag, or the GRANTS_DB
+// environment variable if it's not set. Output is a table unless --output json
+// is passed. Destructive commands accept --dry-run, which shows what they
+// would change without changing it.
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+
+	_ "github.com/lib/pq"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/storers/postgres"
+)
+
+const (
+	// dbEnvVar is the environment variable the connection string is read
+	// from when --db isn't set.
+	dbEnvVar = "GRANTS_DB"
+
+	exitOK    = 0
+	exitError = 1
+	exitUsage = 2
+)
+
+// errUsage is returned by commands that were called with the wrong flags or
+// arguments.
+var errUsage = errors.New("invalid usage")
+
+// env is everything commands need to run.
+type env struct {
+	db     *sql.DB
+	storer postgres.Storer
+	out    io.Writer
+	errOut io.Writer
+	json   bool
+}
+
+// command is a subcommand of grantsctl.
+type command struct {
+	usage string
+	run   func(ctx context.Context, e env, args []string) error
+}
+
+//nolint:gochecknoglobals // a lookup table, never modified
+var commands = map[string]command{
+	"get":           {usage: "get <id>", run: getCmd},
+	"get-by-source": {usage: "get-by-source <source type> <source id>", run: getBySourceCmd},
+	"list":          {usage: "list (--profile <id> | --client <id>) [--after <id>] [--limit n]", run: listCmd},
+	"revoke":        {usage: "revoke [--dry-run] <id>", run: revokeCmd},
+	"revoke-family": {usage: "revoke-family [--dry-run] <id>", run: revokeFamilyCmd},
+	"lineage":       {usage: "lineage <id>", run: lineageCmd},
+	"migrate":       {usage: "migrate (up [--dry-run] [--steps n] [--contract] | down [--dry-run] [--steps n] | status)", run: migrateCmd},
+}
+
+func main() {
+	os.Exit(run(context.Background(), os.Args[1:], os.Stdout, os.Stderr))
+}
+
+// run runs grantsctl with `args`, returning the exit code.
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("grantsctl", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	dsn := flags.String("db", "", "the PostgreSQL connection string; defaults to $"+dbEnvVar)
+	tenant := flags.String("tenant", "", "the tenant to operate on; defaults to the default tenant")
+	output := flags.String("output", "table", "the output format, table or json")
+	ipKeys := flags.String("ip-keys", "", "the key file to decrypt IP addresses with, if they're encrypted")
+	flags.Usage = func() {
+		fmt.Fprintln(stderr, "usage: grantsctl [flags] <command> [command flags] [arguments]")
+		fmt.Fprintln(stderr, "\ncommands:")
+		for _, name := range sortedCommands() {
+			fmt.Fprintln(stderr, "  "+commands[name].usage)
+		}
+		fmt.Fprintln(stderr, "\nflags:")
+		flags.PrintDefaults()
+	}
+	err := flags.Parse(args)
+	if err != nil {
+		return exitUsage
+	}
+	if flags.NArg() < 1 {
+		flags.Usage()
+		return exitUsage
+	}
+	cmd, ok := commands[flags.Arg(0)]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n", flags.Arg(0))
+		flags.Usage()
+		return exitUsage
+	}
+	if *output != "table" && *output != "json" {
+		fmt.Fprintf(stderr, "unknown output format %q, must be table or json\n", *output)
+		flags.Usage()
+		return exitUsage
+	}
+	if *dsn == "" {
+		// read here rather than used as the flag's default, so the
+		// password in it isn't printed with the usage
+		*dsn = os.Getenv(dbEnvVar)
+	}
+	if *dsn == "" {
+		fmt.Fprintf(stderr, "no database to connect to; set --db or $%s\n", dbEnvVar)
+		flags.Usage()
+		return exitUsage
+	}
+
+	db, err := sql.Open("postgres", *dsn)
+	if err != nil {
+		fmt.Fprintf(stderr, "error connecting to database: %s\n", err)
+		return exitError
+	}
+	defer db.Close() //nolint:errcheck // nothing to be done about it
+	var opts []postgres.Option
+	if *ipKeys != "" {
+		keys, err := postgres.NewFileKeyProvider(*ipKeys)
+		if err != nil {
+			fmt.Fprintf(stderr, "error loading IP keys: %s\n", err)
+			return exitError
+		}
+		opts = append(opts, postgres.WithIPEncryption(keys))
+	}
+	if *tenant != "" {
+		ctx = grants.WithTenant(ctx, *tenant)
+	}
+
+	e := env{
+		db:     db,
+		storer: postgres.NewStorer(ctx, db, opts...),
+		out:    stdout,
+		errOut: stderr,
+		json:   *output == "json",
+	}
+	err = cmd.run(ctx, e, flags.Args()[1:])
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(stderr, err)
+		fmt.Fprintln(stderr, "usage: grantsctl [flags] "+cmd.usage)
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "error: %s\n", err)
+		return exitError
+	}
+	return exitOK
+}
+
+// sortedCommands returns the names of the commands, sorted.
+func sortedCommands() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// parseArgs parses `args` with `flags`, which can be interspersed with the
+// positional arguments, and returns the positional arguments, which must
+// number exactly `want`.
+func parseArgs(flags *flag.FlagSet, args []string, want int) ([]string, error) {
+	flags.SetOutput(io.Discard)
+	var positional []string
+	for {
+		err := flags.Parse(args)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", errUsage, err)
+		}
+		args = flags.Args()
+		if len(args) < 1 {
+			break
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+	if len(positional) != want {
+		return nil, fmt.Errorf("%w: expected %d argument(s), got %d", errUsage, want, len(positional))
+	}
+	for _, arg := range positional {
+		if strings.TrimSpace(arg) == "" {
+			return nil, fmt.Errorf("%w: arguments can't be empty", errUsage)
+		}
+	}
+	return positional, nil
+}
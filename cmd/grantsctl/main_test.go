@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"lockbox.dev/grants"
+	grantshttp "lockbox.dev/grants/http"
+)
+
+func TestUsageErrors(t *testing.T) {
+	t.Parallel()
+
+	// none of these get as far as connecting to the database
+	tests := map[string][]string{
+		"no-command":       {"--db", "postgres://localhost/grants"},
+		"unknown-command":  {"--db", "postgres://localhost/grants", "frobnicate"},
+		"bad-output":       {"--db", "postgres://localhost/grants", "--output", "yaml", "get", "abc"},
+		"get-no-id":        {"--db", "postgres://localhost/grants", "get"},
+		"get-two-ids":      {"--db", "postgres://localhost/grants", "get", "abc", "def"},
+		"by-source-one":    {"--db", "postgres://localhost/grants", "get-by-source", "email"},
+		"list-neither":     {"--db", "postgres://localhost/grants", "list"},
+		"list-both":        {"--db", "postgres://localhost/grants", "list", "--profile", "a", "--client", "b"},
+		"list-bad-limit":   {"--db", "postgres://localhost/grants", "list", "--profile", "a", "--limit", "0"},
+		"revoke-bad-flag":  {"--db", "postgres://localhost/grants", "revoke", "--force", "abc"},
+		"migrate-nothing":  {"--db", "postgres://localhost/grants", "migrate"},
+		"migrate-sideways": {"--db", "postgres://localhost/grants", "migrate", "sideways"},
+		"migrate-no-steps": {"--db", "postgres://localhost/grants", "migrate", "down", "--steps", "0"},
+		"migrate-up-steps": {"--db", "postgres://localhost/grants", "migrate", "up", "--steps", "-1"},
+	}
+	for name, args := range tests {
+		name, args := name, args
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			var stdout, stderr bytes.Buffer
+			code := run(context.Background(), args, &stdout, &stderr)
+			if code != exitUsage {
+				t.Errorf("Expected exit code %d, got %d: %s", exitUsage, code, stderr.String())
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("Expected no output, got %q", stdout.String())
+			}
+			if !strings.Contains(stderr.String(), "usage") {
+				t.Errorf("Expected usage to be printed, got %q", stderr.String())
+			}
+		})
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	t.Parallel()
+
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "")
+	positional, err := parseArgs(flags, []string{"abc", "--dry-run"}, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error parsing args: %s", err)
+	}
+	if diff := cmp.Diff([]string{"abc"}, positional); diff != "" {
+		t.Errorf("Unexpected positional args (-wanted, +got): %s", diff)
+	}
+	if !*dryRun {
+		t.Errorf("Expected flag after positional argument to be parsed")
+	}
+}
+
+func TestPrintGrants(t *testing.T) {
+	t.Parallel()
+
+	created := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	list := []grants.Grant{
+		{ID: "first", SourceType: "email", ClientID: "web", ProfileID: "user", CreatedAt: created, State: grants.GrantStateUsed, UsedAt: created.Add(time.Minute)},
+		{ID: "second", SourceType: "refresh_token", ClientID: "web", ProfileID: "user", CreatedAt: created.Add(time.Minute), State: grants.GrantStateActive, AncestorIDs: []string{"first"}},
+	}
+
+	var out bytes.Buffer
+	e := env{out: &out}
+	err := e.printGrants(list)
+	if err != nil {
+		t.Fatalf("Unexpected error printing grants: %s", err)
+	}
+	expected := `ID      STATE   SOURCE TYPE    CLIENT  PROFILE  CREATED               USED
+first   used    email          web     user     2026-10-18T12:00:00Z  2026-10-18T12:01:00Z
+second  active  refresh_token  web     user     2026-10-18T12:01:00Z  -
+`
+	if diff := cmp.Diff(expected, out.String()); diff != "" {
+		t.Errorf("Unexpected table (-wanted, +got): %s", diff)
+	}
+
+	out.Reset()
+	e.json = true
+	err = e.printGrants(list)
+	if err != nil {
+		t.Fatalf("Unexpected error printing grants: %s", err)
+	}
+	var decoded []grantshttp.Grant
+	err = json.Unmarshal(out.Bytes(), &decoded)
+	if err != nil {
+		t.Fatalf("Error decoding output %q: %s", out.String(), err)
+	}
+	if diff := cmp.Diff([]grantshttp.Grant{grantshttp.FromGrant(list[0]), grantshttp.FromGrant(list[1])}, decoded); diff != "" {
+		t.Errorf("Unexpected JSON (-wanted, +got): %s", diff)
+	}
+
+	// an empty list is still a JSON array
+	out.Reset()
+	err = e.printGrants(nil)
+	if err != nil {
+		t.Fatalf("Unexpected error printing grants: %s", err)
+	}
+	if strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("Expected an empty array, got %q", out.String())
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"time"
+
+	migrate "github.com/rubenv/sql-migrate"
+
+	"lockbox.dev/grants/storers/postgres"
+)
+
+// dialect is the sql-migrate dialect of the database.
+const dialect = "postgres"
+
+// migrationStatus describes a migration and whether it has been applied.
+type migrationStatus struct {
+	ID        string     `json:"id"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+func migrateCmd(ctx context.Context, e env, args []string) error {
+	if len(args) < 1 {
+		return fmt.Errorf("%w: expected up, down, or status", errUsage)
+	}
+	switch args[0] {
+	case "up":
+		return migrateUpCmd(ctx, e, args[1:])
+	case "down":
+		return migrateDownCmd(ctx, e, args[1:])
+	case "status":
+		return migrateStatusCmd(ctx, e, args[1:])
+	}
+	return fmt.Errorf("%w: unknown migrate command %q", errUsage, args[0])
+}
+
+func migrateUpCmd(_ context.Context, e env, args []string) error {
+	flags := flag.NewFlagSet("migrate up", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "show the migrations that would be applied without applying them")
+	steps := flags.Int("steps", 0, "the number of migrations to apply; defaults to all of them")
+	contract := flags.Bool("contract", false, "also apply contract migrations, which break older releases")
+	_, err := parseArgs(flags, args, 0)
+	if err != nil {
+		return err
+	}
+	if *steps < 0 {
+		return fmt.Errorf("%w: --steps can't be negative", errUsage)
+	}
+	source, err := migrationsSource(e, *contract)
+	if err != nil {
+		return err
+	}
+	planned, err := plannedMigrations(e, source, migrate.Up, *steps)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		e.note("dry run: would apply %d migration(s)", len(planned))
+		return e.printMigrations(planned)
+	}
+	_, err = migrate.ExecMax(e.db, dialect, source, migrate.Up, *steps)
+	if err != nil {
+		return err
+	}
+	e.note("applied %d migration(s)", len(planned))
+	return e.printMigrations(planned)
+}
+
+func migrateDownCmd(_ context.Context, e env, args []string) error {
+	flags := flag.NewFlagSet("migrate down", flag.ContinueOnError)
+	dryRun := flags.Bool("dry-run", false, "show the migrations that would be rolled back without rolling them back")
+	steps := flags.Int("steps", 1, "the number of migrations to roll back")
+	_, err := parseArgs(flags, args, 0)
+	if err != nil {
+		return err
+	}
+	if *steps < 1 {
+		return fmt.Errorf("%w: --steps must be positive", errUsage)
+	}
+	source, err := migrationsSource(e, false)
+	if err != nil {
+		return err
+	}
+	planned, err := plannedMigrations(e, source, migrate.Down, *steps)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		e.note("dry run: would roll back %d migration(s)", len(planned))
+		return e.printMigrations(planned)
+	}
+	_, err = migrate.ExecMax(e.db, dialect, source, migrate.Down, *steps)
+	if err != nil {
+		return err
+	}
+	e.note("rolled back %d migration(s)", len(planned))
+	return e.printMigrations(planned)
+}
+
+func migrateStatusCmd(_ context.Context, e env, args []string) error {
+	_, err := parseArgs(flag.NewFlagSet("migrate status", flag.ContinueOnError), args, 0)
+	if err != nil {
+		return err
+	}
+	migrations, err := postgres.MigrationsSource().FindMigrations()
+	if err != nil {
+		return err
+	}
+	records, err := migrate.GetMigrationRecords(e.db, dialect)
+	if err != nil {
+		return err
+	}
+	applied := map[string]time.Time{}
+	for _, record := range records {
+		applied[record.Id] = record.AppliedAt
+	}
+	res := make([]migrationStatus, 0, len(migrations))
+	for _, migration := range migrations {
+		status := migrationStatus{ID: migration.Id}
+		if appliedAt, ok := applied[migration.Id]; ok {
+			status.AppliedAt = &appliedAt
+		}
+		res = append(res, status)
+	}
+	return e.printMigrations(res)
+}
+
+// migrationsSource returns the migrations to run: the ones
+// postgres.ApplyMigrations would run, or every migration, including contract
+// migrations, if `contract` is true.
+func migrationsSource(e env, contract bool) (migrate.MigrationSource, error) { //nolint:ireturn // either source can be returned
+	if contract {
+		return postgres.MigrationsSource(), nil
+	}
+	return postgres.ExpandMigrationsSource(e.db)
+}
+
+// plannedMigrations returns the migrations from `source` that would be run in
+// `direction`, up to `max` of them, or all of them if `max` is 0.
+func plannedMigrations(e env, source migrate.MigrationSource, direction migrate.MigrationDirection, max int) ([]migrationStatus, error) {
+	planned, _, err := migrate.PlanMigration(e.db, dialect, source, direction, max)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]migrationStatus, 0, len(planned))
+	for _, migration := range planned {
+		res = append(res, migrationStatus{ID: migration.Id})
+	}
+	return res, nil
+}
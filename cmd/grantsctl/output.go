@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"lockbox.dev/grants"
+	grantshttp "lockbox.dev/grants/http"
+)
+
+// note writes a message meant for the operator, not for parsing, to the
+// error output, so it never ends up mixed into JSON output.
+func (e env) note(format string, args ...interface{}) {
+	fmt.Fprintf(e.errOut, format+"\n", args...)
+}
+
+// printGrant writes `grant` to the output, as a JSON object or as a table of
+// its fields.
+func (e env) printGrant(grant grants.Grant) error {
+	if e.json {
+		return e.printJSON(grantshttp.FromGrant(grant))
+	}
+	w := tabwriter.NewWriter(e.out, 0, 0, 2, ' ', 0) //nolint:gomnd // padding
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"ID", grant.ID},
+		{"Tenant", grant.TenantID},
+		{"State", string(grant.State)},
+		{"Source Type", grant.SourceType},
+		{"Source ID", grant.SourceID},
+		{"Ancestors", strings.Join(grant.AncestorIDs, ", ")},
+		{"Created", formatTime(grant.CreatedAt)},
+		{"Used", formatTime(grant.UsedAt)},
+		{"Scopes", strings.Join(grant.Scopes, " ")},
+		{"Account", grant.AccountID},
+		{"Profile", grant.ProfileID},
+		{"Client", grant.ClientID},
+		{"Create IP", grant.CreateIP},
+		{"Use IP", grant.UseIP},
+		{"Key Thumbprint", grant.KeyThumbprint},
+	}
+	for _, field := range fields {
+		fmt.Fprintf(w, "%s:\t%s\n", field.name, field.value)
+	}
+	return w.Flush()
+}
+
+// printGrants writes `list` to the output, as a JSON array or as a table
+// with a row for each Grant.
+func (e env) printGrants(list []grants.Grant) error {
+	if e.json {
+		res := make([]grantshttp.Grant, 0, len(list))
+		for _, grant := range list {
+			res = append(res, grantshttp.FromGrant(grant))
+		}
+		return e.printJSON(res)
+	}
+	w := tabwriter.NewWriter(e.out, 0, 0, 2, ' ', 0) //nolint:gomnd // padding
+	fmt.Fprintln(w, "ID\tSTATE\tSOURCE TYPE\tCLIENT\tPROFILE\tCREATED\tUSED")
+	for _, grant := range list {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", grant.ID, grant.State, grant.SourceType,
+			grant.ClientID, grant.ProfileID, formatTime(grant.CreatedAt), formatTime(grant.UsedAt))
+	}
+	return w.Flush()
+}
+
+// printMigrations writes `list` to the output, as a JSON array or as a table
+// with a row for each migration.
+func (e env) printMigrations(list []migrationStatus) error {
+	if e.json {
+		return e.printJSON(list)
+	}
+	w := tabwriter.NewWriter(e.out, 0, 0, 2, ' ', 0) //nolint:gomnd // padding
+	fmt.Fprintln(w, "MIGRATION\tAPPLIED")
+	for _, migration := range list {
+		applied := "-"
+		if migration.AppliedAt != nil {
+			applied = formatTime(*migration.AppliedAt)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", migration.ID, applied)
+	}
+	return w.Flush()
+}
+
+func (e env) printJSON(value interface{}) error {
+	enc := json.NewEncoder(e.out)
+	enc.SetIndent("", "  ")
+	return enc.Encode(value)
+}
+
+// formatTime formats `t` for a table, or returns "-" if it's unset.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,223 @@
+package grants
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	// DeviceSlowDownIncrement is how much the polling interval of a
+	// DeviceAuthorization grows by every time it's polled too quickly, as
+	// required by RFC 8628, section 3.5.
+	DeviceSlowDownIncrement = 5 * time.Second
+)
+
+var (
+	// ErrDeviceAuthorizationNotFound is returned when a DeviceAuthorization
+	// is being polled or referenced, but does not exist in the
+	// DeviceStorer being used.
+	ErrDeviceAuthorizationNotFound = errors.New("device authorization not found")
+	// ErrDeviceAuthorizationAlreadyExists is returned when a
+	// DeviceAuthorization is being stored, but one with the same ID, or
+	// the same UserCode in the same tenant, already exists. User codes
+	// are short, so this is expected to happen occasionally; a new user
+	// code should be generated and the DeviceAuthorization stored again.
+	// The user codes of expired DeviceAuthorizations stay in use until
+	// they're deleted by PurgeDeviceAuthorizations.
+	ErrDeviceAuthorizationAlreadyExists = errors.New("device authorization with that ID or user code already exists")
+	// ErrDeviceAuthorizationPending is returned when a DeviceAuthorization
+	// is polled before the user has approved or denied it. It corresponds
+	// to the authorization_pending error of RFC 8628.
+	ErrDeviceAuthorizationPending = errors.New("device authorization is pending")
+	// ErrDeviceSlowDown is returned when a DeviceAuthorization is polled
+	// more often than its Interval allows. It corresponds to the
+	// slow_down error of RFC 8628.
+	ErrDeviceSlowDown = errors.New("device authorization polled too quickly, slow down")
+	// ErrDeviceAccessDenied is returned when a DeviceAuthorization the
+	// user denied is polled. It corresponds to the access_denied error of
+	// RFC 8628.
+	ErrDeviceAccessDenied = errors.New("device authorization was denied")
+	// ErrDeviceCodeExpired is returned when a DeviceAuthorization is used
+	// after it expired without being approved or denied. It corresponds to
+	// the expired_token error of RFC 8628.
+	ErrDeviceCodeExpired = errors.New("device authorization has expired")
+	// ErrDeviceAuthorizationNotPending is returned when a
+	// DeviceAuthorization that has already been approved or denied is
+	// being approved or denied again.
+	ErrDeviceAuthorizationNotPending = errors.New("device authorization has already been approved or denied")
+	// ErrInvalidDeviceStatus is returned when a DeviceAuthorization is
+	// being decided with a DeviceStatus other than DeviceStatusApproved
+	// or DeviceStatusDenied.
+	ErrInvalidDeviceStatus = errors.New("device authorizations can only be approved or denied")
+)
+
+// DeviceStatus is the state of a DeviceAuthorization.
+type DeviceStatus string
+
+const (
+	// DeviceStatusPending is the status of a DeviceAuthorization the user
+	// hasn't acted on yet.
+	DeviceStatusPending DeviceStatus = "pending"
+	// DeviceStatusApproved is the status of a DeviceAuthorization the user
+	// approved.
+	DeviceStatusApproved DeviceStatus = "approved"
+	// DeviceStatusDenied is the status of a DeviceAuthorization the user
+	// denied.
+	DeviceStatusDenied DeviceStatus = "denied"
+)
+
+// DeviceAuthorization represents a request from a device to be granted access,
+// as described by RFC 8628. The device polls it until the user approves or
+// denies it on another device, and approval creates a Grant for the device.
+type DeviceAuthorization struct {
+	ID           string        // a unique ID, derived from the device code; never the device code itself
+	TenantID     string        // the tenant the authorization belongs to; empty for the default tenant
+	UserCode     string        // the short code the user enters to approve or deny the authorization; unique across the tenant's authorizations
+	ClientID     string        // the client requesting access
+	Scopes       []string      // the scopes of access the client requested
+	CreatedAt    time.Time     // when access was requested
+	ExpiresAt    time.Time     // when the authorization expires if it's still pending
+	Interval     time.Duration // the minimum time the device must wait between polls
+	LastPolledAt time.Time     // when the device last polled; zero if it never has
+	Status       DeviceStatus  // whether the authorization is pending, approved, or denied
+	ProfileID    string        // the unique ID representing the user that approved the authorization
+	AccountID    string        // the ID of the account that was used to approve the authorization
+	GrantID      string        // the ID of the Grant created when the authorization was approved
+}
+
+// DeviceDecision represents the user approving or denying a
+// DeviceAuthorization.
+type DeviceDecision struct {
+	UserCode  string       // the user code of the authorization being decided
+	Status    DeviceStatus // DeviceStatusApproved or DeviceStatusDenied
+	ProfileID string       // the unique ID representing the user; only for approvals
+	AccountID string       // the ID of the account the user approved with; only for approvals
+	GrantID   string       // the ID of the Grant created for the device; only for approvals
+	Time      time.Time    // when the decision was made
+}
+
+// Check returns the error polling `d` at `at` should result in, without
+// enforcing its Interval: nil if it was approved, or
+// ErrDeviceAuthorizationPending, ErrDeviceAccessDenied, or
+// ErrDeviceCodeExpired otherwise.
+func (d DeviceAuthorization) Check(at time.Time) error {
+	switch d.Status {
+	case DeviceStatusApproved:
+		return nil
+	case DeviceStatusDenied:
+		return ErrDeviceAccessDenied
+	}
+	if !at.Before(d.ExpiresAt) {
+		return ErrDeviceCodeExpired
+	}
+	return ErrDeviceAuthorizationPending
+}
+
+// Poll returns `d` as it should be stored after the device polls it at `at`,
+// and the error the poll results in. Polling sooner than Interval after the
+// last poll results in an ErrDeviceSlowDown error and grows the Interval by
+// DeviceSlowDownIncrement; otherwise, the result is the same as Check.
+//
+// The returned DeviceAuthorization should be stored whatever error is
+// returned, so the poll and any change to the Interval are recorded.
+func (d DeviceAuthorization) Poll(at time.Time) (DeviceAuthorization, error) {
+	tooSoon := !d.LastPolledAt.IsZero() && at.Sub(d.LastPolledAt) < d.Interval
+	d.LastPolledAt = at
+	if tooSoon && d.Status == DeviceStatusPending {
+		d.Interval += DeviceSlowDownIncrement
+		return d, ErrDeviceSlowDown
+	}
+	return d, d.Check(at)
+}
+
+// Decide returns `d` with `decision` applied. It returns an
+// ErrDeviceAuthorizationNotPending error if `d` has already been approved or
+// denied, and an ErrDeviceCodeExpired error if it expired before the
+// decision was made.
+func (d DeviceAuthorization) Decide(decision DeviceDecision) (DeviceAuthorization, error) {
+	if decision.Status != DeviceStatusApproved && decision.Status != DeviceStatusDenied {
+		return DeviceAuthorization{}, ErrInvalidDeviceStatus
+	}
+	if d.Status != DeviceStatusPending {
+		return DeviceAuthorization{}, ErrDeviceAuthorizationNotPending
+	}
+	if !decision.Time.Before(d.ExpiresAt) {
+		return DeviceAuthorization{}, ErrDeviceCodeExpired
+	}
+	d.Status = decision.Status
+	if decision.Status == DeviceStatusApproved {
+		d.ProfileID = decision.ProfileID
+		d.AccountID = decision.AccountID
+		d.GrantID = decision.GrantID
+	}
+	return d, nil
+}
+
+// DeviceStorer is implemented by Storers that can persist DeviceAuthorizations.
+// Like Storer, every method is scoped to the tenant returned by
+// TenantFromContext. Storers that wrap another Storer, like the ones returned
+// by WithHashedSources, don't implement it; the underlying Storer should be
+// used instead.
+type DeviceStorer interface {
+	// CreateDeviceAuthorization stores `auth`, returning an
+	// ErrDeviceAuthorizationAlreadyExists error if its ID or UserCode is
+	// already in use. It's stored under the tenant returned by
+	// ResolveTenantID.
+	CreateDeviceAuthorization(ctx context.Context, auth DeviceAuthorization) error
+
+	// GetDeviceAuthorization returns the DeviceAuthorization with the
+	// ID `id`, without recording a poll.
+	GetDeviceAuthorization(ctx context.Context, id string) (DeviceAuthorization, error)
+
+	// GetDeviceAuthorizationByUserCode returns the DeviceAuthorization
+	// with the UserCode `userCode`.
+	GetDeviceAuthorizationByUserCode(ctx context.Context, userCode string) (DeviceAuthorization, error)
+
+	// PollDeviceAuthorization atomically applies
+	// DeviceAuthorization.Poll to the DeviceAuthorization with the ID
+	// `id`, storing and returning the result along with the error the
+	// poll resulted in.
+	PollDeviceAuthorization(ctx context.Context, id string, at time.Time) (DeviceAuthorization, error)
+
+	// DecideDeviceAuthorization atomically applies
+	// DeviceAuthorization.Decide to the DeviceAuthorization with the
+	// UserCode of `decision`, storing and returning the result.
+	DecideDeviceAuthorization(ctx context.Context, decision DeviceDecision) (DeviceAuthorization, error)
+}
+
+// DeviceAuthorizationPurger is implemented by DeviceStorers that can delete
+// DeviceAuthorizations once they've expired, so they don't accumulate and
+// their UserCodes can be reused. Like IPRetainer, it applies to every tenant.
+type DeviceAuthorizationPurger interface {
+	// DeleteExpiredDeviceAuthorizations deletes up to `limit`
+	// DeviceAuthorizations that expired before `expiredBefore`, whether
+	// they were approved, denied, or left pending. It returns the number
+	// of DeviceAuthorizations deleted; a result less than `limit` means
+	// there are none left to delete.
+	DeleteExpiredDeviceAuthorizations(ctx context.Context, expiredBefore time.Time, limit int) (int, error)
+}
+
+// PurgeDeviceAuthorizations deletes every DeviceAuthorization in `purger`
+// that expired more than `retainFor` ago, `batchSize` at a time, returning the
+// number deleted. It's meant to be run periodically; `retainFor` gives devices
+// that were approved just before their authorization expired time to collect
+// their Grant. If `batchSize` is less than 1, an ErrInvalidBatchSize error is
+// returned.
+func PurgeDeviceAuthorizations(ctx context.Context, purger DeviceAuthorizationPurger, retainFor time.Duration, batchSize int) (int, error) {
+	if batchSize < 1 {
+		return 0, ErrInvalidBatchSize
+	}
+	cutoff := time.Now().Add(-retainFor)
+	var total int
+	for {
+		count, err := purger.DeleteExpiredDeviceAuthorizations(ctx, cutoff, batchSize)
+		total += count
+		if err != nil {
+			return total, err
+		}
+		if count < batchSize {
+			return total, nil
+		}
+	}
+}
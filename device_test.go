@@ -0,0 +1,28 @@
+package grants_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lockbox.dev/grants"
+)
+
+// emptyPurger is a DeviceAuthorizationPurger with nothing to delete.
+type emptyPurger struct{}
+
+func (emptyPurger) DeleteExpiredDeviceAuthorizations(_ context.Context, _ time.Time, _ int) (int, error) {
+	return 0, nil
+}
+
+func TestPurgeDeviceAuthorizationsInvalidBatchSize(t *testing.T) {
+	t.Parallel()
+
+	for _, batchSize := range []int{0, -1} {
+		_, err := grants.PurgeDeviceAuthorizations(context.Background(), emptyPurger{}, time.Hour, batchSize)
+		if !errors.Is(err, grants.ErrInvalidBatchSize) {
+			t.Errorf("Expected error %v for a batch size of %d, got %v", grants.ErrInvalidBatchSize, batchSize, err)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+// Package dpop implements a grants.ProofVerifier that binds grants to a key
+// held by the client, using proofs modeled on OAuth 2.0 Demonstrating Proof of
+// Possession (DPoP), RFC 9449.
+//
+// A Grant is bound to a key by setting its KeyThumbprint to the RFC 7638
+// thumbprint of the client's public key. To exchange the Grant, the client
+// must present a proof: a JWT with a "typ" of "dpop+jwt", signed by the
+// private key, carrying the public key in its "jwk" header.
+package dpop
+
+import (
+	"context"
+	"crypto"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/jose"
+)
+
+const (
+	// ProofType is the "typ" header value DPoP proofs must use.
+	ProofType = "dpop+jwt"
+
+	// DefaultMaxAge is how old a proof may be when no MaxAge is set on
+	// the Verifier.
+	DefaultMaxAge = 5 * time.Minute
+
+	// clockSkew is how far in the future a proof's iat may be, to allow
+	// for clock drift between client and server.
+	clockSkew = 30 * time.Second
+)
+
+// Claims are the claims in the payload of a DPoP proof.
+type Claims struct {
+	ID       string `json:"jti"`
+	Method   string `json:"htm"`
+	URL      string `json:"htu"`
+	IssuedAt int64  `json:"iat"`
+}
+
+// Verifier is a grants.ProofVerifier that checks DPoP proofs.
+type Verifier struct {
+	// Method is the HTTP method proofs must be bound to. If empty, the
+	// htm claim isn't checked.
+	Method string
+
+	// URL is the HTTP URL proofs must be bound to, without query or
+	// fragment. If empty, the htu claim isn't checked.
+	URL string
+
+	// MaxAge is how old a proof may be before it's rejected. If zero,
+	// DefaultMaxAge is used.
+	MaxAge time.Duration
+
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+}
+
+// VerifyProof checks that the Proof in `use` is a valid DPoP proof signed by
+// the key whose thumbprint is the KeyThumbprint of `grant`. Any failure is
+// returned as an error wrapping grants.ErrInvalidProof.
+func (v Verifier) VerifyProof(_ context.Context, grant grants.Grant, use grants.GrantUse) error {
+	if use.Proof == "" {
+		return fmt.Errorf("%w: no proof presented", grants.ErrInvalidProof)
+	}
+	proof, err := jose.Parse(use.Proof)
+	if err != nil {
+		return fmt.Errorf("%w: %s", grants.ErrInvalidProof, err.Error())
+	}
+	if proof.Header.Type != ProofType {
+		return fmt.Errorf("%w: unexpected typ %q", grants.ErrInvalidProof, proof.Header.Type)
+	}
+	if proof.Header.JWK == nil {
+		return fmt.Errorf("%w: no jwk header", grants.ErrInvalidProof)
+	}
+	key, err := proof.Header.JWK.PublicKey()
+	if err != nil {
+		return fmt.Errorf("%w: %s", grants.ErrInvalidProof, err.Error())
+	}
+	var claims Claims
+	err = proof.UnmarshalClaims(key, &claims)
+	if err != nil {
+		return fmt.Errorf("%w: %s", grants.ErrInvalidProof, err.Error())
+	}
+	thumbprint, err := proof.Header.JWK.Thumbprint()
+	if err != nil {
+		return fmt.Errorf("%w: %s", grants.ErrInvalidProof, err.Error())
+	}
+	if subtle.ConstantTimeCompare([]byte(thumbprint), []byte(grant.KeyThumbprint)) != 1 {
+		return fmt.Errorf("%w: proof key doesn't match grant", grants.ErrInvalidProof)
+	}
+	return v.checkClaims(claims)
+}
+
+func (v Verifier) checkClaims(claims Claims) error {
+	if claims.ID == "" {
+		return fmt.Errorf("%w: no jti claim", grants.ErrInvalidProof)
+	}
+	if v.Method != "" && claims.Method != v.Method {
+		return fmt.Errorf("%w: htm %q doesn't match %q", grants.ErrInvalidProof, claims.Method, v.Method)
+	}
+	if v.URL != "" && stripURL(claims.URL) != stripURL(v.URL) {
+		return fmt.Errorf("%w: htu %q doesn't match %q", grants.ErrInvalidProof, claims.URL, v.URL)
+	}
+	now := time.Now()
+	if v.Now != nil {
+		now = v.Now()
+	}
+	maxAge := v.MaxAge
+	if maxAge == 0 {
+		maxAge = DefaultMaxAge
+	}
+	issued := time.Unix(claims.IssuedAt, 0)
+	if issued.Before(now.Add(-maxAge)) {
+		return fmt.Errorf("%w: proof issued at %s is too old", grants.ErrInvalidProof, issued)
+	}
+	if issued.After(now.Add(clockSkew)) {
+		return fmt.Errorf("%w: proof issued at %s is in the future", grants.ErrInvalidProof, issued)
+	}
+	return nil
+}
+
+// stripURL removes the query and fragment from a URL, as RFC 9449 requires
+// when comparing htu values.
+func stripURL(in string) string {
+	parsed, err := url.Parse(in)
+	if err != nil {
+		return in
+	}
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	return parsed.String()
+}
+
+// Thumbprint returns the KeyThumbprint a Grant should be bound to for `key`.
+func Thumbprint(key crypto.PublicKey) (string, error) {
+	jwk, err := jose.NewJWK(key)
+	if err != nil {
+		return "", err
+	}
+	return jwk.Thumbprint()
+}
+
+// NewProof creates a DPoP proof signed by `key` using the JWS algorithm `alg`,
+// bound to the HTTP `method` and `target` URL, issued at the current time.
+func NewProof(key crypto.Signer, alg, method, target string) (string, error) {
+	jwk, err := jose.NewJWK(key.Public())
+	if err != nil {
+		return "", err
+	}
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(Claims{
+		ID:       id,
+		Method:   method,
+		URL:      target,
+		IssuedAt: time.Now().Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return jose.Sign(jose.Header{
+		Algorithm: alg,
+		Type:      ProofType,
+		JWK:       &jwk,
+	}, payload, key)
+}
@@ -0,0 +1,164 @@
+package dpop_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/dpop"
+	"lockbox.dev/grants/storers/memory"
+)
+
+const (
+	tokenMethod = "POST"
+	tokenURL    = "https://auth.lockbox.dev/token"
+)
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %s", err)
+	}
+	return key
+}
+
+func boundGrant(t *testing.T, key *ecdsa.PrivateKey) grants.Grant {
+	t.Helper()
+	thumbprint, err := dpop.Thumbprint(key.Public())
+	if err != nil {
+		t.Fatalf("Error computing thumbprint: %s", err)
+	}
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatalf("Error generating ID: %s", err)
+	}
+	return grants.Grant{
+		ID:            id,
+		SourceType:    "manual",
+		SourceID:      id,
+		ProfileID:     "tester",
+		ClientID:      "testrunner",
+		KeyThumbprint: thumbprint,
+	}
+}
+
+func TestVerifyProof(t *testing.T) {
+	t.Parallel()
+
+	key := newKey(t)
+	grant := boundGrant(t, key)
+	verifier := dpop.Verifier{Method: tokenMethod, URL: tokenURL}
+
+	proof, err := dpop.NewProof(key, "ES256", tokenMethod, tokenURL+"?unused=query")
+	if err != nil {
+		t.Fatalf("Error creating proof: %s", err)
+	}
+	err = verifier.VerifyProof(context.Background(), grant, grants.GrantUse{Grant: grant.ID, Proof: proof})
+	if err != nil {
+		t.Errorf("Unexpected error verifying proof: %s", err)
+	}
+}
+
+func TestVerifyProofFailures(t *testing.T) {
+	t.Parallel()
+
+	key := newKey(t)
+	grant := boundGrant(t, key)
+
+	otherKeyProof, err := dpop.NewProof(newKey(t), "ES256", tokenMethod, tokenURL)
+	if err != nil {
+		t.Fatalf("Error creating proof: %s", err)
+	}
+	wrongMethodProof, err := dpop.NewProof(key, "ES256", "GET", tokenURL)
+	if err != nil {
+		t.Fatalf("Error creating proof: %s", err)
+	}
+	wrongURLProof, err := dpop.NewProof(key, "ES256", tokenMethod, "https://evil.example/token")
+	if err != nil {
+		t.Fatalf("Error creating proof: %s", err)
+	}
+	validProof, err := dpop.NewProof(key, "ES256", tokenMethod, tokenURL)
+	if err != nil {
+		t.Fatalf("Error creating proof: %s", err)
+	}
+
+	tests := map[string]struct {
+		proof    string
+		verifier dpop.Verifier
+	}{
+		"missing":     {proof: "", verifier: dpop.Verifier{}},
+		"garbage":     {proof: "not-a-jwt", verifier: dpop.Verifier{}},
+		"otherKey":    {proof: otherKeyProof, verifier: dpop.Verifier{}},
+		"wrongMethod": {proof: wrongMethodProof, verifier: dpop.Verifier{Method: tokenMethod}},
+		"wrongURL":    {proof: wrongURLProof, verifier: dpop.Verifier{URL: tokenURL}},
+		"expired": {proof: validProof, verifier: dpop.Verifier{Now: func() time.Time {
+			return time.Now().Add(time.Hour)
+		}}},
+		"future": {proof: validProof, verifier: dpop.Verifier{Now: func() time.Time {
+			return time.Now().Add(-time.Hour)
+		}}},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := test.verifier.VerifyProof(context.Background(), grant, grants.GrantUse{Grant: grant.ID, Proof: test.proof})
+			if !errors.Is(err, grants.ErrInvalidProof) {
+				t.Errorf("Expected error %v, got %v", grants.ErrInvalidProof, err)
+			}
+		})
+	}
+}
+
+func TestStolenGrantIDCantBeExchanged(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	key := newKey(t)
+	grant := boundGrant(t, key)
+
+	base, err := memory.NewStorer()
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+	storer := grants.WithProofVerifier(base, dpop.Verifier{Method: tokenMethod, URL: tokenURL})
+	err = storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Fatalf("Error creating grant: %s", err)
+	}
+
+	// an attacker with only the grant ID, or with their own key, gets nothing
+	stolenProof, err := dpop.NewProof(newKey(t), "ES256", tokenMethod, tokenURL)
+	if err != nil {
+		t.Fatalf("Error creating proof: %s", err)
+	}
+	for _, proof := range []string{"", stolenProof} {
+		_, err = storer.ExchangeGrant(ctx, grants.GrantUse{Grant: grant.ID, IP: "6.6.6.6", Time: time.Now(), Proof: proof})
+		if !errors.Is(err, grants.ErrInvalidProof) {
+			t.Errorf("Expected error %v, got %v", grants.ErrInvalidProof, err)
+		}
+	}
+
+	// and the failed attempts don't burn the grant for its owner
+	proof, err := dpop.NewProof(key, "ES256", tokenMethod, tokenURL)
+	if err != nil {
+		t.Fatalf("Error creating proof: %s", err)
+	}
+	used, err := storer.ExchangeGrant(ctx, grants.GrantUse{Grant: grant.ID, IP: "8.8.8.8", Time: time.Now(), Proof: proof})
+	if err != nil {
+		t.Fatalf("Unexpected error exchanging grant: %s", err)
+	}
+	if !used.Used() || used.UseIP != "8.8.8.8" {
+		t.Errorf("Expected grant to be used from 8.8.8.8, got %+v", used)
+	}
+}
@@ -0,0 +1,212 @@
+// Package export produces data subject access exports: a complete,
+// machine-readable JSON document of every grant stored for a profile, to
+// answer GDPR access requests.
+//
+// Exports follow a versioned schema. Version 1 of the schema is described by
+// the JSON Schema returned by Schema, and has the following shape:
+//
+//	{
+//	  "schema": "https://lockbox.dev/grants/export/v1",
+//	  "version": 1,
+//	  "profile_id": "the profile the export is for",
+//	  "generated_at": "RFC 3339 timestamp the export was started at",
+//	  "grants": [
+//	    {
+//	      "id": "the grant's ID",
+//	      "source_type": "how the user identified themselves, e.g. email",
+//	      "source_id": "the identifier from that source, possibly hashed",
+//	      "ancestor_ids": ["IDs of the grants that led to this one"],
+//	      "created_at": "RFC 3339 timestamp access was granted at",
+//	      "create_ip": "the IP address access was granted from",
+//	      "scopes": ["the scopes access was granted to"],
+//	      "account_id": "the account used to grant access",
+//	      "client_id": "the client access was granted to",
+//	      "key_thumbprint": "thumbprint of the key the grant is bound to",
+//	      "state": "where the grant is in its lifecycle, e.g. active or used",
+//	      "revoked": false,
+//	      "use": {
+//	        "used_at": "RFC 3339 timestamp the grant was exchanged at",
+//	        "ip": "the IP address the grant was exchanged from"
+//	      }
+//	    }
+//	  ]
+//	}
+//
+// Optional string fields are omitted when empty, and "use" is omitted for
+// grants that were never exchanged. Any change to this shape that isn't
+// purely additive will be released as a new schema version.
+package export
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"io"
+	"time"
+
+	"lockbox.dev/grants"
+)
+
+const (
+	// SchemaVersion is the version of the export schema this package
+	// produces.
+	SchemaVersion = 1
+
+	// SchemaURI identifies the version of the export schema this package
+	// produces.
+	SchemaURI = "https://lockbox.dev/grants/export/v1"
+
+	// DefaultPageSize is the number of grants read from the Storer at a
+	// time when no PageSize is set on the Exporter.
+	DefaultPageSize = 100
+)
+
+//go:embed schema_v1.json
+var schemaV1 []byte
+
+// Schema returns the JSON Schema describing the documents this package
+// produces.
+func Schema() []byte {
+	res := make([]byte, len(schemaV1))
+	copy(res, schemaV1)
+	return res
+}
+
+// Document is an export, as it is encoded. It can be used to decode exports;
+// Exporter writes them incrementally without building a Document.
+type Document struct {
+	Schema      string    `json:"schema"`
+	Version     int       `json:"version"`
+	ProfileID   string    `json:"profile_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Grants      []Grant   `json:"grants"`
+}
+
+// Grant is the exported representation of a grants.Grant.
+type Grant struct {
+	ID            string    `json:"id"`
+	SourceType    string    `json:"source_type"`
+	SourceID      string    `json:"source_id"`
+	AncestorIDs   []string  `json:"ancestor_ids"`
+	CreatedAt     time.Time `json:"created_at"`
+	CreateIP      string    `json:"create_ip,omitempty"`
+	Scopes        []string  `json:"scopes"`
+	AccountID     string    `json:"account_id,omitempty"`
+	ClientID      string    `json:"client_id"`
+	KeyThumbprint string    `json:"key_thumbprint,omitempty"`
+	State         string    `json:"state,omitempty"`
+	Revoked       bool      `json:"revoked"`
+	Use           *Use      `json:"use,omitempty"`
+}
+
+// Use is the exported representation of a Grant being exchanged.
+type Use struct {
+	UsedAt time.Time `json:"used_at"`
+	IP     string    `json:"ip,omitempty"`
+}
+
+// FromGrant returns the exported representation of `grant`.
+func FromGrant(grant grants.Grant) Grant {
+	res := Grant{
+		ID:            grant.ID,
+		SourceType:    grant.SourceType,
+		SourceID:      grant.SourceID,
+		AncestorIDs:   grant.AncestorIDs,
+		CreatedAt:     grant.CreatedAt,
+		CreateIP:      grant.CreateIP,
+		Scopes:        grant.Scopes,
+		AccountID:     grant.AccountID,
+		ClientID:      grant.ClientID,
+		KeyThumbprint: grant.KeyThumbprint,
+		State:         string(grant.State),
+		Revoked:       grant.Revoked(),
+	}
+	if res.AncestorIDs == nil {
+		res.AncestorIDs = []string{}
+	}
+	if res.Scopes == nil {
+		res.Scopes = []string{}
+	}
+	if grant.Used() {
+		res.Use = &Use{UsedAt: grant.UsedAt, IP: grant.UseIP}
+	}
+	return res
+}
+
+// Exporter writes exports, reading grants from its Storer a page at a time so
+// large histories never need to be held in memory at once.
+type Exporter struct {
+	Storer   grants.Storer
+	PageSize int              // how many grants to read at a time; defaults to DefaultPageSize
+	Now      func() time.Time // the clock used for generated_at; defaults to time.Now
+}
+
+// Export writes an export of every grant for `profileID` to `w`.
+//
+// The document is written as it is read, so if an error is returned, `w` will
+// have received an incomplete document that should be discarded.
+func (e Exporter) Export(ctx context.Context, w io.Writer, profileID string) error {
+	pageSize := e.PageSize
+	if pageSize < 1 {
+		pageSize = DefaultPageSize
+	}
+	now := time.Now
+	if e.Now != nil {
+		now = e.Now
+	}
+
+	// write everything but the grants, leaving the grants array open
+	header, err := json.Marshal(Document{
+		Schema:      SchemaURI,
+		Version:     SchemaVersion,
+		ProfileID:   profileID,
+		GeneratedAt: now(),
+		Grants:      []Grant{},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(header[:len(header)-len("]}")])
+	if err != nil {
+		return err
+	}
+
+	var after string
+	var written int
+	for {
+		var page []grants.Grant
+		page, err = e.Storer.ListGrantsByProfile(ctx, profileID, after, pageSize)
+		if err != nil {
+			return err
+		}
+		for _, grant := range page {
+			if written > 0 {
+				_, err = io.WriteString(w, ",")
+				if err != nil {
+					return err
+				}
+			}
+			err = writeGrant(w, grant)
+			if err != nil {
+				return err
+			}
+			written++
+			after = grant.ID
+		}
+		if len(page) < pageSize {
+			break
+		}
+	}
+
+	_, err = io.WriteString(w, "]}\n")
+	return err
+}
+
+func writeGrant(w io.Writer, grant grants.Grant) error {
+	encoded, err := json.Marshal(FromGrant(grant))
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(encoded)
+	return err
+}
@@ -0,0 +1,131 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	uuid "github.com/hashicorp/go-uuid"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/export"
+	"lockbox.dev/grants/storers/memory"
+)
+
+func TestExport(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storer, err := memory.NewStorer()
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+
+	now := time.Now().UTC().Round(time.Second)
+	var expected []export.Grant
+	for i := 0; i < 5; i++ {
+		id, err := uuid.GenerateUUID()
+		if err != nil {
+			t.Fatalf("Error generating ID: %s", err)
+		}
+		grant := grants.Grant{
+			ID:          id,
+			SourceType:  "manual",
+			SourceID:    id,
+			AncestorIDs: []string{},
+			CreatedAt:   now,
+			Scopes:      []string{"https://scopes.impractical.co/test"},
+			ProfileID:   "tester",
+			ClientID:    "testrunner",
+			State:       grants.GrantStateActive,
+			CreateIP:    "192.168.1.2",
+		}
+		err = storer.CreateGrant(ctx, grant)
+		if err != nil {
+			t.Fatalf("Error creating grant: %s", err)
+		}
+		if i%2 == 0 {
+			grant, err = storer.ExchangeGrant(ctx, grants.GrantUse{Grant: id, IP: "8.8.8.8", Time: now})
+			if err != nil {
+				t.Fatalf("Error exchanging grant: %s", err)
+			}
+		}
+		expected = append(expected, export.FromGrant(grant))
+	}
+	err = storer.CreateGrant(ctx, grants.Grant{ID: "00000000-0000-0000-0000-000000000000", SourceType: "manual", SourceID: "other", ProfileID: "someone else"})
+	if err != nil {
+		t.Fatalf("Error creating grant: %s", err)
+	}
+
+	var buf bytes.Buffer
+	exporter := export.Exporter{Storer: storer, PageSize: 2, Now: func() time.Time { return now }}
+	err = exporter.Export(ctx, &buf, "tester")
+	if err != nil {
+		t.Fatalf("Unexpected error exporting: %s", err)
+	}
+
+	var doc export.Document
+	err = json.Unmarshal(buf.Bytes(), &doc)
+	if err != nil {
+		t.Fatalf("Export isn't valid JSON: %s\n%s", err, buf.String())
+	}
+	if doc.Schema != export.SchemaURI || doc.Version != export.SchemaVersion || doc.ProfileID != "tester" || !doc.GeneratedAt.Equal(now) {
+		t.Errorf("Unexpected document metadata: %+v", doc)
+	}
+	if diff := cmp.Diff(expected, doc.Grants, sortByID()); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+	for _, grant := range doc.Grants {
+		if grant.Use != nil && grant.Use.IP != "8.8.8.8" {
+			t.Errorf("Expected use IP to be exported, got %+v", grant.Use)
+		}
+	}
+}
+
+func TestExportEmptyProfile(t *testing.T) {
+	t.Parallel()
+
+	storer, err := memory.NewStorer()
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+	var buf bytes.Buffer
+	err = export.Exporter{Storer: storer}.Export(context.Background(), &buf, "nobody")
+	if err != nil {
+		t.Fatalf("Unexpected error exporting: %s", err)
+	}
+	var doc export.Document
+	err = json.Unmarshal(buf.Bytes(), &doc)
+	if err != nil {
+		t.Fatalf("Export isn't valid JSON: %s\n%s", err, buf.String())
+	}
+	if doc.Grants == nil || len(doc.Grants) != 0 {
+		t.Errorf("Expected an empty grants array, got %+v", doc.Grants)
+	}
+}
+
+func TestSchemaIsValidJSON(t *testing.T) {
+	t.Parallel()
+
+	var schema map[string]interface{}
+	err := json.Unmarshal(export.Schema(), &schema)
+	if err != nil {
+		t.Fatalf("Schema isn't valid JSON: %s", err)
+	}
+	if schema["$id"] != export.SchemaURI {
+		t.Errorf("Expected schema $id %q, got %v", export.SchemaURI, schema["$id"])
+	}
+}
+
+func sortByID() cmp.Option {
+	return cmp.Transformer("sortByID", func(in []export.Grant) map[string]export.Grant {
+		res := make(map[string]export.Grant, len(in))
+		for _, grant := range in {
+			res[grant.ID] = grant
+		}
+		return res
+	})
+}
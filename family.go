@@ -0,0 +1,20 @@
+package grants
+
+import (
+	"context"
+)
+
+// FamilyRevoker is implemented by Storers that can revoke every Grant in a
+// family at once, which is how the reuse of a rotated refresh token is
+// contained. Storers that wrap another Storer, like the ones returned by
+// WithHashedSources, don't implement it; it should be called on the
+// underlying Storer.
+type FamilyRevoker interface {
+	// RevokeGrantFamily revokes the Grant identified by `id`, every
+	// Grant in its AncestorIDs, and every Grant with any of those in its
+	// AncestorIDs, in a single atomic operation, returning the IDs of the
+	// Grants it revoked, sorted. Grants that can't be revoked, like ones
+	// that have already been used, are left as they are. If no Grant has
+	// the ID `id`, an ErrGrantNotFound error is returned.
+	RevokeGrantFamily(ctx context.Context, id string) ([]string, error)
+}
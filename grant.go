@@ -23,24 +23,39 @@ var (
 	// ErrGrantSourceAlreadyUsed is returned when a grant is being stored in a Storer, but the source
 	// of the Grant has already been used in that Storer. This usually indicates a replay attack.
 	ErrGrantSourceAlreadyUsed = errors.New("grant source already used to generate a grant, cannot be used to create another grant")
+	// ErrInvalidProof is returned when a grant bound to a key is being
+	// used, but the proof of possession of that key is missing or invalid.
+	// This usually indicates a stolen grant ID is being presented.
+	ErrInvalidProof = errors.New("proof of possession invalid for grant, cannot be exchanged")
 )
 
 // Grant represents a user's authorization for the use of their account to some client.
 type Grant struct {
-	ID          string    // a unique ID
-	SourceType  string    // the type of the source used to identify the user
-	SourceID    string    // the ID of the source used to identify the user; should be unique across grants
-	AncestorIDs []string  // the IDs of any Grants that led to the creation of this grant, e.g. through refresh
-	CreatedAt   time.Time // when the authorization was granted
-	UsedAt      time.Time // when the authorization was exchanged for a session
-	Scopes      []string  // the scopes of access the user granted
-	AccountID   string    // the ID of the account that was used to grant access
-	ProfileID   string    // the unique ID representing the user
-	ClientID    string    // the client access was granted to
-	CreateIP    string    // the IP the user granted access from
-	UseIP       string    // the IP the access was exchanged for a session from
-	Used        bool      // whether the access has been exchanged for a session or not
-	Revoked     bool      // whether the grant has been manually revoked or not
+	ID            string     // a unique ID
+	TenantID      string     // the tenant the grant belongs to; empty for the default tenant
+	SourceType    string     // the type of the source used to identify the user
+	SourceID      string     // the ID of the source used to identify the user; should be unique across the tenant's grants
+	AncestorIDs   []string   // the IDs of any Grants that led to the creation of this grant, e.g. through refresh
+	CreatedAt     time.Time  // when the authorization was granted
+	UsedAt        time.Time  // when the authorization was exchanged for a session
+	Scopes        []string   // the scopes of access the user granted
+	AccountID     string     // the ID of the account that was used to grant access
+	ProfileID     string     // the unique ID representing the user
+	ClientID      string     // the client access was granted to
+	CreateIP      string     // the IP the user granted access from
+	UseIP         string     // the IP the access was exchanged for a session from
+	KeyThumbprint string     // the RFC 7638 thumbprint of the key the grant is bound to, if any
+	State         GrantState // where the grant is in its lifecycle; defaults to GrantStateActive
+}
+
+// Used returns true if the Grant has been exchanged for a session.
+func (g Grant) Used() bool {
+	return g.State == GrantStateUsed
+}
+
+// Revoked returns true if the Grant has been manually revoked.
+func (g Grant) Revoked() bool {
+	return g.State == GrantStateRevoked
 }
 
 // GrantUse represents the exchange of a Grant for a session.
@@ -48,6 +63,7 @@ type GrantUse struct {
 	Grant string    // the ID of the grant that was exchanged
 	IP    string    // the IP address the exchange was initiated from
 	Time  time.Time // the time the exchange happened
+	Proof string    // proof of possession of the key the grant is bound to, if any
 }
 
 // Dependencies bundles together the information needed to run the service.
@@ -71,5 +87,8 @@ func FillGrantDefaults(grant Grant) (Grant, error) {
 	if grant.CreatedAt.IsZero() {
 		res.CreatedAt = time.Now()
 	}
+	if grant.State == "" {
+		res.State = GrantStateActive
+	}
 	return res, nil
 }
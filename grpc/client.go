@@ -0,0 +1,138 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/grpc/grantspb"
+)
+
+var _ grants.Storer = Client{}
+
+// Client is a grants.Storer that stores Grants by calling a Grants service,
+// like the one Server provides.
+type Client struct {
+	client grantspb.GrantsClient
+}
+
+// NewClient returns a Client that calls the Grants service over `conn`.
+func NewClient(conn grpc.ClientConnInterface) Client {
+	return Client{client: grantspb.NewGrantsClient(conn)}
+}
+
+// CreateGrant stores `grant` in the Grants service.
+func (c Client) CreateGrant(ctx context.Context, grant grants.Grant) error {
+	_, err := c.client.CreateGrant(outgoingTenant(ctx), &grantspb.CreateGrantRequest{
+		Grant: grantToProto(grant),
+	})
+	if err != nil {
+		return FromStatus(err)
+	}
+	return nil
+}
+
+// ExchangeGrant exchanges the Grant identified by `use` in the Grants
+// service, returning the exchanged Grant.
+func (c Client) ExchangeGrant(ctx context.Context, use grants.GrantUse) (grants.Grant, error) {
+	resp, err := c.client.ExchangeGrant(outgoingTenant(ctx), &grantspb.ExchangeGrantRequest{
+		Use: grantUseToProto(use),
+	})
+	if err != nil {
+		return grants.Grant{}, FromStatus(err)
+	}
+	return grantFromProto(resp.GetGrant()), nil
+}
+
+// RevokeGrant revokes the Grant identified by `id` in the Grants service,
+// returning the revoked Grant.
+func (c Client) RevokeGrant(ctx context.Context, id string) (grants.Grant, error) {
+	resp, err := c.client.RevokeGrant(outgoingTenant(ctx), &grantspb.RevokeGrantRequest{
+		Id: id,
+	})
+	if err != nil {
+		return grants.Grant{}, FromStatus(err)
+	}
+	return grantFromProto(resp.GetGrant()), nil
+}
+
+// GetGrant retrieves the Grant identified by `id` from the Grants service.
+func (c Client) GetGrant(ctx context.Context, id string) (grants.Grant, error) {
+	resp, err := c.client.GetGrant(outgoingTenant(ctx), &grantspb.GetGrantRequest{
+		Id: id,
+	})
+	if err != nil {
+		return grants.Grant{}, FromStatus(err)
+	}
+	return grantFromProto(resp.GetGrant()), nil
+}
+
+// GetGrantBySource retrieves the Grant created from the source identified by
+// `sourceType` and `sourceID` from the Grants service.
+func (c Client) GetGrantBySource(ctx context.Context, sourceType, sourceID string) (grants.Grant, error) {
+	resp, err := c.client.GetGrantBySource(outgoingTenant(ctx), &grantspb.GetGrantBySourceRequest{
+		SourceType: sourceType,
+		SourceId:   sourceID,
+	})
+	if err != nil {
+		return grants.Grant{}, FromStatus(err)
+	}
+	return grantFromProto(resp.GetGrant()), nil
+}
+
+// TransitionGrant moves the Grant identified by `id` to `state` in the Grants
+// service, returning the updated Grant.
+func (c Client) TransitionGrant(ctx context.Context, id string, state grants.GrantState) (grants.Grant, error) {
+	resp, err := c.client.TransitionGrant(outgoingTenant(ctx), &grantspb.TransitionGrantRequest{
+		Id:    id,
+		State: string(state),
+	})
+	if err != nil {
+		return grants.Grant{}, FromStatus(err)
+	}
+	return grantFromProto(resp.GetGrant()), nil
+}
+
+// RotateGrant exchanges the Grant identified by `use` and creates `next` as
+// its child in the Grants service, returning the created Grant.
+func (c Client) RotateGrant(ctx context.Context, use grants.GrantUse, next grants.Grant) (grants.Grant, error) {
+	resp, err := c.client.RotateGrant(outgoingTenant(ctx), &grantspb.RotateGrantRequest{
+		Use:  grantUseToProto(use),
+		Next: grantToProto(next),
+	})
+	if err != nil {
+		return grants.Grant{}, FromStatus(err)
+	}
+	return grantFromProto(resp.GetGrant()), nil
+}
+
+// ListGrantsByProfile lists up to `limit` Grants for `profileID` from the
+// Grants service, starting after the Grant with the ID `after`.
+func (c Client) ListGrantsByProfile(ctx context.Context, profileID, after string, limit int) ([]grants.Grant, error) {
+	resp, err := c.client.ListGrantsByProfile(outgoingTenant(ctx), &grantspb.ListGrantsByProfileRequest{
+		ProfileId: profileID,
+		After:     after,
+		Limit:     int64(limit),
+	})
+	if err != nil {
+		return nil, FromStatus(err)
+	}
+	var res []grants.Grant
+	for _, grant := range resp.GetGrants() {
+		res = append(res, grantFromProto(grant))
+	}
+	return res, nil
+}
+
+// AnonymizeProfile scrubs the personal data from every Grant for `profileID`
+// in the Grants service.
+func (c Client) AnonymizeProfile(ctx context.Context, profileID string) error {
+	_, err := c.client.AnonymizeProfile(outgoingTenant(ctx), &grantspb.AnonymizeProfileRequest{
+		ProfileId: profileID,
+	})
+	if err != nil {
+		return FromStatus(err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"lockbox.dev/grants"
+)
+
+// ErrorDomain is the domain of the google.rpc.ErrorInfo details attached to
+// the statuses the Server returns.
+const ErrorDomain = "grants.lockbox.dev"
+
+// The reasons the google.rpc.ErrorInfo details attached to the statuses the
+// Server returns use to identify errors.
+const (
+	ReasonGrantNotFound           = "GRANT_NOT_FOUND"
+	ReasonGrantAlreadyExists      = "GRANT_ALREADY_EXISTS"
+	ReasonGrantSourceAlreadyUsed  = "GRANT_SOURCE_ALREADY_USED"
+	ReasonGrantAlreadyUsed        = "GRANT_ALREADY_USED"
+	ReasonGrantRevoked            = "GRANT_REVOKED"
+	ReasonGrantPending            = "GRANT_PENDING"
+	ReasonGrantExpired            = "GRANT_EXPIRED"
+	ReasonGrantDenied             = "GRANT_DENIED"
+	ReasonInvalidGrantState       = "INVALID_GRANT_STATE"
+	ReasonInvalidGrantTransition  = "INVALID_GRANT_TRANSITION"
+	ReasonInvalidProof            = "INVALID_PROOF"
+	ReasonTenantMismatch          = "TENANT_MISMATCH"
+	ReasonSourceTypeNotRegistered = "SOURCE_TYPE_NOT_REGISTERED"
+)
+
+type errorMapping struct {
+	err    error
+	code   codes.Code
+	reason string
+}
+
+//nolint:gochecknoglobals // a lookup table, never modified
+var errorMappings = []errorMapping{
+	{err: grants.ErrGrantNotFound, code: codes.NotFound, reason: ReasonGrantNotFound},
+	{err: grants.ErrGrantAlreadyExists, code: codes.AlreadyExists, reason: ReasonGrantAlreadyExists},
+	{err: grants.ErrGrantSourceAlreadyUsed, code: codes.AlreadyExists, reason: ReasonGrantSourceAlreadyUsed},
+	{err: grants.ErrGrantAlreadyUsed, code: codes.FailedPrecondition, reason: ReasonGrantAlreadyUsed},
+	{err: grants.ErrGrantRevoked, code: codes.FailedPrecondition, reason: ReasonGrantRevoked},
+	{err: grants.ErrGrantPending, code: codes.FailedPrecondition, reason: ReasonGrantPending},
+	{err: grants.ErrGrantExpired, code: codes.FailedPrecondition, reason: ReasonGrantExpired},
+	{err: grants.ErrGrantDenied, code: codes.FailedPrecondition, reason: ReasonGrantDenied},
+	{err: grants.ErrInvalidGrantState, code: codes.InvalidArgument, reason: ReasonInvalidGrantState},
+	{err: grants.ErrInvalidGrantTransition, code: codes.FailedPrecondition, reason: ReasonInvalidGrantTransition},
+	{err: grants.ErrInvalidProof, code: codes.Unauthenticated, reason: ReasonInvalidProof},
+	{err: grants.ErrTenantMismatch, code: codes.PermissionDenied, reason: ReasonTenantMismatch},
+	{err: grants.ErrSourceTypeNotRegistered, code: codes.InvalidArgument, reason: ReasonSourceTypeNotRegistered},
+}
+
+// ToStatus returns the gRPC status that describes `err`, matching it against
+// the errors defined by the grants package with errors.Is. The status carries
+// a google.rpc.ErrorInfo detail identifying the error, so FromStatus can turn
+// it back into the same error on the other side. Context cancellations and
+// deadlines are reported as codes.Canceled and codes.DeadlineExceeded, as
+// status.FromContextError does. Errors that don't match any of them are
+// reported as codes.Internal, without their message.
+func ToStatus(err error) *status.Status {
+	// only the context error itself is reported, not the message of
+	// whatever wrapped it
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.FromContextError(context.DeadlineExceeded)
+	case errors.Is(err, context.Canceled):
+		return status.FromContextError(context.Canceled)
+	}
+	for _, mapping := range errorMappings {
+		if !errors.Is(err, mapping.err) {
+			continue
+		}
+		st := status.New(mapping.code, mapping.err.Error())
+		detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+			Reason: mapping.reason,
+			Domain: ErrorDomain,
+		})
+		if detailErr != nil {
+			return st
+		}
+		return detailed
+	}
+	return status.New(codes.Internal, "internal error")
+}
+
+// FromStatus returns the error from the grants package that the gRPC status
+// error `err` describes, if it was created by ToStatus. Statuses with
+// codes.Canceled or codes.DeadlineExceeded are returned as context.Canceled
+// and context.DeadlineExceeded. Any other error is returned unmodified.
+func FromStatus(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	switch st.Code() { //nolint:exhaustive // every other code is handled below
+	case codes.Canceled:
+		return context.Canceled
+	case codes.DeadlineExceeded:
+		return context.DeadlineExceeded
+	}
+	for _, detail := range st.Details() {
+		info, ok := detail.(*errdetails.ErrorInfo)
+		if !ok || info.GetDomain() != ErrorDomain {
+			continue
+		}
+		for _, mapping := range errorMappings {
+			if mapping.reason == info.GetReason() {
+				return mapping.err
+			}
+		}
+	}
+	return err
+}
@@ -0,0 +1,5 @@
+// Package grantspb holds the protocol buffer definitions of the Grants gRPC
+// service, and the code generated from them.
+package grantspb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative grants.proto
@@ -0,0 +1,405 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v3.21.12
+// source: grants.proto
+
+package grantspb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Grants_CreateGrant_FullMethodName         = "/lockbox.grants.v1.Grants/CreateGrant"
+	Grants_ExchangeGrant_FullMethodName       = "/lockbox.grants.v1.Grants/ExchangeGrant"
+	Grants_RevokeGrant_FullMethodName         = "/lockbox.grants.v1.Grants/RevokeGrant"
+	Grants_GetGrant_FullMethodName            = "/lockbox.grants.v1.Grants/GetGrant"
+	Grants_GetGrantBySource_FullMethodName    = "/lockbox.grants.v1.Grants/GetGrantBySource"
+	Grants_TransitionGrant_FullMethodName     = "/lockbox.grants.v1.Grants/TransitionGrant"
+	Grants_RotateGrant_FullMethodName         = "/lockbox.grants.v1.Grants/RotateGrant"
+	Grants_ListGrantsByProfile_FullMethodName = "/lockbox.grants.v1.Grants/ListGrantsByProfile"
+	Grants_AnonymizeProfile_FullMethodName    = "/lockbox.grants.v1.Grants/AnonymizeProfile"
+)
+
+// GrantsClient is the client API for Grants service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type GrantsClient interface {
+	CreateGrant(ctx context.Context, in *CreateGrantRequest, opts ...grpc.CallOption) (*CreateGrantResponse, error)
+	ExchangeGrant(ctx context.Context, in *ExchangeGrantRequest, opts ...grpc.CallOption) (*ExchangeGrantResponse, error)
+	RevokeGrant(ctx context.Context, in *RevokeGrantRequest, opts ...grpc.CallOption) (*RevokeGrantResponse, error)
+	GetGrant(ctx context.Context, in *GetGrantRequest, opts ...grpc.CallOption) (*GetGrantResponse, error)
+	GetGrantBySource(ctx context.Context, in *GetGrantBySourceRequest, opts ...grpc.CallOption) (*GetGrantBySourceResponse, error)
+	TransitionGrant(ctx context.Context, in *TransitionGrantRequest, opts ...grpc.CallOption) (*TransitionGrantResponse, error)
+	RotateGrant(ctx context.Context, in *RotateGrantRequest, opts ...grpc.CallOption) (*RotateGrantResponse, error)
+	ListGrantsByProfile(ctx context.Context, in *ListGrantsByProfileRequest, opts ...grpc.CallOption) (*ListGrantsByProfileResponse, error)
+	AnonymizeProfile(ctx context.Context, in *AnonymizeProfileRequest, opts ...grpc.CallOption) (*AnonymizeProfileResponse, error)
+}
+
+type grantsClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewGrantsClient(cc grpc.ClientConnInterface) GrantsClient {
+	return &grantsClient{cc}
+}
+
+func (c *grantsClient) CreateGrant(ctx context.Context, in *CreateGrantRequest, opts ...grpc.CallOption) (*CreateGrantResponse, error) {
+	out := new(CreateGrantResponse)
+	err := c.cc.Invoke(ctx, Grants_CreateGrant_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grantsClient) ExchangeGrant(ctx context.Context, in *ExchangeGrantRequest, opts ...grpc.CallOption) (*ExchangeGrantResponse, error) {
+	out := new(ExchangeGrantResponse)
+	err := c.cc.Invoke(ctx, Grants_ExchangeGrant_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grantsClient) RevokeGrant(ctx context.Context, in *RevokeGrantRequest, opts ...grpc.CallOption) (*RevokeGrantResponse, error) {
+	out := new(RevokeGrantResponse)
+	err := c.cc.Invoke(ctx, Grants_RevokeGrant_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grantsClient) GetGrant(ctx context.Context, in *GetGrantRequest, opts ...grpc.CallOption) (*GetGrantResponse, error) {
+	out := new(GetGrantResponse)
+	err := c.cc.Invoke(ctx, Grants_GetGrant_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grantsClient) GetGrantBySource(ctx context.Context, in *GetGrantBySourceRequest, opts ...grpc.CallOption) (*GetGrantBySourceResponse, error) {
+	out := new(GetGrantBySourceResponse)
+	err := c.cc.Invoke(ctx, Grants_GetGrantBySource_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grantsClient) TransitionGrant(ctx context.Context, in *TransitionGrantRequest, opts ...grpc.CallOption) (*TransitionGrantResponse, error) {
+	out := new(TransitionGrantResponse)
+	err := c.cc.Invoke(ctx, Grants_TransitionGrant_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grantsClient) RotateGrant(ctx context.Context, in *RotateGrantRequest, opts ...grpc.CallOption) (*RotateGrantResponse, error) {
+	out := new(RotateGrantResponse)
+	err := c.cc.Invoke(ctx, Grants_RotateGrant_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grantsClient) ListGrantsByProfile(ctx context.Context, in *ListGrantsByProfileRequest, opts ...grpc.CallOption) (*ListGrantsByProfileResponse, error) {
+	out := new(ListGrantsByProfileResponse)
+	err := c.cc.Invoke(ctx, Grants_ListGrantsByProfile_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *grantsClient) AnonymizeProfile(ctx context.Context, in *AnonymizeProfileRequest, opts ...grpc.CallOption) (*AnonymizeProfileResponse, error) {
+	out := new(AnonymizeProfileResponse)
+	err := c.cc.Invoke(ctx, Grants_AnonymizeProfile_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// GrantsServer is the server API for Grants service.
+// All implementations must embed UnimplementedGrantsServer
+// for forward compatibility
+type GrantsServer interface {
+	CreateGrant(context.Context, *CreateGrantRequest) (*CreateGrantResponse, error)
+	ExchangeGrant(context.Context, *ExchangeGrantRequest) (*ExchangeGrantResponse, error)
+	RevokeGrant(context.Context, *RevokeGrantRequest) (*RevokeGrantResponse, error)
+	GetGrant(context.Context, *GetGrantRequest) (*GetGrantResponse, error)
+	GetGrantBySource(context.Context, *GetGrantBySourceRequest) (*GetGrantBySourceResponse, error)
+	TransitionGrant(context.Context, *TransitionGrantRequest) (*TransitionGrantResponse, error)
+	RotateGrant(context.Context, *RotateGrantRequest) (*RotateGrantResponse, error)
+	ListGrantsByProfile(context.Context, *ListGrantsByProfileRequest) (*ListGrantsByProfileResponse, error)
+	AnonymizeProfile(context.Context, *AnonymizeProfileRequest) (*AnonymizeProfileResponse, error)
+	mustEmbedUnimplementedGrantsServer()
+}
+
+// UnimplementedGrantsServer must be embedded to have forward compatible implementations.
+type UnimplementedGrantsServer struct {
+}
+
+func (UnimplementedGrantsServer) CreateGrant(context.Context, *CreateGrantRequest) (*CreateGrantResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateGrant not implemented")
+}
+func (UnimplementedGrantsServer) ExchangeGrant(context.Context, *ExchangeGrantRequest) (*ExchangeGrantResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExchangeGrant not implemented")
+}
+func (UnimplementedGrantsServer) RevokeGrant(context.Context, *RevokeGrantRequest) (*RevokeGrantResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeGrant not implemented")
+}
+func (UnimplementedGrantsServer) GetGrant(context.Context, *GetGrantRequest) (*GetGrantResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGrant not implemented")
+}
+func (UnimplementedGrantsServer) GetGrantBySource(context.Context, *GetGrantBySourceRequest) (*GetGrantBySourceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetGrantBySource not implemented")
+}
+func (UnimplementedGrantsServer) TransitionGrant(context.Context, *TransitionGrantRequest) (*TransitionGrantResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransitionGrant not implemented")
+}
+func (UnimplementedGrantsServer) RotateGrant(context.Context, *RotateGrantRequest) (*RotateGrantResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateGrant not implemented")
+}
+func (UnimplementedGrantsServer) ListGrantsByProfile(context.Context, *ListGrantsByProfileRequest) (*ListGrantsByProfileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListGrantsByProfile not implemented")
+}
+func (UnimplementedGrantsServer) AnonymizeProfile(context.Context, *AnonymizeProfileRequest) (*AnonymizeProfileResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AnonymizeProfile not implemented")
+}
+func (UnimplementedGrantsServer) mustEmbedUnimplementedGrantsServer() {}
+
+// UnsafeGrantsServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to GrantsServer will
+// result in compilation errors.
+type UnsafeGrantsServer interface {
+	mustEmbedUnimplementedGrantsServer()
+}
+
+func RegisterGrantsServer(s grpc.ServiceRegistrar, srv GrantsServer) {
+	s.RegisterService(&Grants_ServiceDesc, srv)
+}
+
+func _Grants_CreateGrant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateGrantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrantsServer).CreateGrant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Grants_CreateGrant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrantsServer).CreateGrant(ctx, req.(*CreateGrantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Grants_ExchangeGrant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExchangeGrantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrantsServer).ExchangeGrant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Grants_ExchangeGrant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrantsServer).ExchangeGrant(ctx, req.(*ExchangeGrantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Grants_RevokeGrant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeGrantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrantsServer).RevokeGrant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Grants_RevokeGrant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrantsServer).RevokeGrant(ctx, req.(*RevokeGrantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Grants_GetGrant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGrantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrantsServer).GetGrant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Grants_GetGrant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrantsServer).GetGrant(ctx, req.(*GetGrantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Grants_GetGrantBySource_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetGrantBySourceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrantsServer).GetGrantBySource(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Grants_GetGrantBySource_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrantsServer).GetGrantBySource(ctx, req.(*GetGrantBySourceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Grants_TransitionGrant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransitionGrantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrantsServer).TransitionGrant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Grants_TransitionGrant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrantsServer).TransitionGrant(ctx, req.(*TransitionGrantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Grants_RotateGrant_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RotateGrantRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrantsServer).RotateGrant(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Grants_RotateGrant_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrantsServer).RotateGrant(ctx, req.(*RotateGrantRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Grants_ListGrantsByProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListGrantsByProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrantsServer).ListGrantsByProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Grants_ListGrantsByProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrantsServer).ListGrantsByProfile(ctx, req.(*ListGrantsByProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Grants_AnonymizeProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AnonymizeProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(GrantsServer).AnonymizeProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Grants_AnonymizeProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(GrantsServer).AnonymizeProfile(ctx, req.(*AnonymizeProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Grants_ServiceDesc is the grpc.ServiceDesc for Grants service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Grants_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "lockbox.grants.v1.Grants",
+	HandlerType: (*GrantsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateGrant",
+			Handler:    _Grants_CreateGrant_Handler,
+		},
+		{
+			MethodName: "ExchangeGrant",
+			Handler:    _Grants_ExchangeGrant_Handler,
+		},
+		{
+			MethodName: "RevokeGrant",
+			Handler:    _Grants_RevokeGrant_Handler,
+		},
+		{
+			MethodName: "GetGrant",
+			Handler:    _Grants_GetGrant_Handler,
+		},
+		{
+			MethodName: "GetGrantBySource",
+			Handler:    _Grants_GetGrantBySource_Handler,
+		},
+		{
+			MethodName: "TransitionGrant",
+			Handler:    _Grants_TransitionGrant_Handler,
+		},
+		{
+			MethodName: "RotateGrant",
+			Handler:    _Grants_RotateGrant_Handler,
+		},
+		{
+			MethodName: "ListGrantsByProfile",
+			Handler:    _Grants_ListGrantsByProfile_Handler,
+		},
+		{
+			MethodName: "AnonymizeProfile",
+			Handler:    _Grants_AnonymizeProfile_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "grants.proto",
+}
@@ -0,0 +1,134 @@
+// Package grpc exposes a grants.Storer over gRPC, using the Grants service
+// defined in the grantspb package.
+//
+// Server wraps any grants.Storer to serve the Grants service, and Client
+// implements grants.Storer by calling a Grants service, so a Storer running
+// in another process can be used anywhere a local one can:
+//
+//	server := grpc.NewServer()
+//	grantspb.RegisterGrantsServer(server, grantsgrpc.Server{Dependencies: deps})
+//
+//	storer := grantsgrpc.NewClient(conn)
+//
+// The tenant a call is scoped to travels in the TenantMetadataKey metadata
+// key, and errors from the grants package travel as statuses built by
+// ToStatus, which the Client turns back into the same errors. The Server
+// trusts the tenant it's sent, so callers should be authenticated and
+// authorized with interceptors before their calls reach it.
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/grpc/grantspb"
+)
+
+// TenantMetadataKey is the metadata key the tenant a call is scoped to is
+// sent in. Calls without it are scoped to the default tenant.
+const TenantMetadataKey = "lockbox-tenant"
+
+// outgoingTenant returns a copy of `ctx` that sends the tenant it's scoped
+// to, if any, in the metadata of outgoing calls.
+func outgoingTenant(ctx context.Context) context.Context {
+	tenantID := grants.TenantFromContext(ctx)
+	if tenantID == "" {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, TenantMetadataKey, tenantID)
+}
+
+// incomingTenant returns a copy of `ctx` scoped to the tenant sent in the
+// metadata of the incoming call, if any.
+func incomingTenant(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+	tenants := md.Get(TenantMetadataKey)
+	if len(tenants) < 1 || tenants[0] == "" {
+		return ctx
+	}
+	return grants.WithTenant(ctx, tenants[0])
+}
+
+func timeToProto(t time.Time) *timestamppb.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	return timestamppb.New(t)
+}
+
+func timeFromProto(t *timestamppb.Timestamp) time.Time {
+	if t == nil {
+		return time.Time{}
+	}
+	return t.AsTime()
+}
+
+func grantToProto(grant grants.Grant) *grantspb.Grant {
+	return &grantspb.Grant{
+		Id:            grant.ID,
+		TenantId:      grant.TenantID,
+		SourceType:    grant.SourceType,
+		SourceId:      grant.SourceID,
+		AncestorIds:   grant.AncestorIDs,
+		CreatedAt:     timeToProto(grant.CreatedAt),
+		UsedAt:        timeToProto(grant.UsedAt),
+		Scopes:        grant.Scopes,
+		AccountId:     grant.AccountID,
+		ProfileId:     grant.ProfileID,
+		ClientId:      grant.ClientID,
+		CreateIp:      grant.CreateIP,
+		UseIp:         grant.UseIP,
+		KeyThumbprint: grant.KeyThumbprint,
+		State:         string(grant.State),
+	}
+}
+
+func grantFromProto(grant *grantspb.Grant) grants.Grant {
+	// protobuf can't tell an empty list from a missing one, so root
+	// Grants come back with an empty AncestorIDs, like they do from the
+	// postgres Storer
+	ancestors := make([]string, 0, len(grant.GetAncestorIds()))
+	ancestors = append(ancestors, grant.GetAncestorIds()...)
+	return grants.Grant{
+		ID:            grant.GetId(),
+		TenantID:      grant.GetTenantId(),
+		SourceType:    grant.GetSourceType(),
+		SourceID:      grant.GetSourceId(),
+		AncestorIDs:   ancestors,
+		CreatedAt:     timeFromProto(grant.GetCreatedAt()),
+		UsedAt:        timeFromProto(grant.GetUsedAt()),
+		Scopes:        grant.GetScopes(),
+		AccountID:     grant.GetAccountId(),
+		ProfileID:     grant.GetProfileId(),
+		ClientID:      grant.GetClientId(),
+		CreateIP:      grant.GetCreateIp(),
+		UseIP:         grant.GetUseIp(),
+		KeyThumbprint: grant.GetKeyThumbprint(),
+		State:         grants.GrantState(grant.GetState()),
+	}
+}
+
+func grantUseToProto(use grants.GrantUse) *grantspb.GrantUse {
+	return &grantspb.GrantUse{
+		Grant: use.Grant,
+		Ip:    use.IP,
+		Time:  timeToProto(use.Time),
+		Proof: use.Proof,
+	}
+}
+
+func grantUseFromProto(use *grantspb.GrantUse) grants.GrantUse {
+	return grants.GrantUse{
+		Grant: use.GetGrant(),
+		IP:    use.GetIp(),
+		Time:  timeFromProto(use.GetTime()),
+		Proof: use.GetProof(),
+	}
+}
@@ -0,0 +1,144 @@
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"lockbox.dev/grants"
+	grantsgrpc "lockbox.dev/grants/grpc"
+)
+
+func TestErrorRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	tests := map[error]codes.Code{
+		grants.ErrGrantNotFound:           codes.NotFound,
+		grants.ErrGrantAlreadyExists:      codes.AlreadyExists,
+		grants.ErrGrantSourceAlreadyUsed:  codes.AlreadyExists,
+		grants.ErrGrantAlreadyUsed:        codes.FailedPrecondition,
+		grants.ErrGrantRevoked:            codes.FailedPrecondition,
+		grants.ErrGrantPending:            codes.FailedPrecondition,
+		grants.ErrGrantExpired:            codes.FailedPrecondition,
+		grants.ErrGrantDenied:             codes.FailedPrecondition,
+		grants.ErrInvalidGrantState:       codes.InvalidArgument,
+		grants.ErrInvalidGrantTransition:  codes.FailedPrecondition,
+		grants.ErrInvalidProof:            codes.Unauthenticated,
+		grants.ErrTenantMismatch:          codes.PermissionDenied,
+		grants.ErrSourceTypeNotRegistered: codes.InvalidArgument,
+	}
+	for err, code := range tests {
+		err, code := err, code
+		t.Run(err.Error(), func(t *testing.T) {
+			t.Parallel()
+
+			// wrapped errors are mapped the same way
+			st := grantsgrpc.ToStatus(fmt.Errorf("storing grant: %w", err))
+			if st.Code() != code {
+				t.Errorf("Expected code %s, got %s", code, st.Code())
+			}
+			if len(st.Details()) != 1 {
+				t.Errorf("Expected one detail, got %v", st.Details())
+			}
+			res := grantsgrpc.FromStatus(st.Err())
+			if !errors.Is(res, err) {
+				t.Errorf("Expected error to be %v, got %v", err, res)
+			}
+		})
+	}
+
+	contextTests := map[error]codes.Code{
+		context.Canceled:         codes.Canceled,
+		context.DeadlineExceeded: codes.DeadlineExceeded,
+	}
+	for err, code := range contextTests {
+		err, code := err, code
+		t.Run(err.Error(), func(t *testing.T) {
+			t.Parallel()
+
+			st := grantsgrpc.ToStatus(fmt.Errorf("storing grant: %w", err))
+			if st.Code() != code {
+				t.Errorf("Expected code %s, got %s", code, st.Code())
+			}
+			if st.Message() != err.Error() {
+				t.Errorf("Expected message %q, got %q", err.Error(), st.Message())
+			}
+			res := grantsgrpc.FromStatus(st.Err())
+			if !errors.Is(res, err) {
+				t.Errorf("Expected error to be %v, got %v", err, res)
+			}
+		})
+	}
+}
+
+func TestUnknownErrorStatus(t *testing.T) {
+	t.Parallel()
+
+	err := errors.New("connection to database refused: password incorrect") //nolint:goerr113 // error for testing
+	st := grantsgrpc.ToStatus(err)
+	if st.Code() != codes.Internal {
+		t.Errorf("Expected code %s, got %s", codes.Internal, st.Code())
+	}
+	if st.Message() == err.Error() {
+		t.Errorf("Expected error message not to be exposed, got %q", st.Message())
+	}
+
+	// statuses that didn't come from ToStatus are left alone
+	other := status.Error(codes.Unavailable, "connection refused")
+	if res := grantsgrpc.FromStatus(other); res != other { //nolint:errorlint // testing that it's the same error
+		t.Errorf("Expected %v, got %v", other, res)
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	factory := grantsgrpc.NewFactory()
+	t.Cleanup(func() {
+		err := factory.TeardownStorers()
+		if err != nil {
+			t.Errorf("Error tearing down storers: %s", err)
+		}
+	})
+	storer, err := factory.NewStorer(ctx)
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+	grant, err := grants.FillGrantDefaults(grants.Grant{SourceType: "manual", SourceID: "TestClientErrors"})
+	if err != nil {
+		t.Fatalf("Unexpected error filling grant defaults: %s", err)
+	}
+	err = storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant: %s", err)
+	}
+
+	err = storer.CreateGrant(ctx, grant)
+	if !errors.Is(err, grants.ErrGrantAlreadyExists) {
+		t.Errorf("Expected error to be %v, got %v", grants.ErrGrantAlreadyExists, err)
+	}
+	_, err = storer.TransitionGrant(ctx, grant.ID, grants.GrantStateDenied)
+	if !errors.Is(err, grants.ErrInvalidGrantTransition) {
+		t.Errorf("Expected error to be %v, got %v", grants.ErrInvalidGrantTransition, err)
+	}
+	_, err = storer.GetGrant(grants.WithTenant(ctx, "other"), grant.ID)
+	if !errors.Is(err, grants.ErrGrantNotFound) {
+		t.Errorf("Expected error to be %v from another tenant, got %v", grants.ErrGrantNotFound, err)
+	}
+	err = storer.CreateGrant(grants.WithTenant(ctx, "other"), grants.Grant{ID: grant.ID, TenantID: "another"})
+	if !errors.Is(err, grants.ErrTenantMismatch) {
+		t.Errorf("Expected error to be %v, got %v", grants.ErrTenantMismatch, err)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = storer.GetGrant(canceled, grant.ID)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected error to be %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,127 @@
+package grpc
+
+import (
+	"context"
+
+	"google.golang.org/grpc/codes"
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/grpc/grantspb"
+)
+
+// Server serves the Grants service, passing every call on to its Storer.
+type Server struct {
+	grantspb.UnimplementedGrantsServer
+	grants.Dependencies
+}
+
+// CreateGrant stores the Grant in `req`.
+func (s Server) CreateGrant(ctx context.Context, req *grantspb.CreateGrantRequest) (*grantspb.CreateGrantResponse, error) {
+	ctx = incomingTenant(ctx)
+	err := s.Storer.CreateGrant(ctx, grantFromProto(req.GetGrant()))
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &grantspb.CreateGrantResponse{}, nil
+}
+
+// ExchangeGrant exchanges the Grant identified by the GrantUse in `req`.
+func (s Server) ExchangeGrant(ctx context.Context, req *grantspb.ExchangeGrantRequest) (*grantspb.ExchangeGrantResponse, error) {
+	ctx = incomingTenant(ctx)
+	grant, err := s.Storer.ExchangeGrant(ctx, grantUseFromProto(req.GetUse()))
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &grantspb.ExchangeGrantResponse{Grant: grantToProto(grant)}, nil
+}
+
+// RevokeGrant revokes the Grant identified by `req`.
+func (s Server) RevokeGrant(ctx context.Context, req *grantspb.RevokeGrantRequest) (*grantspb.RevokeGrantResponse, error) {
+	ctx = incomingTenant(ctx)
+	grant, err := s.Storer.RevokeGrant(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &grantspb.RevokeGrantResponse{Grant: grantToProto(grant)}, nil
+}
+
+// GetGrant retrieves the Grant identified by `req`.
+func (s Server) GetGrant(ctx context.Context, req *grantspb.GetGrantRequest) (*grantspb.GetGrantResponse, error) {
+	ctx = incomingTenant(ctx)
+	grant, err := s.Storer.GetGrant(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &grantspb.GetGrantResponse{Grant: grantToProto(grant)}, nil
+}
+
+// GetGrantBySource retrieves the Grant created from the source identified by
+// `req`.
+func (s Server) GetGrantBySource(ctx context.Context, req *grantspb.GetGrantBySourceRequest) (*grantspb.GetGrantBySourceResponse, error) {
+	ctx = incomingTenant(ctx)
+	grant, err := s.Storer.GetGrantBySource(ctx, req.GetSourceType(), req.GetSourceId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &grantspb.GetGrantBySourceResponse{Grant: grantToProto(grant)}, nil
+}
+
+// TransitionGrant moves the Grant identified by `req` to the state in `req`.
+func (s Server) TransitionGrant(ctx context.Context, req *grantspb.TransitionGrantRequest) (*grantspb.TransitionGrantResponse, error) {
+	ctx = incomingTenant(ctx)
+	grant, err := s.Storer.TransitionGrant(ctx, req.GetId(), grants.GrantState(req.GetState()))
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &grantspb.TransitionGrantResponse{Grant: grantToProto(grant)}, nil
+}
+
+// RotateGrant exchanges the Grant identified by the GrantUse in `req` and
+// creates the next Grant in `req` as its child.
+func (s Server) RotateGrant(ctx context.Context, req *grantspb.RotateGrantRequest) (*grantspb.RotateGrantResponse, error) {
+	ctx = incomingTenant(ctx)
+	grant, err := s.Storer.RotateGrant(ctx, grantUseFromProto(req.GetUse()), grantFromProto(req.GetNext()))
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &grantspb.RotateGrantResponse{Grant: grantToProto(grant)}, nil
+}
+
+// ListGrantsByProfile lists a page of the Grants for the profile identified
+// by `req`.
+func (s Server) ListGrantsByProfile(ctx context.Context, req *grantspb.ListGrantsByProfileRequest) (*grantspb.ListGrantsByProfileResponse, error) {
+	ctx = incomingTenant(ctx)
+	results, err := s.Storer.ListGrantsByProfile(ctx, req.GetProfileId(), req.GetAfter(), int(req.GetLimit()))
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	resp := &grantspb.ListGrantsByProfileResponse{
+		Grants: make([]*grantspb.Grant, 0, len(results)),
+	}
+	for _, grant := range results {
+		resp.Grants = append(resp.Grants, grantToProto(grant))
+	}
+	return resp, nil
+}
+
+// AnonymizeProfile scrubs the personal data from every Grant for the profile
+// identified by `req`.
+func (s Server) AnonymizeProfile(ctx context.Context, req *grantspb.AnonymizeProfileRequest) (*grantspb.AnonymizeProfileResponse, error) {
+	ctx = incomingTenant(ctx)
+	err := s.Storer.AnonymizeProfile(ctx, req.GetProfileId())
+	if err != nil {
+		return nil, statusError(ctx, err)
+	}
+	return &grantspb.AnonymizeProfileResponse{}, nil
+}
+
+// statusError returns the gRPC status error ToStatus builds for `err`,
+// logging any errors that don't describe a problem with the request.
+func statusError(ctx context.Context, err error) error {
+	st := ToStatus(err)
+	if st.Code() == codes.Internal {
+		yall.FromContext(ctx).WithError(err).Error("error serving grants call")
+	}
+	return st.Err()
+}
@@ -0,0 +1,86 @@
+package grpc
+
+import (
+	"context"
+	"net"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/grpc/grantspb"
+	"lockbox.dev/grants/storers/memory"
+)
+
+const bufconnSize = 1 << 20
+
+// Factory implements the storertest.Factory interface
+// for the Client type; it offers a consistent
+// interface for setting up and tearing down Clients
+// for testing purposes. Each Client it creates talks
+// to its own in-process Server, backed by a memory
+// Storer.
+type Factory struct {
+	servers []*grpc.Server
+	conns   []*grpc.ClientConn
+	lock    sync.Mutex
+}
+
+// NewFactory returns a Factory, ready to be used.
+// NewFactory must be called to obtain a usable Factory,
+// because Factory types have internal state that must
+// be initialized.
+func NewFactory() *Factory {
+	return &Factory{}
+}
+
+// NewStorer starts a new Server and returns a Client
+// connected to it.
+func (f *Factory) NewStorer(ctx context.Context) (grants.Storer, error) { //nolint:ireturn // interface requires returning an interface
+	storer, err := memory.NewStorer()
+	if err != nil {
+		return nil, err
+	}
+	listener := bufconn.Listen(bufconnSize)
+	server := grpc.NewServer()
+	grantspb.RegisterGrantsServer(server, Server{Dependencies: grants.Dependencies{Storer: storer}})
+	go server.Serve(listener) //nolint:errcheck // the error is always from the server being stopped
+
+	conn, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		server.Stop()
+		return nil, err
+	}
+
+	f.lock.Lock()
+	f.servers = append(f.servers, server)
+	f.conns = append(f.conns, conn)
+	f.lock.Unlock()
+
+	return NewClient(conn), nil
+}
+
+// TeardownStorers closes every connection and stops
+// every Server created by NewStorer, cleaning up after
+// the Factory.
+func (f *Factory) TeardownStorers() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, conn := range f.conns {
+		err := conn.Close()
+		if err != nil {
+			return err
+		}
+	}
+	for _, server := range f.servers {
+		server.Stop()
+	}
+	return nil
+}
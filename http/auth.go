@@ -0,0 +1,53 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+var (
+	// ErrUnauthenticated is returned by Authorizers when a request
+	// doesn't carry valid credentials. It's reported as an
+	// http.StatusUnauthorized.
+	ErrUnauthenticated = errors.New("request is not authenticated")
+	// ErrForbidden is returned by Authorizers when a request carries
+	// valid credentials, but they don't allow the request. It's reported
+	// as an http.StatusForbidden.
+	ErrForbidden = errors.New("request is not allowed")
+)
+
+// Action identifies what a request is trying to do.
+type Action string
+
+const (
+	// ActionCreate is the Action of requests creating a Grant.
+	ActionCreate Action = "create"
+	// ActionGet is the Action of requests retrieving a Grant.
+	ActionGet Action = "get"
+	// ActionExchange is the Action of requests exchanging a Grant.
+	ActionExchange Action = "exchange"
+	// ActionRevoke is the Action of requests revoking a Grant.
+	ActionRevoke Action = "revoke"
+)
+
+// Authorizer authenticates the caller of every request, and decides whether
+// it may perform `action` on the Grant with the ID `grantID`. `grantID` is
+// empty for ActionCreate.
+//
+// The returned context is used for the rest of the request, so Authorizers
+// can scope it to the caller's tenant with grants.WithTenant. A nil context
+// means the request's own context is used. Errors should wrap
+// ErrUnauthenticated or ErrForbidden; any other error is reported as an
+// internal error.
+type Authorizer interface {
+	Authorize(r *http.Request, action Action, grantID string) (context.Context, error)
+}
+
+// AuthorizerFunc is an Authorizer implemented by a function.
+type AuthorizerFunc func(r *http.Request, action Action, grantID string) (context.Context, error)
+
+// Authorize calls the function.
+func (f AuthorizerFunc) Authorize(r *http.Request, action Action, grantID string) (context.Context, error) {
+	return f(r, action, grantID)
+}
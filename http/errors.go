@@ -0,0 +1,77 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+
+	"lockbox.dev/grants"
+)
+
+// The slugs RequestErrors use to identify problems.
+const (
+	SlugActOfGod          = "act_of_god"
+	SlugAccessDenied      = "access_denied"
+	SlugNotFound          = "not_found"
+	SlugMethodNotAllowed  = "method_not_allowed"
+	SlugInvalidFormat     = "invalid_format"
+	SlugInvalidValue      = "invalid_value"
+	SlugMissing           = "missing"
+	SlugConflict          = "conflict"
+	SlugInvalidProof      = "invalid_proof"
+	SlugUsed              = "used"
+	SlugRevoked           = "revoked"
+	SlugPending           = "pending"
+	SlugExpired           = "expired"
+	SlugDenied            = "denied"
+	SlugInvalidTransition = "invalid_transition"
+	SlugSlowDown          = "slow_down"
+)
+
+type errorMapping struct {
+	err    error
+	status int
+	reqErr RequestError
+}
+
+//nolint:gochecknoglobals // a lookup table, never modified
+var errorMappings = []errorMapping{
+	{err: ErrUnauthenticated, status: http.StatusUnauthorized, reqErr: RequestError{Slug: SlugAccessDenied, Header: "Authorization"}},
+	{err: ErrForbidden, status: http.StatusForbidden, reqErr: RequestError{Slug: SlugAccessDenied}},
+	{err: grants.ErrTenantMismatch, status: http.StatusForbidden, reqErr: RequestError{Slug: SlugAccessDenied}},
+
+	{err: grants.ErrGrantNotFound, status: http.StatusNotFound, reqErr: RequestError{Slug: SlugNotFound}},
+	{err: grants.ErrGrantAlreadyExists, status: http.StatusConflict, reqErr: RequestError{Slug: SlugConflict, Field: "/id"}},
+	{err: grants.ErrGrantSourceAlreadyUsed, status: http.StatusConflict, reqErr: RequestError{Slug: SlugConflict, Field: "/source_id"}},
+	{err: grants.ErrInvalidProof, status: http.StatusUnauthorized, reqErr: RequestError{Slug: SlugInvalidProof, Header: ProofHeader}},
+	{err: grants.ErrSourceTypeNotRegistered, status: http.StatusBadRequest, reqErr: RequestError{Slug: SlugInvalidValue, Field: "/source_type"}},
+
+	{err: grants.ErrGrantAlreadyUsed, status: http.StatusConflict, reqErr: RequestError{Slug: SlugUsed}},
+	{err: grants.ErrGrantRevoked, status: http.StatusConflict, reqErr: RequestError{Slug: SlugRevoked}},
+	{err: grants.ErrGrantPending, status: http.StatusConflict, reqErr: RequestError{Slug: SlugPending}},
+	{err: grants.ErrGrantExpired, status: http.StatusConflict, reqErr: RequestError{Slug: SlugExpired}},
+	{err: grants.ErrGrantDenied, status: http.StatusConflict, reqErr: RequestError{Slug: SlugDenied}},
+	{err: grants.ErrInvalidGrantTransition, status: http.StatusConflict, reqErr: RequestError{Slug: SlugInvalidTransition}},
+	{err: grants.ErrInvalidGrantState, status: http.StatusBadRequest, reqErr: RequestError{Slug: SlugInvalidValue, Field: "/state"}},
+
+	{err: grants.ErrDeviceAuthorizationNotFound, status: http.StatusNotFound, reqErr: RequestError{Slug: SlugNotFound}},
+	{err: grants.ErrDeviceAuthorizationAlreadyExists, status: http.StatusConflict, reqErr: RequestError{Slug: SlugConflict}},
+	{err: grants.ErrDeviceAuthorizationPending, status: http.StatusConflict, reqErr: RequestError{Slug: SlugPending}},
+	{err: grants.ErrDeviceSlowDown, status: http.StatusTooManyRequests, reqErr: RequestError{Slug: SlugSlowDown}},
+	{err: grants.ErrDeviceAccessDenied, status: http.StatusConflict, reqErr: RequestError{Slug: SlugDenied}},
+	{err: grants.ErrDeviceCodeExpired, status: http.StatusConflict, reqErr: RequestError{Slug: SlugExpired}},
+	{err: grants.ErrDeviceAuthorizationNotPending, status: http.StatusConflict, reqErr: RequestError{Slug: SlugInvalidTransition}},
+	{err: grants.ErrInvalidDeviceStatus, status: http.StatusBadRequest, reqErr: RequestError{Slug: SlugInvalidValue, Field: "/status"}},
+}
+
+// ErrorResponse returns the status code and RequestError that describe
+// `err`, matching it against the errors defined by the grants package and
+// this package with errors.Is. Errors that don't match any of them are
+// reported as an http.StatusInternalServerError.
+func ErrorResponse(err error) (int, RequestError) {
+	for _, mapping := range errorMappings {
+		if errors.Is(err, mapping.err) {
+			return mapping.status, mapping.reqErr
+		}
+	}
+	return http.StatusInternalServerError, RequestError{Slug: SlugActOfGod}
+}
@@ -0,0 +1,318 @@
+// Package http exposes a grants.Storer over HTTP, as JSON endpoints for
+// creating, retrieving, exchanging, and revoking Grants:
+//
+//	POST /grants               create a Grant
+//	GET  /grants/{id}          retrieve a Grant
+//	POST /grants/{id}/exchange exchange a Grant for a session
+//	POST /grants/{id}/revoke   revoke a Grant
+//
+// Every request is checked by the API's Authorizer before it reaches the
+// Storer, as these endpoints are expected to be called from untrusted
+// sources. Every response is a Response, holding the Grants affected by the
+// request or the errors that prevented it from succeeding.
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+const (
+	// ProofHeader is the header proofs of possession are read from when
+	// exchanging Grants.
+	ProofHeader = "DPoP"
+
+	// maxBodyBytes is the largest request body the API will read.
+	maxBodyBytes = 1 << 20
+)
+
+// API serves the HTTP endpoints described by the package documentation.
+type API struct {
+	grants.Dependencies
+
+	// Authorizer decides whether each request may proceed. If nil, every
+	// request is rejected.
+	Authorizer Authorizer
+
+	// TrustedProxies is the number of proxies in front of the API that
+	// append to the X-Forwarded-For header. If it's more than 0, the
+	// client IP recorded on Grants is read from that header, as
+	// described by ClientIP. It must match the deployment exactly: too
+	// many lets clients pick the IP that's recorded.
+	TrustedProxies int
+
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+}
+
+// Grant is the JSON representation of a grants.Grant.
+type Grant struct {
+	ID            string     `json:"id"`
+	SourceType    string     `json:"source_type"`
+	SourceID      string     `json:"source_id"`
+	AncestorIDs   []string   `json:"ancestor_ids,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UsedAt        *time.Time `json:"used_at,omitempty"`
+	Scopes        []string   `json:"scopes,omitempty"`
+	AccountID     string     `json:"account_id,omitempty"`
+	ProfileID     string     `json:"profile_id,omitempty"`
+	ClientID      string     `json:"client_id,omitempty"`
+	CreateIP      string     `json:"create_ip,omitempty"`
+	UseIP         string     `json:"use_ip,omitempty"`
+	KeyThumbprint string     `json:"key_thumbprint,omitempty"`
+	State         string     `json:"state"`
+}
+
+// Response is the body of every response the API returns.
+type Response struct {
+	Grants []Grant        `json:"grants,omitempty"`
+	Errors []RequestError `json:"errors,omitempty"`
+}
+
+// RequestError describes a problem with a request. Slug identifies the
+// problem, and Field, Param, or Header identify the part of the request that
+// caused it, if any; Field is a JSON pointer into the request body.
+type RequestError struct {
+	Slug   string `json:"error,omitempty"`
+	Field  string `json:"field,omitempty"`
+	Param  string `json:"param,omitempty"`
+	Header string `json:"header,omitempty"`
+}
+
+// createRequest is the body of a request to create a Grant. Only the fields
+// the caller is allowed to choose are accepted; the rest are set by the API.
+type createRequest struct {
+	SourceType    string   `json:"source_type"`
+	SourceID      string   `json:"source_id"`
+	Scopes        []string `json:"scopes"`
+	AccountID     string   `json:"account_id"`
+	ProfileID     string   `json:"profile_id"`
+	ClientID      string   `json:"client_id"`
+	KeyThumbprint string   `json:"key_thumbprint"`
+	State         string   `json:"state"`
+}
+
+// FromGrant returns the JSON representation of `grant`.
+func FromGrant(grant grants.Grant) Grant {
+	res := Grant{
+		ID:            grant.ID,
+		SourceType:    grant.SourceType,
+		SourceID:      grant.SourceID,
+		AncestorIDs:   grant.AncestorIDs,
+		CreatedAt:     grant.CreatedAt,
+		Scopes:        grant.Scopes,
+		AccountID:     grant.AccountID,
+		ProfileID:     grant.ProfileID,
+		ClientID:      grant.ClientID,
+		CreateIP:      grant.CreateIP,
+		UseIP:         grant.UseIP,
+		KeyThumbprint: grant.KeyThumbprint,
+		State:         string(grant.State),
+	}
+	if !grant.UsedAt.IsZero() {
+		usedAt := grant.UsedAt
+		res.UsedAt = &usedAt
+	}
+	return res
+}
+
+// Handler returns an http.Handler serving the API's endpoints. Paths are
+// matched from the root, so use http.StripPrefix to serve the API under a
+// prefix.
+func (a API) Handler() http.Handler {
+	return http.HandlerFunc(a.route)
+}
+
+func (a API) route(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	if parts[0] != "grants" || len(parts) > 3 {
+		writeErrors(w, http.StatusNotFound, RequestError{Slug: SlugNotFound})
+		return
+	}
+	switch {
+	case len(parts) == 1:
+		a.serveMethod(w, r, http.MethodPost, a.handleCreate)
+	case parts[1] == "":
+		writeErrors(w, http.StatusNotFound, RequestError{Slug: SlugNotFound})
+	case len(parts) == 2:
+		a.serveMethod(w, r, http.MethodGet, func(w http.ResponseWriter, r *http.Request) {
+			a.handleGet(w, r, parts[1])
+		})
+	case parts[2] == "exchange":
+		a.serveMethod(w, r, http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+			a.handleExchange(w, r, parts[1])
+		})
+	case parts[2] == "revoke":
+		a.serveMethod(w, r, http.MethodPost, func(w http.ResponseWriter, r *http.Request) {
+			a.handleRevoke(w, r, parts[1])
+		})
+	default:
+		writeErrors(w, http.StatusNotFound, RequestError{Slug: SlugNotFound})
+	}
+}
+
+func (a API) serveMethod(w http.ResponseWriter, r *http.Request, method string, handler http.HandlerFunc) {
+	if r.Method != method {
+		w.Header().Set("Allow", method)
+		writeErrors(w, http.StatusMethodNotAllowed, RequestError{Slug: SlugMethodNotAllowed})
+		return
+	}
+	handler(w, r)
+}
+
+// authorize runs the API's Authorizer for the request, writing an error
+// response and returning false if the request may not proceed.
+func (a API) authorize(w http.ResponseWriter, r *http.Request, action Action, grantID string) (context.Context, bool) {
+	if a.Authorizer == nil {
+		writeErrors(w, http.StatusUnauthorized, RequestError{Slug: SlugAccessDenied, Header: "Authorization"})
+		return nil, false
+	}
+	ctx, err := a.Authorizer.Authorize(r, action, grantID)
+	if err != nil {
+		a.writeError(r.Context(), w, err)
+		return nil, false
+	}
+	if ctx == nil {
+		ctx = r.Context()
+	}
+	return ctx, true
+}
+
+func (a API) handleCreate(w http.ResponseWriter, r *http.Request) {
+	ctx, ok := a.authorize(w, r, ActionCreate, "")
+	if !ok {
+		return
+	}
+	var body createRequest
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&body)
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, RequestError{Slug: SlugInvalidFormat, Field: "/"})
+		return
+	}
+	var reqErrs []RequestError
+	if body.SourceType == "" {
+		reqErrs = append(reqErrs, RequestError{Slug: SlugMissing, Field: "/source_type"})
+	}
+	if body.SourceID == "" {
+		reqErrs = append(reqErrs, RequestError{Slug: SlugMissing, Field: "/source_id"})
+	}
+	if body.ProfileID == "" {
+		reqErrs = append(reqErrs, RequestError{Slug: SlugMissing, Field: "/profile_id"})
+	}
+	if len(reqErrs) > 0 {
+		writeErrors(w, http.StatusBadRequest, reqErrs...)
+		return
+	}
+	grant, err := grants.FillGrantDefaults(grants.Grant{
+		SourceType:    body.SourceType,
+		SourceID:      body.SourceID,
+		CreatedAt:     a.now(),
+		Scopes:        body.Scopes,
+		AccountID:     body.AccountID,
+		ProfileID:     body.ProfileID,
+		ClientID:      body.ClientID,
+		CreateIP:      ClientIP(r, a.TrustedProxies),
+		KeyThumbprint: body.KeyThumbprint,
+		State:         grants.GrantState(body.State),
+	})
+	if err != nil {
+		a.writeError(ctx, w, err)
+		return
+	}
+	err = a.Storer.CreateGrant(ctx, grant)
+	if err != nil {
+		a.writeError(ctx, w, err)
+		return
+	}
+	grant, err = a.Storer.GetGrant(ctx, grant.ID)
+	if err != nil {
+		a.writeError(ctx, w, err)
+		return
+	}
+	writeGrant(w, http.StatusCreated, grant)
+}
+
+func (a API) handleGet(w http.ResponseWriter, r *http.Request, id string) {
+	ctx, ok := a.authorize(w, r, ActionGet, id)
+	if !ok {
+		return
+	}
+	grant, err := a.Storer.GetGrant(ctx, id)
+	if err != nil {
+		a.writeError(ctx, w, err)
+		return
+	}
+	writeGrant(w, http.StatusOK, grant)
+}
+
+func (a API) handleExchange(w http.ResponseWriter, r *http.Request, id string) {
+	ctx, ok := a.authorize(w, r, ActionExchange, id)
+	if !ok {
+		return
+	}
+	grant, err := a.Storer.ExchangeGrant(ctx, grants.GrantUse{
+		Grant: id,
+		IP:    ClientIP(r, a.TrustedProxies),
+		Time:  a.now(),
+		Proof: r.Header.Get(ProofHeader),
+	})
+	if err != nil {
+		a.writeError(ctx, w, err)
+		return
+	}
+	writeGrant(w, http.StatusOK, grant)
+}
+
+func (a API) handleRevoke(w http.ResponseWriter, r *http.Request, id string) {
+	ctx, ok := a.authorize(w, r, ActionRevoke, id)
+	if !ok {
+		return
+	}
+	grant, err := a.Storer.RevokeGrant(ctx, id)
+	if err != nil {
+		a.writeError(ctx, w, err)
+		return
+	}
+	writeGrant(w, http.StatusOK, grant)
+}
+
+func (a API) now() time.Time {
+	if a.Now != nil {
+		return a.Now()
+	}
+	return time.Now()
+}
+
+// writeError writes the response for `err`, logging it if it isn't one the
+// caller is expected to handle.
+func (a API) writeError(ctx context.Context, w http.ResponseWriter, err error) {
+	status, reqErr := ErrorResponse(err)
+	if status == http.StatusInternalServerError && !errors.Is(err, context.Canceled) {
+		yall.FromContext(ctx).WithError(err).Error("error serving grants request")
+	}
+	writeErrors(w, status, reqErr)
+}
+
+func writeGrant(w http.ResponseWriter, status int, grant grants.Grant) {
+	writeJSON(w, status, Response{Grants: []Grant{FromGrant(grant)}})
+}
+
+func writeErrors(w http.ResponseWriter, status int, errs ...RequestError) {
+	writeJSON(w, status, Response{Errors: errs})
+}
+
+func writeJSON(w http.ResponseWriter, status int, resp Response) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp) //nolint:errcheck,errchkjson // nothing to be done if the client went away
+}
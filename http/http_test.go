@@ -0,0 +1,379 @@
+package http_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"lockbox.dev/grants"
+	grantshttp "lockbox.dev/grants/http"
+	"lockbox.dev/grants/storers/memory"
+)
+
+const (
+	testToken = "Bearer test"
+
+	// missingID is the ID of a Grant that never exists.
+	missingID = "00000000-0000-0000-0000-000000000000"
+)
+
+// testAuthorizer allows requests carrying testToken, scoping them to the
+// tenant in the X-Tenant header, and forbids revoking.
+func testAuthorizer(r *http.Request, action grantshttp.Action, _ string) (context.Context, error) {
+	if r.Header.Get("Authorization") != testToken {
+		return nil, grantshttp.ErrUnauthenticated
+	}
+	if action == grantshttp.ActionRevoke && r.Header.Get("X-Can-Revoke") == "" {
+		return nil, grantshttp.ErrForbidden
+	}
+	return grants.WithTenant(r.Context(), r.Header.Get("X-Tenant")), nil
+}
+
+func newAPI(t *testing.T) (grantshttp.API, time.Time) {
+	t.Helper()
+	storer, err := memory.NewStorer()
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+	now := time.Now().Round(time.Millisecond).UTC()
+	return grantshttp.API{
+		Dependencies: grants.Dependencies{Storer: storer},
+		Authorizer:   grantshttp.AuthorizerFunc(testAuthorizer),
+		Now:          func() time.Time { return now },
+	}, now
+}
+
+func do(t *testing.T, api grantshttp.API, method, path, body string, headers map[string]string) (int, grantshttp.Response) {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.RemoteAddr = "1.2.3.4:5678"
+	req.Header.Set("Authorization", testToken)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	api.Handler().ServeHTTP(w, req)
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Expected Content-Type %q, got %q", "application/json", ct)
+	}
+	var resp grantshttp.Response
+	err := json.Unmarshal(w.Body.Bytes(), &resp)
+	if err != nil {
+		t.Fatalf("Error decoding response %q: %s", w.Body.String(), err)
+	}
+	return w.Code, resp
+}
+
+func createOrFail(t *testing.T, api grantshttp.API, headers map[string]string) grantshttp.Grant {
+	t.Helper()
+	status, resp := do(t, api, http.MethodPost, "/grants", `{
+		"source_type": "manual",
+		"source_id": "`+t.Name()+`",
+		"scopes": ["https://scopes.impractical.co/test"],
+		"profile_id": "user",
+		"client_id": "testrunner"
+	}`, headers)
+	if status != http.StatusCreated {
+		t.Fatalf("Expected status %d creating grant, got %d: %+v", http.StatusCreated, status, resp.Errors)
+	}
+	if len(resp.Grants) != 1 {
+		t.Fatalf("Expected 1 grant, got %+v", resp.Grants)
+	}
+	return resp.Grants[0]
+}
+
+func TestCreateGetExchange(t *testing.T) {
+	t.Parallel()
+
+	api, now := newAPI(t)
+	created := createOrFail(t, api, nil)
+	expected := grantshttp.Grant{
+		ID:         created.ID,
+		SourceType: "manual",
+		SourceID:   t.Name(),
+		CreatedAt:  now,
+		Scopes:     []string{"https://scopes.impractical.co/test"},
+		ProfileID:  "user",
+		ClientID:   "testrunner",
+		CreateIP:   "1.2.3.4",
+		State:      string(grants.GrantStateActive),
+	}
+	if diff := cmp.Diff(expected, created); diff != "" {
+		t.Errorf("Unexpected diff creating grant (-wanted, +got): %s", diff)
+	}
+
+	status, resp := do(t, api, http.MethodGet, "/grants/"+created.ID, "", nil)
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d getting grant, got %d: %+v", http.StatusOK, status, resp.Errors)
+	}
+	if diff := cmp.Diff([]grantshttp.Grant{expected}, resp.Grants); diff != "" {
+		t.Errorf("Unexpected diff getting grant (-wanted, +got): %s", diff)
+	}
+
+	status, resp = do(t, api, http.MethodPost, "/grants/"+created.ID+"/exchange", "", map[string]string{
+		"X-Forwarded-For": "8.8.8.8",
+	})
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d exchanging grant, got %d: %+v", http.StatusOK, status, resp.Errors)
+	}
+	expected.State = string(grants.GrantStateUsed)
+	expected.UsedAt = &now
+	// X-Forwarded-For isn't trusted by default
+	expected.UseIP = "1.2.3.4"
+	if diff := cmp.Diff([]grantshttp.Grant{expected}, resp.Grants); diff != "" {
+		t.Errorf("Unexpected diff exchanging grant (-wanted, +got): %s", diff)
+	}
+
+	status, resp = do(t, api, http.MethodPost, "/grants/"+created.ID+"/exchange", "", nil)
+	if status != http.StatusConflict {
+		t.Errorf("Expected status %d re-exchanging grant, got %d", http.StatusConflict, status)
+	}
+	if diff := cmp.Diff([]grantshttp.RequestError{{Slug: grantshttp.SlugUsed}}, resp.Errors); diff != "" {
+		t.Errorf("Unexpected diff re-exchanging grant (-wanted, +got): %s", diff)
+	}
+}
+
+func TestExchangeForwardedFor(t *testing.T) {
+	t.Parallel()
+
+	api, _ := newAPI(t)
+	api.TrustedProxies = 1
+	created := createOrFail(t, api, map[string]string{"X-Forwarded-For": "9.9.9.9"})
+	if created.CreateIP != "9.9.9.9" {
+		t.Errorf("Expected create IP %q, got %q", "9.9.9.9", created.CreateIP)
+	}
+
+	// the client sent its own X-Forwarded-For header, and the proxy
+	// appended the address it really came from
+	status, resp := do(t, api, http.MethodPost, "/grants/"+created.ID+"/exchange", "", map[string]string{
+		"X-Forwarded-For": "6.6.6.6, 8.8.8.8",
+	})
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d exchanging grant, got %d: %+v", http.StatusOK, status, resp.Errors)
+	}
+	if resp.Grants[0].UseIP != "8.8.8.8" {
+		t.Errorf("Expected use IP %q, got %q", "8.8.8.8", resp.Grants[0].UseIP)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		forwardedFor   []string
+		trustedProxies int
+		expected       string
+	}{
+		"untrusted":         {forwardedFor: []string{"9.9.9.9"}, expected: "192.0.2.1"},
+		"one-proxy":         {forwardedFor: []string{"9.9.9.9"}, trustedProxies: 1, expected: "9.9.9.9"},
+		"one-proxy-spoofed": {forwardedFor: []string{"6.6.6.6, 9.9.9.9"}, trustedProxies: 1, expected: "9.9.9.9"},
+		"two-proxies":       {forwardedFor: []string{"6.6.6.6, 9.9.9.9, 10.0.0.1"}, trustedProxies: 2, expected: "9.9.9.9"},
+		"two-headers":       {forwardedFor: []string{"6.6.6.6", "9.9.9.9, 10.0.0.1"}, trustedProxies: 2, expected: "9.9.9.9"},
+		"fewer-entries":     {forwardedFor: []string{"9.9.9.9"}, trustedProxies: 2, expected: "9.9.9.9"},
+		"no-header":         {trustedProxies: 1, expected: "192.0.2.1"},
+		"not-an-ip":         {forwardedFor: []string{"6.6.6.6, unknown"}, trustedProxies: 1, expected: "192.0.2.1"},
+	}
+
+	for name, test := range cases {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			for _, header := range test.forwardedFor {
+				r.Header.Add("X-Forwarded-For", header)
+			}
+			if got := grantshttp.ClientIP(r, test.trustedProxies); got != test.expected {
+				t.Errorf("Expected %q, got %q", test.expected, got)
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	t.Parallel()
+
+	api, _ := newAPI(t)
+	created := createOrFail(t, api, nil)
+
+	status, resp := do(t, api, http.MethodPost, "/grants/"+created.ID+"/revoke", "", nil)
+	if status != http.StatusForbidden {
+		t.Errorf("Expected status %d revoking without permission, got %d", http.StatusForbidden, status)
+	}
+	if diff := cmp.Diff([]grantshttp.RequestError{{Slug: grantshttp.SlugAccessDenied}}, resp.Errors); diff != "" {
+		t.Errorf("Unexpected diff revoking without permission (-wanted, +got): %s", diff)
+	}
+
+	status, resp = do(t, api, http.MethodPost, "/grants/"+created.ID+"/revoke", "", map[string]string{"X-Can-Revoke": "1"})
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d revoking grant, got %d: %+v", http.StatusOK, status, resp.Errors)
+	}
+	if resp.Grants[0].State != string(grants.GrantStateRevoked) {
+		t.Errorf("Expected state %q, got %q", grants.GrantStateRevoked, resp.Grants[0].State)
+	}
+
+	status, resp = do(t, api, http.MethodPost, "/grants/"+created.ID+"/exchange", "", nil)
+	if status != http.StatusConflict {
+		t.Errorf("Expected status %d exchanging revoked grant, got %d", http.StatusConflict, status)
+	}
+	if diff := cmp.Diff([]grantshttp.RequestError{{Slug: grantshttp.SlugRevoked}}, resp.Errors); diff != "" {
+		t.Errorf("Unexpected diff exchanging revoked grant (-wanted, +got): %s", diff)
+	}
+}
+
+func TestTenantIsolation(t *testing.T) {
+	t.Parallel()
+
+	api, _ := newAPI(t)
+	created := createOrFail(t, api, map[string]string{"X-Tenant": "tenant-a"})
+
+	status, resp := do(t, api, http.MethodGet, "/grants/"+created.ID, "", map[string]string{"X-Tenant": "tenant-b"})
+	if status != http.StatusNotFound {
+		t.Errorf("Expected status %d getting another tenant's grant, got %d", http.StatusNotFound, status)
+	}
+	if diff := cmp.Diff([]grantshttp.RequestError{{Slug: grantshttp.SlugNotFound}}, resp.Errors); diff != "" {
+		t.Errorf("Unexpected diff getting another tenant's grant (-wanted, +got): %s", diff)
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		method  string
+		path    string
+		body    string
+		headers map[string]string
+		status  int
+		errs    []grantshttp.RequestError
+	}{
+		"unauthenticated": {
+			method: http.MethodGet, path: "/grants/" + missingID,
+			headers: map[string]string{"Authorization": "Bearer wrong"},
+			status:  http.StatusUnauthorized,
+			errs:    []grantshttp.RequestError{{Slug: grantshttp.SlugAccessDenied, Header: "Authorization"}},
+		},
+		"not-found": {
+			method: http.MethodGet, path: "/grants/" + missingID,
+			status: http.StatusNotFound,
+			errs:   []grantshttp.RequestError{{Slug: grantshttp.SlugNotFound}},
+		},
+		"unknown-path": {
+			method: http.MethodGet, path: "/grants/" + missingID + "/def",
+			status: http.StatusNotFound,
+			errs:   []grantshttp.RequestError{{Slug: grantshttp.SlugNotFound}},
+		},
+		"wrong-method": {
+			method: http.MethodDelete, path: "/grants/" + missingID,
+			status: http.StatusMethodNotAllowed,
+			errs:   []grantshttp.RequestError{{Slug: grantshttp.SlugMethodNotAllowed}},
+		},
+		"invalid-json": {
+			method: http.MethodPost, path: "/grants", body: `{`,
+			status: http.StatusBadRequest,
+			errs:   []grantshttp.RequestError{{Slug: grantshttp.SlugInvalidFormat, Field: "/"}},
+		},
+		"unknown-field": {
+			method: http.MethodPost, path: "/grants", body: `{"id": "chosen"}`,
+			status: http.StatusBadRequest,
+			errs:   []grantshttp.RequestError{{Slug: grantshttp.SlugInvalidFormat, Field: "/"}},
+		},
+		"missing-fields": {
+			method: http.MethodPost, path: "/grants", body: `{}`,
+			status: http.StatusBadRequest,
+			errs: []grantshttp.RequestError{
+				{Slug: grantshttp.SlugMissing, Field: "/source_type"},
+				{Slug: grantshttp.SlugMissing, Field: "/source_id"},
+				{Slug: grantshttp.SlugMissing, Field: "/profile_id"},
+			},
+		},
+		"invalid-state": {
+			method: http.MethodPost, path: "/grants",
+			body:   `{"source_type": "manual", "source_id": "state", "profile_id": "user", "state": "used"}`,
+			status: http.StatusBadRequest,
+			errs:   []grantshttp.RequestError{{Slug: grantshttp.SlugInvalidValue, Field: "/state"}},
+		},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			api, _ := newAPI(t)
+			status, resp := do(t, api, test.method, test.path, test.body, test.headers)
+			if status != test.status {
+				t.Errorf("Expected status %d, got %d", test.status, status)
+			}
+			if diff := cmp.Diff(test.errs, resp.Errors); diff != "" {
+				t.Errorf("Unexpected diff in errors (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
+
+func TestSourceAlreadyUsed(t *testing.T) {
+	t.Parallel()
+
+	api, _ := newAPI(t)
+	createOrFail(t, api, nil)
+	status, resp := do(t, api, http.MethodPost, "/grants", `{"source_type": "manual", "source_id": "`+t.Name()+`", "profile_id": "user"}`, nil)
+	if status != http.StatusConflict {
+		t.Errorf("Expected status %d, got %d", http.StatusConflict, status)
+	}
+	if diff := cmp.Diff([]grantshttp.RequestError{{Slug: grantshttp.SlugConflict, Field: "/source_id"}}, resp.Errors); diff != "" {
+		t.Errorf("Unexpected diff in errors (-wanted, +got): %s", diff)
+	}
+}
+
+func TestNoAuthorizer(t *testing.T) {
+	t.Parallel()
+
+	api, _ := newAPI(t)
+	api.Authorizer = nil
+	status, _ := do(t, api, http.MethodGet, "/grants/"+missingID, "", nil)
+	if status != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, status)
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		err    error
+		status int
+		slug   string
+	}{
+		"wrapped-not-found": {err: fmt.Errorf("looking up grant: %w", grants.ErrGrantNotFound), status: http.StatusNotFound, slug: grantshttp.SlugNotFound},
+		"pending":           {err: grants.ErrGrantPending, status: http.StatusConflict, slug: grantshttp.SlugPending},
+		"expired":           {err: grants.ErrGrantExpired, status: http.StatusConflict, slug: grantshttp.SlugExpired},
+		"denied":            {err: grants.ErrGrantDenied, status: http.StatusConflict, slug: grantshttp.SlugDenied},
+		"invalid-proof":     {err: grants.ErrInvalidProof, status: http.StatusUnauthorized, slug: grantshttp.SlugInvalidProof},
+		"slow-down":         {err: grants.ErrDeviceSlowDown, status: http.StatusTooManyRequests, slug: grantshttp.SlugSlowDown},
+		"unknown":           {err: errors.New("surprise"), status: http.StatusInternalServerError, slug: grantshttp.SlugActOfGod},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			status, reqErr := grantshttp.ErrorResponse(test.err)
+			if status != test.status {
+				t.Errorf("Expected status %d, got %d", test.status, status)
+			}
+			if reqErr.Slug != test.slug {
+				t.Errorf("Expected slug %q, got %q", test.slug, reqErr.Slug)
+			}
+		})
+	}
+}
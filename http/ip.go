@@ -0,0 +1,42 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientIP returns the IP address `r` came from. If `trustedProxies` is more
+// than 0, the request is expected to have passed through that many proxies,
+// each appending the address it received the request from to the
+// X-Forwarded-For header, and the address the outermost of them received it
+// from is used. Entries to the left of that were sent by the client, and can't
+// be trusted. If the header is missing or that entry isn't an IP address, or
+// `trustedProxies` is 0, the address of the connection is used.
+func ClientIP(r *http.Request, trustedProxies int) string {
+	if trustedProxies > 0 {
+		var entries []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			entries = append(entries, strings.Split(header, ",")...)
+		}
+		if len(entries) > 0 {
+			// with fewer entries than proxies, every entry was
+			// added by a proxy, and the leftmost is the client
+			pos := len(entries) - trustedProxies
+			if pos < 0 {
+				pos = 0
+			}
+			if ip := net.ParseIP(strings.TrimSpace(entries[pos])); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		return ip.String()
+	}
+	return host
+}
@@ -0,0 +1,154 @@
+package jose_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+
+	"lockbox.dev/grants/jose"
+)
+
+func TestThumbprintRFC7638(t *testing.T) {
+	t.Parallel()
+
+	// the example key from RFC 7638 section 3.1
+	key := jose.JWK{
+		KeyType:   jose.KeyTypeRSA,
+		KeyID:     "2011-04-29",
+		Algorithm: "RS256",
+		N:         "0vx7agoebGcQSuuPiLJXZptN9nndrQmbXEps2aiAFbWhM78LhWx4cbbfAAtVT86zwu1RK7aPFFxuhDR1L6tSoc_BJECPebWKRXjBZCiFV4n3oknjhMstn64tZ_2W-5JsGY4Hc5n9yBXArwl93lqt7_RN5w6Cf0h4QyQ5v-65YGjQR0_FDW2QvzqY368QQMicAtaSqzs8KJZgnYb9c7d0zgdAZHzu6qMQvRL5hajrn1n91CbOpbISD08qNLyrdkt-bFTWhAI4vMQFh6WeZu0fM4lFd2NcRwr3XPksINHaQ-G_xBniIqbw0Ls1jF44-csFCur-kEgU8awapJzKnqDKgw",
+		E:         "AQAB",
+	}
+	thumbprint, err := key.Thumbprint()
+	if err != nil {
+		t.Fatalf("Unexpected error computing thumbprint: %s", err)
+	}
+	if thumbprint != "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs" {
+		t.Errorf("Expected thumbprint %q, got %q", "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs", thumbprint)
+	}
+}
+
+func generateKeys(t *testing.T) map[string]crypto.Signer {
+	t.Helper()
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating ECDSA key: %s", err)
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating RSA key: %s", err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating Ed25519 key: %s", err)
+	}
+	return map[string]crypto.Signer{
+		"ES256": ecKey,
+		"RS256": rsaKey,
+		"PS256": rsaKey,
+		"EdDSA": edKey,
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	t.Parallel()
+
+	for alg, key := range generateKeys(t) {
+		alg, key := alg, key
+		t.Run(alg, func(t *testing.T) {
+			t.Parallel()
+
+			jwk, err := jose.NewJWK(key.Public())
+			if err != nil {
+				t.Fatalf("Unexpected error creating JWK: %s", err)
+			}
+			token, err := jose.Sign(jose.Header{Algorithm: alg, JWK: &jwk}, []byte(`{"sub":"tester"}`), key)
+			if err != nil {
+				t.Fatalf("Unexpected error signing: %s", err)
+			}
+			parsed, err := jose.Parse(token)
+			if err != nil {
+				t.Fatalf("Unexpected error parsing: %s", err)
+			}
+			pub, err := parsed.Header.JWK.PublicKey()
+			if err != nil {
+				t.Fatalf("Unexpected error decoding JWK: %s", err)
+			}
+			var claims struct {
+				Subject string `json:"sub"`
+			}
+			err = parsed.UnmarshalClaims(pub, &claims)
+			if err != nil {
+				t.Fatalf("Unexpected error verifying: %s", err)
+			}
+			if claims.Subject != "tester" {
+				t.Errorf("Expected sub %q, got %q", "tester", claims.Subject)
+			}
+
+			tampered, err := jose.Parse(token[:len(token)-4] + "AAAA")
+			if err != nil {
+				t.Fatalf("Unexpected error parsing tampered token: %s", err)
+			}
+			err = tampered.Verify(pub)
+			if !errors.Is(err, jose.ErrInvalidSignature) {
+				t.Errorf("Expected error %v verifying tampered token, got %v", jose.ErrInvalidSignature, err)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsUnsupportedAlgorithms(t *testing.T) {
+	t.Parallel()
+
+	for _, alg := range []string{"none", "HS256", ""} {
+		alg := alg
+		t.Run(alg, func(t *testing.T) {
+			t.Parallel()
+
+			// header {"alg":<alg>} with an empty payload and signature
+			token, err := jose.Parse(encodeHeader(t, alg) + "..")
+			if err != nil {
+				t.Fatalf("Unexpected error parsing: %s", err)
+			}
+			err = token.Verify(nil)
+			if !errors.Is(err, jose.ErrUnsupportedAlgorithm) {
+				t.Errorf("Expected error %v, got %v", jose.ErrUnsupportedAlgorithm, err)
+			}
+		})
+	}
+}
+
+func encodeHeader(t *testing.T, alg string) string {
+	t.Helper()
+	header := map[string]string{
+		"none":  "eyJhbGciOiJub25lIn0",
+		"HS256": "eyJhbGciOiJIUzI1NiJ9",
+		"":      "eyJhbGciOiIifQ",
+	}[alg]
+	if header == "" {
+		t.Fatalf("No test header for %q", alg)
+	}
+	return header
+}
+
+func TestKeySetLookup(t *testing.T) {
+	t.Parallel()
+
+	set := jose.KeySet{Keys: []jose.JWK{{KeyType: jose.KeyTypeRSA, KeyID: "a"}, {KeyType: jose.KeyTypeEC, KeyID: "b"}}}
+	key, err := set.Key("b")
+	if err != nil {
+		t.Fatalf("Unexpected error looking up key: %s", err)
+	}
+	if key.KeyType != jose.KeyTypeEC {
+		t.Errorf("Expected key type %q, got %q", jose.KeyTypeEC, key.KeyType)
+	}
+	_, err = set.Key("c")
+	if !errors.Is(err, jose.ErrKeyNotFound) {
+		t.Errorf("Expected error %v, got %v", jose.ErrKeyNotFound, err)
+	}
+}
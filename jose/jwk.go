@@ -0,0 +1,253 @@
+// Package jose implements the small subset of the JOSE specifications that
+// grants needs: JSON Web Keys (RFC 7517), their thumbprints (RFC 7638), and
+// verification of compact JSON Web Signatures (RFC 7515).
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	// KeyTypeEC is the kty value for elliptic curve keys.
+	KeyTypeEC = "EC"
+	// KeyTypeRSA is the kty value for RSA keys.
+	KeyTypeRSA = "RSA"
+	// KeyTypeOKP is the kty value for octet key pairs, used for Ed25519
+	// keys.
+	KeyTypeOKP = "OKP"
+)
+
+var (
+	// ErrUnsupportedKey is returned when a JWK uses a key type or curve
+	// that isn't supported.
+	ErrUnsupportedKey = errors.New("unsupported key type")
+	// ErrInvalidKey is returned when a JWK is missing required members or
+	// has members that can't be decoded.
+	ErrInvalidKey = errors.New("invalid key")
+	// ErrKeyNotFound is returned when a KeySet has no key matching the
+	// requested key ID.
+	ErrKeyNotFound = errors.New("key not found")
+)
+
+// JWK is a JSON Web Key, as described in RFC 7517. Only public keys are
+// supported.
+type JWK struct {
+	KeyType   string `json:"kty"`
+	KeyID     string `json:"kid,omitempty"`
+	Use       string `json:"use,omitempty"`
+	Algorithm string `json:"alg,omitempty"`
+
+	// elliptic curve and octet key pair members
+	Curve string `json:"crv,omitempty"`
+	X     string `json:"x,omitempty"`
+	Y     string `json:"y,omitempty"`
+
+	// RSA members
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// D is the private key member. It is only decoded so that keys
+	// containing private material can be detected and rejected.
+	D string `json:"d,omitempty"`
+}
+
+// KeySet is a JSON Web Key Set, as described in RFC 7517 section 5.
+type KeySet struct {
+	Keys []JWK `json:"keys"`
+}
+
+// Key returns the JWK in the KeySet with a KeyID of `kid`. If no key matches,
+// an ErrKeyNotFound error is returned.
+func (k KeySet) Key(kid string) (JWK, error) {
+	for _, key := range k.Keys {
+		if key.KeyID == kid {
+			return key, nil
+		}
+	}
+	return JWK{}, fmt.Errorf("%w: %q", ErrKeyNotFound, kid)
+}
+
+// NewJWK returns the JWK representation of the passed public key. ECDSA keys
+// on the P-256, P-384, and P-521 curves, RSA keys, and Ed25519 keys are
+// supported.
+func NewJWK(pub crypto.PublicKey) (JWK, error) {
+	switch key := pub.(type) {
+	case *ecdsa.PublicKey:
+		crv, size, err := curveParams(key.Curve)
+		if err != nil {
+			return JWK{}, err
+		}
+		return JWK{
+			KeyType: KeyTypeEC,
+			Curve:   crv,
+			X:       encodeSegment(key.X.FillBytes(make([]byte, size))),
+			Y:       encodeSegment(key.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case *rsa.PublicKey:
+		return JWK{
+			KeyType: KeyTypeRSA,
+			N:       encodeSegment(key.N.Bytes()),
+			E:       encodeSegment(big.NewInt(int64(key.E)).Bytes()),
+		}, nil
+	case ed25519.PublicKey:
+		return JWK{
+			KeyType: KeyTypeOKP,
+			Curve:   "Ed25519",
+			X:       encodeSegment(key),
+		}, nil
+	default:
+		return JWK{}, fmt.Errorf("%w: %T", ErrUnsupportedKey, pub)
+	}
+}
+
+// PublicKey returns the public key the JWK describes. The returned key will be
+// an *ecdsa.PublicKey, an *rsa.PublicKey, or an ed25519.PublicKey.
+func (j JWK) PublicKey() (crypto.PublicKey, error) {
+	if j.D != "" {
+		return nil, fmt.Errorf("%w: contains private key material", ErrInvalidKey)
+	}
+	switch j.KeyType {
+	case KeyTypeEC:
+		return j.ecdsaKey()
+	case KeyTypeRSA:
+		return j.rsaKey()
+	case KeyTypeOKP:
+		if j.Curve != "Ed25519" {
+			return nil, fmt.Errorf("%w: curve %q", ErrUnsupportedKey, j.Curve)
+		}
+		x, err := decodeSegment(j.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%w: bad x", ErrInvalidKey)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedKey, j.KeyType)
+	}
+}
+
+func (j JWK) ecdsaKey() (*ecdsa.PublicKey, error) {
+	var curve elliptic.Curve
+	switch j.Curve {
+	case "P-256":
+		curve = elliptic.P256()
+	case "P-384":
+		curve = elliptic.P384()
+	case "P-521":
+		curve = elliptic.P521()
+	default:
+		return nil, fmt.Errorf("%w: curve %q", ErrUnsupportedKey, j.Curve)
+	}
+	x, err := decodeSegment(j.X)
+	if err != nil || len(x) == 0 {
+		return nil, fmt.Errorf("%w: bad x", ErrInvalidKey)
+	}
+	y, err := decodeSegment(j.Y)
+	if err != nil || len(y) == 0 {
+		return nil, fmt.Errorf("%w: bad y", ErrInvalidKey)
+	}
+	key := &ecdsa.PublicKey{
+		Curve: curve,
+		X:     new(big.Int).SetBytes(x),
+		Y:     new(big.Int).SetBytes(y),
+	}
+	if !curve.IsOnCurve(key.X, key.Y) {
+		return nil, fmt.Errorf("%w: point not on curve", ErrInvalidKey)
+	}
+	return key, nil
+}
+
+func (j JWK) rsaKey() (*rsa.PublicKey, error) {
+	n, err := decodeSegment(j.N)
+	if err != nil || len(n) == 0 {
+		return nil, fmt.Errorf("%w: bad n", ErrInvalidKey)
+	}
+	e, err := decodeSegment(j.E)
+	if err != nil || len(e) == 0 {
+		return nil, fmt.Errorf("%w: bad e", ErrInvalidKey)
+	}
+	exp := new(big.Int).SetBytes(e)
+	if !exp.IsInt64() || exp.Int64() > int64(^uint32(0)>>1) {
+		return nil, fmt.Errorf("%w: exponent too large", ErrInvalidKey)
+	}
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(exp.Int64()),
+	}, nil
+}
+
+// Thumbprint returns the base64url-encoded SHA-256 JWK Thumbprint of the key,
+// as described in RFC 7638. Only the required members for the key type are
+// included, in lexicographic order, so two JWKs describing the same key will
+// always have the same thumbprint.
+func (j JWK) Thumbprint() (string, error) {
+	var members interface{}
+	switch j.KeyType {
+	case KeyTypeEC:
+		if j.Curve == "" || j.X == "" || j.Y == "" {
+			return "", fmt.Errorf("%w: missing crv, x, or y", ErrInvalidKey)
+		}
+		// struct fields are marshaled in declaration order, which
+		// is kept lexicographic here
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{j.Curve, j.KeyType, j.X, j.Y}
+	case KeyTypeRSA:
+		if j.N == "" || j.E == "" {
+			return "", fmt.Errorf("%w: missing n or e", ErrInvalidKey)
+		}
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.KeyType, j.N}
+	case KeyTypeOKP:
+		if j.Curve == "" || j.X == "" {
+			return "", fmt.Errorf("%w: missing crv or x", ErrInvalidKey)
+		}
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Curve, j.KeyType, j.X}
+	default:
+		return "", fmt.Errorf("%w: %q", ErrUnsupportedKey, j.KeyType)
+	}
+	canonical, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return encodeSegment(sum[:]), nil
+}
+
+func curveParams(curve elliptic.Curve) (string, int, error) {
+	params := curve.Params()
+	size := (params.BitSize + 7) / 8 //nolint:gomnd // rounding bits up to bytes
+	switch params.Name {
+	case "P-256", "P-384", "P-521":
+		return params.Name, size, nil
+	default:
+		return "", 0, fmt.Errorf("%w: curve %q", ErrUnsupportedKey, params.Name)
+	}
+}
+
+func encodeSegment(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSegment(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
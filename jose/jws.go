@@ -0,0 +1,227 @@
+package jose
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	// register the hash functions used by the supported algorithms
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+)
+
+const jwsSegments = 3
+
+var (
+	// ErrMalformedJWS is returned when a compact JWS can't be parsed.
+	ErrMalformedJWS = errors.New("malformed JWS")
+	// ErrUnsupportedAlgorithm is returned when a JWS uses a signing
+	// algorithm that isn't supported, including "none" and the symmetric
+	// HMAC algorithms.
+	ErrUnsupportedAlgorithm = errors.New("unsupported signing algorithm")
+	// ErrInvalidSignature is returned when a JWS signature doesn't verify
+	// against the key it's checked with.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Header is the protected header of a JWS.
+type Header struct {
+	Algorithm string `json:"alg"`
+	Type      string `json:"typ,omitempty"`
+	KeyID     string `json:"kid,omitempty"`
+	JWK       *JWK   `json:"jwk,omitempty"`
+}
+
+// JWS is a parsed JSON Web Signature in compact serialization. Parsing a JWS
+// does not verify it; Verify must be called before trusting the Payload.
+type JWS struct {
+	Header  Header
+	Payload []byte
+
+	signingInput string
+	signature    []byte
+}
+
+// Parse decodes a JWS in compact serialization.
+func Parse(token string) (JWS, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != jwsSegments {
+		return JWS{}, fmt.Errorf("%w: expected %d segments, got %d", ErrMalformedJWS, jwsSegments, len(parts))
+	}
+	headerJSON, err := decodeSegment(parts[0])
+	if err != nil {
+		return JWS{}, fmt.Errorf("%w: header: %s", ErrMalformedJWS, err.Error())
+	}
+	var header Header
+	err = json.Unmarshal(headerJSON, &header)
+	if err != nil {
+		return JWS{}, fmt.Errorf("%w: header: %s", ErrMalformedJWS, err.Error())
+	}
+	payload, err := decodeSegment(parts[1])
+	if err != nil {
+		return JWS{}, fmt.Errorf("%w: payload: %s", ErrMalformedJWS, err.Error())
+	}
+	sig, err := decodeSegment(parts[2])
+	if err != nil {
+		return JWS{}, fmt.Errorf("%w: signature: %s", ErrMalformedJWS, err.Error())
+	}
+	return JWS{
+		Header:       header,
+		Payload:      payload,
+		signingInput: parts[0] + "." + parts[1],
+		signature:    sig,
+	}, nil
+}
+
+// Verify checks the signature of the JWS against `key`, using the algorithm
+// specified in the JWS header. The key must be of a type appropriate for that
+// algorithm.
+func (j JWS) Verify(key crypto.PublicKey) error {
+	alg, err := lookupAlgorithm(j.Header.Algorithm)
+	if err != nil {
+		return err
+	}
+	return alg.verify(key, []byte(j.signingInput), j.signature)
+}
+
+// UnmarshalClaims verifies the JWS against `key` and, if the signature is
+// valid, decodes its payload as JSON into `claims`.
+func (j JWS) UnmarshalClaims(key crypto.PublicKey, claims interface{}) error {
+	err := j.Verify(key)
+	if err != nil {
+		return err
+	}
+	err = json.Unmarshal(j.Payload, claims)
+	if err != nil {
+		return fmt.Errorf("%w: payload: %s", ErrMalformedJWS, err.Error())
+	}
+	return nil
+}
+
+// Sign produces a JWS in compact serialization, signing `payload` with `key`
+// using the algorithm specified in `header`.
+func Sign(header Header, payload []byte, key crypto.Signer) (string, error) {
+	alg, err := lookupAlgorithm(header.Algorithm)
+	if err != nil {
+		return "", err
+	}
+	headerJSON, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	input := encodeSegment(headerJSON) + "." + encodeSegment(payload)
+	sig, err := alg.sign(key, []byte(input))
+	if err != nil {
+		return "", err
+	}
+	return input + "." + encodeSegment(sig), nil
+}
+
+type algorithm struct {
+	hash crypto.Hash
+	kind string
+}
+
+const (
+	kindRSA   = "rsa"
+	kindPSS   = "pss"
+	kindECDSA = "ecdsa"
+	kindEdDSA = "eddsa"
+)
+
+var algorithms = map[string]algorithm{
+	"RS256": {hash: crypto.SHA256, kind: kindRSA},
+	"RS384": {hash: crypto.SHA384, kind: kindRSA},
+	"RS512": {hash: crypto.SHA512, kind: kindRSA},
+	"PS256": {hash: crypto.SHA256, kind: kindPSS},
+	"PS384": {hash: crypto.SHA384, kind: kindPSS},
+	"PS512": {hash: crypto.SHA512, kind: kindPSS},
+	"ES256": {hash: crypto.SHA256, kind: kindECDSA},
+	"ES384": {hash: crypto.SHA384, kind: kindECDSA},
+	"ES512": {hash: crypto.SHA512, kind: kindECDSA},
+	"EdDSA": {kind: kindEdDSA},
+}
+
+func lookupAlgorithm(name string) (algorithm, error) {
+	alg, ok := algorithms[name]
+	if !ok {
+		return algorithm{}, fmt.Errorf("%w: %q", ErrUnsupportedAlgorithm, name)
+	}
+	return alg, nil
+}
+
+func (a algorithm) digest(input []byte) []byte {
+	h := a.hash.New()
+	h.Write(input) //nolint:errcheck // hash writes never return errors
+	return h.Sum(nil)
+}
+
+func (a algorithm) verify(key crypto.PublicKey, input, sig []byte) error {
+	var valid bool
+	switch a.kind {
+	case kindRSA, kindPSS:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %T for RSA algorithm", ErrUnsupportedKey, key)
+		}
+		if a.kind == kindRSA {
+			valid = rsa.VerifyPKCS1v15(pub, a.hash, a.digest(input), sig) == nil
+		} else {
+			valid = rsa.VerifyPSS(pub, a.hash, a.digest(input), sig, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}) == nil
+		}
+	case kindECDSA:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %T for ECDSA algorithm", ErrUnsupportedKey, key)
+		}
+		size := (pub.Curve.Params().BitSize + 7) / 8 //nolint:gomnd // rounding bits up to bytes
+		if len(sig) != 2*size {
+			return ErrInvalidSignature
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		valid = ecdsa.Verify(pub, a.digest(input), r, s)
+	case kindEdDSA:
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %T for EdDSA algorithm", ErrUnsupportedKey, key)
+		}
+		valid = ed25519.Verify(pub, input, sig)
+	}
+	if !valid {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+func (a algorithm) sign(key crypto.Signer, input []byte) ([]byte, error) {
+	switch a.kind {
+	case kindRSA:
+		return key.Sign(rand.Reader, a.digest(input), a.hash)
+	case kindPSS:
+		return key.Sign(rand.Reader, a.digest(input), &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: a.hash})
+	case kindECDSA:
+		priv, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%w: %T for ECDSA algorithm", ErrUnsupportedKey, key)
+		}
+		r, s, err := ecdsa.Sign(rand.Reader, priv, a.digest(input))
+		if err != nil {
+			return nil, err
+		}
+		size := (priv.Curve.Params().BitSize + 7) / 8 //nolint:gomnd // rounding bits up to bytes
+		sig := make([]byte, 2*size)
+		r.FillBytes(sig[:size])
+		s.FillBytes(sig[size:])
+		return sig, nil
+	default:
+		return key.Sign(rand.Reader, input, crypto.Hash(0))
+	}
+}
@@ -0,0 +1,61 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+)
+
+var (
+	// ErrInvalidClient is returned by ClientAuthenticators when a client
+	// is unknown, or the credentials it presented are wrong.
+	ErrInvalidClient = errors.New("invalid client credentials")
+
+	// errMultipleClientAuth is returned when a request authenticates the
+	// client in more than one way, which RFC 6749 forbids.
+	errMultipleClientAuth = errors.New("client authenticated with more than one method")
+)
+
+// ClientAuthenticator checks the credentials clients present to the OAuth
+// 2.0 endpoints. `secret` is empty for public clients, which only present
+// their ID; implementations must reject that for confidential clients.
+// Failures should return an error wrapping ErrInvalidClient; any other error
+// is reported as a server error.
+type ClientAuthenticator interface {
+	AuthenticateClient(ctx context.Context, clientID, secret string) error
+}
+
+// ClientAuthenticatorFunc is a ClientAuthenticator implemented by a function.
+type ClientAuthenticatorFunc func(ctx context.Context, clientID, secret string) error
+
+// AuthenticateClient calls the function.
+func (f ClientAuthenticatorFunc) AuthenticateClient(ctx context.Context, clientID, secret string) error {
+	return f(ctx, clientID, secret)
+}
+
+// clientCredentials returns the client ID and secret presented with `r`,
+// from either HTTP Basic authentication or the client_id and client_secret
+// form parameters, as described by section 2.3.1 of RFC 6749. The second
+// return value is true if they came from HTTP Basic authentication. The form
+// must already be parsed.
+func clientCredentials(r *http.Request) (string, string, bool, error) {
+	user, pass, basic := r.BasicAuth()
+	if basic {
+		if r.PostForm.Get("client_id") != "" || r.PostForm.Get("client_secret") != "" {
+			return "", "", true, errMultipleClientAuth
+		}
+		// credentials are form-encoded before being put in the
+		// header, so they need decoding
+		clientID, err := url.QueryUnescape(user)
+		if err != nil {
+			return "", "", true, err
+		}
+		secret, err := url.QueryUnescape(pass)
+		if err != nil {
+			return "", "", true, err
+		}
+		return clientID, secret, true, nil
+	}
+	return r.PostForm.Get("client_id"), r.PostForm.Get("client_secret"), false, nil
+}
@@ -0,0 +1,74 @@
+package oauth
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+
+	"lockbox.dev/grants"
+)
+
+// The code_challenge_method values RFC 7636 defines.
+const (
+	CodeChallengeMethodPlain = "plain"
+	CodeChallengeMethodS256  = "S256"
+)
+
+// ErrCodeMismatch is returned by CodeVerifiers when an authorization_code
+// token request doesn't match the authorization request its code was issued
+// for.
+var ErrCodeMismatch = errors.New("token request doesn't match the authorization request")
+
+// CodeExchange holds the parameters of an authorization_code token request
+// that have to match the authorization request its code was issued for.
+type CodeExchange struct {
+	RedirectURI  string // the redirect_uri parameter, if one was sent
+	CodeVerifier string // the code_verifier parameter of RFC 7636, if one was sent
+}
+
+// CodeVerifier checks authorization_code token requests against the
+// authorization request their code was issued for, which only whatever issued
+// the code knows about. Section 4.1.3 of RFC 6749 requires the redirect_uri to
+// be identical to the one in the authorization request, if it included one,
+// and RFC 7636 requires the code_verifier to match the code_challenge, if it
+// included one; VerifyPKCE can check the latter.
+//
+// Mismatches should return an error wrapping ErrCodeMismatch; any other error
+// is reported as a server error.
+type CodeVerifier interface {
+	VerifyCode(ctx context.Context, grant grants.Grant, exchange CodeExchange) error
+}
+
+// CodeVerifierFunc is a CodeVerifier implemented by a function.
+type CodeVerifierFunc func(ctx context.Context, grant grants.Grant, exchange CodeExchange) error
+
+// VerifyCode calls the function.
+func (f CodeVerifierFunc) VerifyCode(ctx context.Context, grant grants.Grant, exchange CodeExchange) error {
+	return f(ctx, grant, exchange)
+}
+
+// VerifyPKCE checks `verifier` against the `challenge` and `method` sent in an
+// authorization request, as described by section 4.6 of RFC 7636, returning
+// ErrCodeMismatch if they don't match. An empty `method` is treated as
+// CodeChallengeMethodPlain, as RFC 7636 requires.
+func VerifyPKCE(challenge, method, verifier string) error {
+	if challenge == "" || verifier == "" {
+		return ErrCodeMismatch
+	}
+	var expected string
+	switch method {
+	case "", CodeChallengeMethodPlain:
+		expected = verifier
+	case CodeChallengeMethodS256:
+		sum := sha256.Sum256([]byte(verifier))
+		expected = base64.RawURLEncoding.EncodeToString(sum[:])
+	default:
+		return ErrCodeMismatch
+	}
+	if subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) != 1 {
+		return ErrCodeMismatch
+	}
+	return nil
+}
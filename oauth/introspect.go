@@ -0,0 +1,86 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+// IntrospectionResponse is the body of a token introspection endpoint
+// response. Inactive tokens only set Active.
+type IntrospectionResponse struct {
+	Active    bool   `json:"active"`
+	Scope     string `json:"scope,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Subject   string `json:"sub,omitempty"`
+	IssuedAt  int64  `json:"iat,omitempty"`
+	ExpiresAt int64  `json:"exp,omitempty"`
+}
+
+// IntrospectionHandler returns an http.Handler serving the token
+// introspection endpoint described by RFC 7662, which resource servers can
+// use to check whether a Grant can still be used. Callers authenticate like
+// clients do, but public clients are refused.
+//
+// A Grant is active if it's in grants.GrantStateActive and, when GrantTTL is
+// set, was created less than GrantTTL ago. Every other Grant, including ones
+// that don't exist, is reported as only `{"active": false}`.
+func (s Server) IntrospectionHandler() http.Handler {
+	return http.HandlerFunc(s.serveIntrospection)
+}
+
+func (s Server) serveIntrospection(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, ErrorInvalidRequest, "introspection requests must be POSTed")
+		return
+	}
+	clientID, ok := s.authenticateClient(w, r, true)
+	if !ok {
+		return
+	}
+	if len(r.PostForm["token"]) > 1 {
+		writeError(w, http.StatusBadRequest, ErrorInvalidRequest, "token must only be included once")
+		return
+	}
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeError(w, http.StatusBadRequest, ErrorInvalidRequest, "token is required")
+		return
+	}
+	ctx := r.Context()
+	grant, err := s.Storer.GetGrant(ctx, token)
+	if errors.Is(err, grants.ErrGrantNotFound) {
+		writeJSON(w, http.StatusOK, IntrospectionResponse{})
+		return
+	}
+	if err != nil {
+		yall.FromContext(ctx).WithField("grant", token).WithField("client_id", clientID).WithError(err).Error("error retrieving grant to introspect")
+		writeError(w, http.StatusInternalServerError, ErrorServerError, "")
+		return
+	}
+	if grant.State != grants.GrantStateActive {
+		writeJSON(w, http.StatusOK, IntrospectionResponse{})
+		return
+	}
+	resp := IntrospectionResponse{
+		Active:   true,
+		Scope:    strings.Join(grant.Scopes, " "),
+		ClientID: grant.ClientID,
+		Subject:  grant.ProfileID,
+		IssuedAt: grant.CreatedAt.Unix(),
+	}
+	if s.GrantTTL > 0 {
+		expiresAt := grant.CreatedAt.Add(s.GrantTTL)
+		if !s.now().Before(expiresAt) {
+			writeJSON(w, http.StatusOK, IntrospectionResponse{})
+			return
+		}
+		resp.ExpiresAt = expiresAt.Unix()
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
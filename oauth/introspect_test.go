@@ -0,0 +1,165 @@
+package oauth_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/oauth"
+)
+
+// introspectRequest POSTs `form` to the introspection endpoint,
+// authenticating as "web" with HTTP Basic authentication unless `form` has a
+// client_id, and returns the status and raw body.
+func introspectRequest(t *testing.T, server oauth.Server, form url.Values) (int, string) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/introspect", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if form.Get("client_id") == "" {
+		req.SetBasicAuth("web", "hunter2")
+	}
+	w := httptest.NewRecorder()
+	server.IntrospectionHandler().ServeHTTP(w, req)
+	return w.Code, strings.TrimSpace(w.Body.String())
+}
+
+func TestIntrospectActive(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newServer(t)
+	server.GrantTTL = time.Hour
+	grant := createCodeOrFail(ctx, t, server, "cli")
+
+	status, body := introspectRequest(t, server, url.Values{"token": {grant.ID}})
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %s", http.StatusOK, status, body)
+	}
+	var resp oauth.IntrospectionResponse
+	err := json.Unmarshal([]byte(body), &resp)
+	if err != nil {
+		t.Fatalf("Error decoding response %q: %s", body, err)
+	}
+	expected := oauth.IntrospectionResponse{
+		Active:    true,
+		Scope:     "read write",
+		ClientID:  "cli",
+		Subject:   "user",
+		IssuedAt:  grant.CreatedAt.Unix(),
+		ExpiresAt: grant.CreatedAt.Add(time.Hour).Unix(),
+	}
+	if diff := cmp.Diff(expected, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func TestIntrospectInactive(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newServer(t)
+	used := createCodeOrFail(ctx, t, server, "web")
+	refreshToken := refreshOrFail(t, server, used.ID)
+	revoked, err := server.Storer.RevokeGrant(ctx, refreshToken)
+	if err != nil {
+		t.Fatalf("Unexpected error revoking grant: %s", err)
+	}
+	pending, err := grants.FillGrantDefaults(grants.Grant{SourceType: "manual", SourceID: "pending", ClientID: "web", State: grants.GrantStatePending})
+	if err != nil {
+		t.Fatalf("Unexpected error filling grant defaults: %s", err)
+	}
+	err = server.Storer.CreateGrant(ctx, pending)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant: %s", err)
+	}
+	expired := createCodeOrFail(ctx, t, server, "cli")
+	_, err = server.Storer.TransitionGrant(ctx, expired.ID, grants.GrantStateExpired)
+	if err != nil {
+		t.Fatalf("Unexpected error expiring grant: %s", err)
+	}
+
+	tests := map[string]string{
+		"used":    used.ID,
+		"revoked": revoked.ID,
+		"pending": pending.ID,
+		"expired": expired.ID,
+		"unknown": missingID,
+	}
+	for name, token := range tests {
+		name, token := name, token
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			status, body := introspectRequest(t, server, url.Values{"token": {token}})
+			if status != http.StatusOK {
+				t.Errorf("Expected status %d, got %d", http.StatusOK, status)
+			}
+			if body != `{"active":false}` {
+				t.Errorf("Expected only active:false, got %s", body)
+			}
+		})
+	}
+}
+
+func TestIntrospectTTL(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newServer(t)
+	server.GrantTTL = time.Hour
+	grant := createCodeOrFail(ctx, t, server, "web")
+	server.Now = func() time.Time { return grant.CreatedAt.Add(time.Hour) }
+
+	status, body := introspectRequest(t, server, url.Values{"token": {grant.ID}})
+	if status != http.StatusOK {
+		t.Errorf("Expected status %d, got %d", http.StatusOK, status)
+	}
+	if body != `{"active":false}` {
+		t.Errorf("Expected only active:false, got %s", body)
+	}
+}
+
+func TestIntrospectAuthentication(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newServer(t)
+	grant := createCodeOrFail(ctx, t, server, "cli")
+
+	tests := map[string]struct {
+		form   url.Values
+		status int
+		code   string
+	}{
+		"public-client": {form: url.Values{"token": {grant.ID}, "client_id": {"cli"}}, status: http.StatusUnauthorized, code: oauth.ErrorInvalidClient},
+		"wrong-secret":  {form: url.Values{"token": {grant.ID}, "client_id": {"web"}, "client_secret": {"wrong"}}, status: http.StatusUnauthorized, code: oauth.ErrorInvalidClient},
+		"no-token":      {form: url.Values{}, status: http.StatusBadRequest, code: oauth.ErrorInvalidRequest},
+	}
+	for name, test := range tests {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			status, body := introspectRequest(t, server, test.form)
+			if status != test.status {
+				t.Errorf("Expected status %d, got %d", test.status, status)
+			}
+			var resp oauth.ErrorResponse
+			err := json.Unmarshal([]byte(body), &resp)
+			if err != nil {
+				t.Fatalf("Error decoding response %q: %s", body, err)
+			}
+			if resp.Error != test.code {
+				t.Errorf("Expected error %q, got %q", test.code, resp.Error)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+// Package oauth implements the OAuth 2.0 endpoints, RFC 6749, that turn
+// Grants into tokens.
+//
+// Authorization codes are Grant IDs: whatever issues them creates a Grant for
+// the client and hands its ID to the client as the code. The token endpoint
+// exchanges the Grant and passes it to a TokenIssuer, which mints the access
+// token. When refresh tokens are enabled, the token endpoint also creates a
+// child of the exchanged Grant with grants.Storer.RotateGrant, and its ID is
+// the refresh token; refreshing rotates that Grant in turn. Refresh token
+// Grants have a SourceType of RefreshTokenSourceType, so if the Storer is
+// wrapped with grants.WithSourceRegistry, that type must be registered.
+//
+// Grants don't record the redirect_uri or PKCE code_challenge of the
+// authorization request a code was issued for, so whatever issues codes needs
+// to keep them, and check token requests against them with a CodeVerifier.
+//
+// Refresh tokens and authorization codes can be revoked through the
+// revocation endpoint, RFC 7009, which can revoke a Grant's whole family so a
+// leaked refresh token can't outlive the revocation of an earlier one.
+// Resource servers can check whether a Grant is still active through the
+// introspection endpoint, RFC 7662.
+package oauth
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"lockbox.dev/grants"
+)
+
+// RefreshTokenSourceType is the SourceType of the Grants refresh tokens
+// identify.
+const RefreshTokenSourceType = "refresh_token"
+
+// The error codes RFC 6749 and the specifications that extend it define for
+// token endpoint responses.
+const (
+	ErrorInvalidRequest       = "invalid_request"
+	ErrorInvalidClient        = "invalid_client"
+	ErrorInvalidGrant         = "invalid_grant"
+	ErrorUnauthorizedClient   = "unauthorized_client"
+	ErrorUnsupportedGrantType = "unsupported_grant_type"
+	ErrorInvalidScope         = "invalid_scope"
+	ErrorInvalidDPoPProof     = "invalid_dpop_proof"
+	ErrorServerError          = "server_error"
+)
+
+// ErrorResponse is the body of an error response from an OAuth 2.0
+// endpoint.
+type ErrorResponse struct {
+	Error       string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// GrantError returns the RFC 6749 error code that describes `err`, an error
+// returned while using a Grant, or ErrorServerError if it doesn't describe a
+// problem with the Grant.
+func GrantError(err error) string {
+	switch {
+	case errors.Is(err, grants.ErrGrantNotFound),
+		errors.Is(err, grants.ErrGrantAlreadyUsed),
+		errors.Is(err, grants.ErrGrantRevoked),
+		errors.Is(err, grants.ErrGrantPending),
+		errors.Is(err, grants.ErrGrantExpired),
+		errors.Is(err, grants.ErrGrantDenied),
+		errors.Is(err, grants.ErrInvalidGrantTransition),
+		errors.Is(err, ErrCodeMismatch):
+		return ErrorInvalidGrant
+	case errors.Is(err, grants.ErrInvalidProof):
+		return ErrorInvalidDPoPProof
+	}
+	return ErrorServerError
+}
+
+func writeError(w http.ResponseWriter, status int, code, description string) {
+	writeJSON(w, status, ErrorResponse{Error: code, Description: description})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Pragma", "no-cache")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body) //nolint:errcheck,errchkjson // nothing to be done if the client went away
+}
@@ -0,0 +1,72 @@
+package oauth
+
+import (
+	"errors"
+	"net/http"
+
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+// RevocationHandler returns an http.Handler serving the token revocation
+// endpoint described by RFC 7009. The token being revoked is a refresh token
+// or an authorization code; access tokens are minted by the TokenIssuer, and
+// can't be revoked here.
+//
+// As the RFC requires, tokens that don't exist or are already unusable are
+// reported as successfully revoked, but tokens issued to another client are
+// refused with an unauthorized_client error and left as they are.
+func (s Server) RevocationHandler() http.Handler {
+	return http.HandlerFunc(s.serveRevocation)
+}
+
+func (s Server) serveRevocation(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, ErrorInvalidRequest, "revocation requests must be POSTed")
+		return
+	}
+	clientID, ok := s.authenticateClient(w, r, false)
+	if !ok {
+		return
+	}
+	if len(r.PostForm["token"]) > 1 {
+		writeError(w, http.StatusBadRequest, ErrorInvalidRequest, "token must only be included once")
+		return
+	}
+	// token_type_hint is ignored; every token we can revoke is a Grant
+	token := r.PostForm.Get("token")
+	if token == "" {
+		writeError(w, http.StatusBadRequest, ErrorInvalidRequest, "token is required")
+		return
+	}
+	ctx := r.Context()
+	log := yall.FromContext(ctx).WithField("grant", token).WithField("client_id", clientID)
+	grant, err := s.Storer.GetGrant(ctx, token)
+	if errors.Is(err, grants.ErrGrantNotFound) {
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	if err != nil {
+		log.WithError(err).Error("error retrieving grant to revoke")
+		writeError(w, http.StatusInternalServerError, ErrorServerError, "")
+		return
+	}
+	if grant.ClientID != clientID {
+		writeError(w, http.StatusBadRequest, ErrorUnauthorizedClient, "token was issued to another client")
+		return
+	}
+	if s.Families != nil {
+		_, err = s.Families.RevokeGrantFamily(ctx, grant.ID)
+	} else {
+		_, err = s.Storer.RevokeGrant(ctx, grant.ID)
+	}
+	// a token that can't be used any more is as good as revoked
+	if err != nil && GrantError(err) != ErrorInvalidGrant {
+		log.WithError(err).Error("error revoking grant")
+		writeError(w, http.StatusInternalServerError, ErrorServerError, "")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
@@ -0,0 +1,163 @@
+package oauth_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/oauth"
+)
+
+// revokeRequest POSTs `form` to the revocation endpoint, authenticating as
+// "web" with HTTP Basic authentication unless `form` has a client_id.
+func revokeRequest(t *testing.T, server oauth.Server, form url.Values) (int, oauth.ErrorResponse) {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/revoke", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if form.Get("client_id") == "" {
+		req.SetBasicAuth("web", "hunter2")
+	}
+	w := httptest.NewRecorder()
+	server.RevocationHandler().ServeHTTP(w, req)
+	var resp oauth.ErrorResponse
+	if w.Code != http.StatusOK {
+		err := json.Unmarshal(w.Body.Bytes(), &resp)
+		if err != nil {
+			t.Fatalf("Error decoding response %q: %s", w.Body.String(), err)
+		}
+	}
+	return w.Code, resp
+}
+
+// refreshOrFail exchanges `code` for a refresh token as the "web" client.
+func refreshOrFail(t *testing.T, server oauth.Server, code string) string {
+	t.Helper()
+	res := tokenRequest(t, server, url.Values{"grant_type": {"authorization_code"}, "code": {code}})
+	if res.status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %+v", http.StatusOK, res.status, res.err)
+	}
+	return res.token.RefreshToken
+}
+
+func expectState(ctx context.Context, t *testing.T, server oauth.Server, id string, state grants.GrantState) {
+	t.Helper()
+	grant, err := server.Storer.GetGrant(ctx, id)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant: %s", err)
+	}
+	if grant.State != state {
+		t.Errorf("Expected grant %s to be %q, got %q", id, state, grant.State)
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newServer(t)
+	refreshToken := refreshOrFail(t, server, createCodeOrFail(ctx, t, server, "web").ID)
+
+	status, resp := revokeRequest(t, server, url.Values{"token": {refreshToken}, "token_type_hint": {"refresh_token"}})
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %+v", http.StatusOK, status, resp)
+	}
+	expectState(ctx, t, server, refreshToken, grants.GrantStateRevoked)
+
+	res := tokenRequest(t, server, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {refreshToken}})
+	expectError(t, res, http.StatusBadRequest, oauth.ErrorInvalidGrant)
+
+	// revoking again is still a success
+	status, resp = revokeRequest(t, server, url.Values{"token": {refreshToken}})
+	if status != http.StatusOK {
+		t.Errorf("Expected status %d, got %d: %+v", http.StatusOK, status, resp)
+	}
+}
+
+func TestRevokeFamily(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newServer(t)
+	families, ok := server.Storer.(grants.FamilyRevoker)
+	if !ok {
+		t.Fatalf("%T doesn't implement grants.FamilyRevoker", server.Storer)
+	}
+	server.Families = families
+
+	first := refreshOrFail(t, server, createCodeOrFail(ctx, t, server, "web").ID)
+	res := tokenRequest(t, server, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {first}})
+	if res.status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %+v", http.StatusOK, res.status, res.err)
+	}
+	second := res.token.RefreshToken
+
+	// revoking a refresh token that was already rotated revokes the one
+	// it was rotated into
+	status, resp := revokeRequest(t, server, url.Values{"token": {first}})
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %+v", http.StatusOK, status, resp)
+	}
+	expectState(ctx, t, server, first, grants.GrantStateUsed)
+	expectState(ctx, t, server, second, grants.GrantStateRevoked)
+}
+
+func TestRevokeWithoutFamily(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newServer(t)
+
+	first := refreshOrFail(t, server, createCodeOrFail(ctx, t, server, "web").ID)
+	res := tokenRequest(t, server, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {first}})
+	if res.status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %+v", http.StatusOK, res.status, res.err)
+	}
+	second := res.token.RefreshToken
+
+	status, resp := revokeRequest(t, server, url.Values{"token": {first}})
+	if status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %+v", http.StatusOK, status, resp)
+	}
+	expectState(ctx, t, server, second, grants.GrantStateActive)
+}
+
+func TestRevokeErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newServer(t)
+	otherClient := createCodeOrFail(ctx, t, server, "cli")
+
+	status, resp := revokeRequest(t, server, url.Values{"token": {missingID}})
+	if status != http.StatusOK {
+		t.Errorf("Expected status %d revoking an unknown token, got %d: %+v", http.StatusOK, status, resp)
+	}
+
+	status, resp = revokeRequest(t, server, url.Values{"token": {otherClient.ID}})
+	if status != http.StatusBadRequest || resp.Error != oauth.ErrorUnauthorizedClient {
+		t.Errorf("Expected %d %q revoking another client's token, got %d %q", http.StatusBadRequest, oauth.ErrorUnauthorizedClient, status, resp.Error)
+	}
+	expectState(ctx, t, server, otherClient.ID, grants.GrantStateActive)
+
+	status, resp = revokeRequest(t, server, url.Values{})
+	if status != http.StatusBadRequest || resp.Error != oauth.ErrorInvalidRequest {
+		t.Errorf("Expected %d %q without a token, got %d %q", http.StatusBadRequest, oauth.ErrorInvalidRequest, status, resp.Error)
+	}
+
+	status, resp = revokeRequest(t, server, url.Values{"token": {otherClient.ID}, "client_id": {"web"}, "client_secret": {"wrong"}})
+	if status != http.StatusUnauthorized || resp.Error != oauth.ErrorInvalidClient {
+		t.Errorf("Expected %d %q with the wrong secret, got %d %q", http.StatusUnauthorized, oauth.ErrorInvalidClient, status, resp.Error)
+	}
+
+	// the public client can revoke its own token
+	status, resp = revokeRequest(t, server, url.Values{"token": {otherClient.ID}, "client_id": {"cli"}})
+	if status != http.StatusOK {
+		t.Errorf("Expected status %d, got %d: %+v", http.StatusOK, status, resp)
+	}
+	expectState(ctx, t, server, otherClient.ID, grants.GrantStateRevoked)
+}
@@ -0,0 +1,364 @@
+package oauth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+	grantshttp "lockbox.dev/grants/http"
+)
+
+// The grant types the token endpoint supports.
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+)
+
+// Token is an access token minted by a TokenIssuer.
+type Token struct {
+	AccessToken string        // the access token itself
+	TokenType   string        // the type of the token; defaults to "Bearer"
+	ExpiresIn   time.Duration // how long the token is valid for, if known
+}
+
+// TokenIssuer mints access tokens for Grants that have been exchanged.
+type TokenIssuer interface {
+	IssueToken(ctx context.Context, grant grants.Grant) (Token, error)
+}
+
+// TokenIssuerFunc is a TokenIssuer implemented by a function.
+type TokenIssuerFunc func(ctx context.Context, grant grants.Grant) (Token, error)
+
+// IssueToken calls the function.
+func (f TokenIssuerFunc) IssueToken(ctx context.Context, grant grants.Grant) (Token, error) {
+	return f(ctx, grant)
+}
+
+// TokenResponse is the body of a successful token endpoint response.
+type TokenResponse struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in,omitempty"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// Server serves the OAuth 2.0 endpoints.
+type Server struct {
+	grants.Dependencies
+
+	// Clients authenticates the clients calling the endpoints. It's
+	// required.
+	Clients ClientAuthenticator
+
+	// Issuer mints access tokens. It's required.
+	Issuer TokenIssuer
+
+	// Codes checks that authorization_code token requests match the
+	// authorization request their code was issued for. If nil, the
+	// redirect_uri and code_verifier parameters aren't checked at all,
+	// which RFC 6749 only allows when authorization requests never
+	// include a redirect_uri, and which leaves public clients open to
+	// having their codes intercepted.
+	Codes CodeVerifier
+
+	// RefreshTokens controls whether refresh tokens are issued and the
+	// refresh_token grant type is supported.
+	RefreshTokens bool
+
+	// Families, if set, is used to revoke the whole family of a Grant
+	// when it's revoked through the revocation endpoint, so revoking a
+	// refresh token also revokes every refresh token rotated from the
+	// same authorization code. It's usually the Storer underlying
+	// Dependencies.Storer.
+	Families grants.FamilyRevoker
+
+	// GrantTTL, if set, is how long after their creation Grants are
+	// reported as active by the introspection endpoint, which also
+	// reports it as their expiry.
+	GrantTTL time.Duration
+
+	// TrustedProxies is the number of proxies in front of the Server
+	// that append to the X-Forwarded-For header. If it's more than 0,
+	// the client IP recorded on Grants is read from that header, as
+	// described by grantshttp.ClientIP. It must match the deployment
+	// exactly: too many lets clients pick the IP that's recorded.
+	TrustedProxies int
+
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+}
+
+// tokenRequest is a request to the token endpoint, after the client has been
+// authenticated.
+type tokenRequest struct {
+	clientID string
+	ip       string
+	proof    string
+	form     url.Values
+}
+
+// TokenHandler returns an http.Handler serving the token endpoint described
+// by section 3.2 of RFC 6749. The redirect_uri and code_verifier parameters
+// of authorization_code requests are checked by Codes, as only whatever
+// issued the code knows what they should be; without it, they're ignored.
+func (s Server) TokenHandler() http.Handler {
+	return http.HandlerFunc(s.serveToken)
+}
+
+func (s Server) serveToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeError(w, http.StatusMethodNotAllowed, ErrorInvalidRequest, "token requests must be POSTed")
+		return
+	}
+	clientID, ok := s.authenticateClient(w, r, false)
+	if !ok {
+		return
+	}
+	req := tokenRequest{
+		clientID: clientID,
+		ip:       grantshttp.ClientIP(r, s.TrustedProxies),
+		proof:    r.Header.Get(grantshttp.ProofHeader),
+		form:     r.PostForm,
+	}
+	for _, param := range []string{"grant_type", "code", "redirect_uri", "code_verifier", "refresh_token", "scope"} {
+		if len(r.PostForm[param]) > 1 {
+			writeError(w, http.StatusBadRequest, ErrorInvalidRequest, param+" must only be included once")
+			return
+		}
+	}
+	switch req.form.Get("grant_type") {
+	case GrantTypeAuthorizationCode:
+		s.authorizationCode(r.Context(), w, req)
+	case GrantTypeRefreshToken:
+		if !s.RefreshTokens {
+			writeError(w, http.StatusBadRequest, ErrorUnsupportedGrantType, "")
+			return
+		}
+		s.refreshToken(r.Context(), w, req)
+	case "":
+		writeError(w, http.StatusBadRequest, ErrorInvalidRequest, "grant_type is required")
+	default:
+		writeError(w, http.StatusBadRequest, ErrorUnsupportedGrantType, "")
+	}
+}
+
+// authenticateClient parses the request's form and authenticates the client
+// that made it, returning its ID. If `requireSecret` is true, public clients,
+// which don't present a secret, are refused. If the client can't be
+// authenticated, the error response is written and false is returned.
+func (s Server) authenticateClient(w http.ResponseWriter, r *http.Request, requireSecret bool) (string, bool) {
+	err := r.ParseForm()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrorInvalidRequest, "request body must be form encoded")
+		return "", false
+	}
+	clientID, secret, basic, err := clientCredentials(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, ErrorInvalidRequest, "invalid client credentials format")
+		return "", false
+	}
+	if clientID == "" || (requireSecret && secret == "") {
+		writeClientError(w, basic)
+		return "", false
+	}
+	err = s.Clients.AuthenticateClient(r.Context(), clientID, secret)
+	if errors.Is(err, ErrInvalidClient) {
+		writeClientError(w, basic)
+		return "", false
+	}
+	if err != nil {
+		yall.FromContext(r.Context()).WithField("client_id", clientID).WithError(err).Error("error authenticating client")
+		writeError(w, http.StatusInternalServerError, ErrorServerError, "")
+		return "", false
+	}
+	return clientID, true
+}
+
+// writeClientError writes the response for a client that couldn't be
+// authenticated, as described by section 5.2 of RFC 6749.
+func writeClientError(w http.ResponseWriter, basic bool) {
+	if basic {
+		w.Header().Set("WWW-Authenticate", `Basic realm="token"`)
+	}
+	writeError(w, http.StatusUnauthorized, ErrorInvalidClient, "")
+}
+
+func (s Server) authorizationCode(ctx context.Context, w http.ResponseWriter, req tokenRequest) {
+	code := req.form.Get("code")
+	if code == "" {
+		writeError(w, http.StatusBadRequest, ErrorInvalidRequest, "code is required")
+		return
+	}
+	grant, ok := s.clientGrant(ctx, w, req.clientID, code)
+	if !ok {
+		return
+	}
+	if grant.SourceType == RefreshTokenSourceType {
+		writeError(w, http.StatusBadRequest, ErrorInvalidGrant, "")
+		return
+	}
+	if s.Codes != nil {
+		err := s.Codes.VerifyCode(ctx, grant, CodeExchange{
+			RedirectURI:  req.form.Get("redirect_uri"),
+			CodeVerifier: req.form.Get("code_verifier"),
+		})
+		if err != nil {
+			s.writeGrantError(ctx, w, err)
+			return
+		}
+	}
+	use := grants.GrantUse{
+		Grant: grant.ID,
+		IP:    req.ip,
+		Time:  s.now(),
+		Proof: req.proof,
+	}
+	if !s.RefreshTokens {
+		used, err := s.Storer.ExchangeGrant(ctx, use)
+		if err != nil {
+			s.writeGrantError(ctx, w, err)
+			return
+		}
+		s.issue(ctx, w, used, "")
+		return
+	}
+	s.rotate(ctx, w, use, grant, grant.Scopes)
+}
+
+func (s Server) refreshToken(ctx context.Context, w http.ResponseWriter, req tokenRequest) {
+	refreshToken := req.form.Get("refresh_token")
+	if refreshToken == "" {
+		writeError(w, http.StatusBadRequest, ErrorInvalidRequest, "refresh_token is required")
+		return
+	}
+	grant, ok := s.clientGrant(ctx, w, req.clientID, refreshToken)
+	if !ok {
+		return
+	}
+	if grant.SourceType != RefreshTokenSourceType {
+		writeError(w, http.StatusBadRequest, ErrorInvalidGrant, "")
+		return
+	}
+	scopes := grant.Scopes
+	if req.form.Get("scope") != "" {
+		scopes = strings.Fields(req.form.Get("scope"))
+		if !subset(scopes, grant.Scopes) {
+			writeError(w, http.StatusBadRequest, ErrorInvalidScope, "refreshed scopes can't exceed the original scopes")
+			return
+		}
+	}
+	s.rotate(ctx, w, grants.GrantUse{
+		Grant: grant.ID,
+		IP:    req.ip,
+		Time:  s.now(),
+		Proof: req.proof,
+	}, grant, scopes)
+}
+
+// clientGrant retrieves the Grant with the ID `id`, writing an invalid_grant
+// response and returning false if it doesn't exist or wasn't issued to the
+// client with the ID `clientID`.
+func (s Server) clientGrant(ctx context.Context, w http.ResponseWriter, clientID, id string) (grants.Grant, bool) {
+	grant, err := s.Storer.GetGrant(ctx, id)
+	if err != nil {
+		s.writeGrantError(ctx, w, err)
+		return grants.Grant{}, false
+	}
+	if grant.ClientID != clientID {
+		writeError(w, http.StatusBadRequest, ErrorInvalidGrant, "")
+		return grants.Grant{}, false
+	}
+	return grant, true
+}
+
+// rotate exchanges the Grant `use` identifies, creating a refresh token Grant
+// for `scopes` as its child, and issues a token for the child.
+func (s Server) rotate(ctx context.Context, w http.ResponseWriter, use grants.GrantUse, parent grants.Grant, scopes []string) {
+	next, err := grants.FillGrantDefaults(grants.Grant{
+		SourceType:    RefreshTokenSourceType,
+		CreatedAt:     use.Time,
+		Scopes:        scopes,
+		AccountID:     parent.AccountID,
+		ProfileID:     parent.ProfileID,
+		ClientID:      parent.ClientID,
+		CreateIP:      use.IP,
+		KeyThumbprint: parent.KeyThumbprint,
+	})
+	if err != nil {
+		s.writeGrantError(ctx, w, err)
+		return
+	}
+	next.SourceID = next.ID
+	child, err := s.Storer.RotateGrant(ctx, use, next)
+	if err != nil {
+		s.writeGrantError(ctx, w, err)
+		return
+	}
+	s.issue(ctx, w, child, child.ID)
+}
+
+// issue mints a token for `grant` and writes it, along with `refreshToken`
+// if it's set.
+func (s Server) issue(ctx context.Context, w http.ResponseWriter, grant grants.Grant, refreshToken string) {
+	token, err := s.Issuer.IssueToken(ctx, grant)
+	if err != nil {
+		yall.FromContext(ctx).WithField("grant", grant.ID).WithError(err).Error("error issuing token")
+		writeError(w, http.StatusInternalServerError, ErrorServerError, "")
+		return
+	}
+	if token.TokenType == "" {
+		token.TokenType = "Bearer"
+	}
+	writeJSON(w, http.StatusOK, TokenResponse{
+		AccessToken:  token.AccessToken,
+		TokenType:    token.TokenType,
+		ExpiresIn:    int64(token.ExpiresIn / time.Second),
+		RefreshToken: refreshToken,
+		Scope:        strings.Join(grant.Scopes, " "),
+	})
+}
+
+// writeGrantError writes the response for `err`, an error returned while
+// using a Grant.
+func (s Server) writeGrantError(ctx context.Context, w http.ResponseWriter, err error) {
+	code := GrantError(err)
+	if code == ErrorServerError {
+		yall.FromContext(ctx).WithError(err).Error("error using grant")
+		writeError(w, http.StatusInternalServerError, code, "")
+		return
+	}
+	status := http.StatusBadRequest
+	if code == ErrorInvalidDPoPProof {
+		status = http.StatusUnauthorized
+	}
+	writeError(w, status, code, "")
+}
+
+func (s Server) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+// subset returns true if every scope in `scopes` is in `of`.
+func subset(scopes, of []string) bool {
+	allowed := make(map[string]struct{}, len(of))
+	for _, scope := range of {
+		allowed[scope] = struct{}{}
+	}
+	for _, scope := range scopes {
+		if _, ok := allowed[scope]; !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,396 @@
+package oauth_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/oauth"
+	"lockbox.dev/grants/storers/memory"
+)
+
+const missingID = "00000000-0000-0000-0000-000000000000"
+
+// testClients knows a confidential client "web" with the secret "hunter2",
+// and a public client "cli".
+func testClients(_ context.Context, clientID, secret string) error {
+	switch {
+	case clientID == "web" && secret == "hunter2":
+		return nil
+	case clientID == "cli" && secret == "":
+		return nil
+	}
+	return oauth.ErrInvalidClient
+}
+
+// testIssuer issues tokens that are just the ID of the Grant they're for.
+func testIssuer(_ context.Context, grant grants.Grant) (oauth.Token, error) {
+	return oauth.Token{AccessToken: "token-" + grant.ID, ExpiresIn: time.Hour}, nil
+}
+
+func newServer(t *testing.T) oauth.Server {
+	t.Helper()
+	storer, err := memory.NewStorer()
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+	return oauth.Server{
+		Dependencies:  grants.Dependencies{Storer: storer},
+		Clients:       oauth.ClientAuthenticatorFunc(testClients),
+		Issuer:        oauth.TokenIssuerFunc(testIssuer),
+		RefreshTokens: true,
+	}
+}
+
+func createCodeOrFail(ctx context.Context, t *testing.T, server oauth.Server, clientID string) grants.Grant {
+	t.Helper()
+	grant, err := grants.FillGrantDefaults(grants.Grant{
+		SourceType: "manual",
+		SourceID:   clientID + "-" + t.Name(),
+		Scopes:     []string{"read", "write"},
+		ProfileID:  "user",
+		ClientID:   clientID,
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error filling grant defaults: %s", err)
+	}
+	err = server.Storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant: %s", err)
+	}
+	return grant
+}
+
+type result struct {
+	status int
+	header http.Header
+	token  oauth.TokenResponse
+	err    oauth.ErrorResponse
+}
+
+// tokenRequest POSTs `form` to the token endpoint, authenticating as "web"
+// with HTTP Basic authentication unless `form` has a client_id.
+func tokenRequest(t *testing.T, server oauth.Server, form url.Values) result {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.RemoteAddr = "1.2.3.4:5678"
+	if form.Get("client_id") == "" {
+		req.SetBasicAuth("web", "hunter2")
+	}
+	w := httptest.NewRecorder()
+	server.TokenHandler().ServeHTTP(w, req)
+	if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Expected Cache-Control %q, got %q", "no-store", cc)
+	}
+	res := result{status: w.Code, header: w.Header()}
+	var err error
+	if w.Code == http.StatusOK {
+		err = json.Unmarshal(w.Body.Bytes(), &res.token)
+	} else {
+		err = json.Unmarshal(w.Body.Bytes(), &res.err)
+	}
+	if err != nil {
+		t.Fatalf("Error decoding response %q: %s", w.Body.String(), err)
+	}
+	return res
+}
+
+func expectError(t *testing.T, res result, status int, code string) {
+	t.Helper()
+	if res.status != status {
+		t.Errorf("Expected status %d, got %d", status, res.status)
+	}
+	if res.err.Error != code {
+		t.Errorf("Expected error %q, got %q", code, res.err.Error)
+	}
+}
+
+func TestAuthorizationCode(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newServer(t)
+	server.RefreshTokens = false
+	code := createCodeOrFail(ctx, t, server, "web")
+
+	res := tokenRequest(t, server, url.Values{"grant_type": {"authorization_code"}, "code": {code.ID}})
+	if res.status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %+v", http.StatusOK, res.status, res.err)
+	}
+	expected := oauth.TokenResponse{
+		AccessToken: "token-" + code.ID,
+		TokenType:   "Bearer",
+		ExpiresIn:   3600,
+		Scope:       "read write",
+	}
+	if diff := cmp.Diff(expected, res.token); diff != "" {
+		t.Errorf("Unexpected diff in token (-wanted, +got): %s", diff)
+	}
+
+	used, err := server.Storer.GetGrant(ctx, code.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant: %s", err)
+	}
+	if !used.Used() || used.UseIP != "1.2.3.4" {
+		t.Errorf("Expected grant to be used from 1.2.3.4, got %+v", used)
+	}
+
+	res = tokenRequest(t, server, url.Values{"grant_type": {"authorization_code"}, "code": {code.ID}})
+	expectError(t, res, http.StatusBadRequest, oauth.ErrorInvalidGrant)
+
+	res = tokenRequest(t, server, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {code.ID}})
+	expectError(t, res, http.StatusBadRequest, oauth.ErrorUnsupportedGrantType)
+}
+
+func TestAuthorizationCodeErrors(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newServer(t)
+	otherClient := createCodeOrFail(ctx, t, server, "cli")
+	revoked := createCodeOrFail(ctx, t, server, "web")
+	_, err := server.Storer.RevokeGrant(ctx, revoked.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error revoking grant: %s", err)
+	}
+
+	tests := map[string]struct {
+		form   url.Values
+		status int
+		code   string
+	}{
+		"not-found":    {form: url.Values{"grant_type": {"authorization_code"}, "code": {missingID}}, status: http.StatusBadRequest, code: oauth.ErrorInvalidGrant},
+		"revoked":      {form: url.Values{"grant_type": {"authorization_code"}, "code": {revoked.ID}}, status: http.StatusBadRequest, code: oauth.ErrorInvalidGrant},
+		"other-client": {form: url.Values{"grant_type": {"authorization_code"}, "code": {otherClient.ID}}, status: http.StatusBadRequest, code: oauth.ErrorInvalidGrant},
+		"no-code":      {form: url.Values{"grant_type": {"authorization_code"}}, status: http.StatusBadRequest, code: oauth.ErrorInvalidRequest},
+		"no-type":      {form: url.Values{"code": {revoked.ID}}, status: http.StatusBadRequest, code: oauth.ErrorInvalidRequest},
+		"unknown-type": {form: url.Values{"grant_type": {"password"}}, status: http.StatusBadRequest, code: oauth.ErrorUnsupportedGrantType},
+		"repeated": {
+			form:   url.Values{"grant_type": {"authorization_code"}, "code": {revoked.ID, otherClient.ID}},
+			status: http.StatusBadRequest, code: oauth.ErrorInvalidRequest,
+		},
+		"bad-secret": {
+			form:   url.Values{"grant_type": {"authorization_code"}, "client_id": {"web"}, "client_secret": {"wrong"}},
+			status: http.StatusUnauthorized, code: oauth.ErrorInvalidClient,
+		},
+		"secret-required": {
+			form:   url.Values{"grant_type": {"authorization_code"}, "client_id": {"web"}},
+			status: http.StatusUnauthorized, code: oauth.ErrorInvalidClient,
+		},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			res := tokenRequest(t, server, test.form)
+			expectError(t, res, test.status, test.code)
+		})
+	}
+
+	// the failed attempt by another client didn't use up the code
+	stored, err := server.Storer.GetGrant(ctx, otherClient.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant: %s", err)
+	}
+	if stored.State != grants.GrantStateActive {
+		t.Errorf("Expected grant to be %q, got %q", grants.GrantStateActive, stored.State)
+	}
+}
+
+func TestBasicAuthFailure(t *testing.T) {
+	t.Parallel()
+
+	server := newServer(t)
+	req := httptest.NewRequest(http.MethodPost, "/token", strings.NewReader("grant_type=authorization_code&code="+missingID))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth("web", "wrong")
+	w := httptest.NewRecorder()
+	server.TokenHandler().ServeHTTP(w, req)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("Expected status %d, got %d", http.StatusUnauthorized, w.Code)
+	}
+	if w.Header().Get("WWW-Authenticate") == "" {
+		t.Error("Expected a WWW-Authenticate header")
+	}
+}
+
+func TestRefreshToken(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newServer(t)
+	code := createCodeOrFail(ctx, t, server, "cli")
+
+	res := tokenRequest(t, server, url.Values{"grant_type": {"authorization_code"}, "code": {code.ID}, "client_id": {"cli"}})
+	if res.status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %+v", http.StatusOK, res.status, res.err)
+	}
+	first := res.token.RefreshToken
+	if first == "" {
+		t.Fatal("Expected a refresh token")
+	}
+	if res.token.AccessToken != "token-"+first {
+		t.Errorf("Expected token to be issued for the refresh token grant, got %q", res.token.AccessToken)
+	}
+
+	refreshGrant, err := server.Storer.GetGrant(ctx, first)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving refresh token grant: %s", err)
+	}
+	if diff := cmp.Diff([]string{code.ID}, refreshGrant.AncestorIDs); diff != "" {
+		t.Errorf("Unexpected diff in ancestors (-wanted, +got): %s", diff)
+	}
+	if refreshGrant.SourceType != oauth.RefreshTokenSourceType || refreshGrant.ProfileID != "user" || refreshGrant.ClientID != "cli" {
+		t.Errorf("Unexpected refresh token grant %+v", refreshGrant)
+	}
+
+	res = tokenRequest(t, server, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {first}, "client_id": {"cli"}, "scope": {"read"}})
+	if res.status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %+v", http.StatusOK, res.status, res.err)
+	}
+	second := res.token.RefreshToken
+	if second == "" || second == first {
+		t.Fatalf("Expected a new refresh token, got %q", second)
+	}
+	if res.token.Scope != "read" {
+		t.Errorf("Expected scope %q, got %q", "read", res.token.Scope)
+	}
+
+	// refresh tokens can only be used once
+	res = tokenRequest(t, server, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {first}, "client_id": {"cli"}})
+	expectError(t, res, http.StatusBadRequest, oauth.ErrorInvalidGrant)
+
+	// scopes can't grow back once narrowed
+	res = tokenRequest(t, server, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {second}, "client_id": {"cli"}, "scope": {"read write"}})
+	expectError(t, res, http.StatusBadRequest, oauth.ErrorInvalidScope)
+
+	// refresh tokens can't be used by other clients
+	res = tokenRequest(t, server, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {second}})
+	expectError(t, res, http.StatusBadRequest, oauth.ErrorInvalidGrant)
+
+	// refresh tokens aren't authorization codes
+	res = tokenRequest(t, server, url.Values{"grant_type": {"authorization_code"}, "code": {second}, "client_id": {"cli"}})
+	expectError(t, res, http.StatusBadRequest, oauth.ErrorInvalidGrant)
+
+	// and authorization codes aren't refresh tokens
+	other := createCodeOrFail(ctx, t, server, "web")
+	res = tokenRequest(t, server, url.Values{"grant_type": {"refresh_token"}, "refresh_token": {other.ID}})
+	expectError(t, res, http.StatusBadRequest, oauth.ErrorInvalidGrant)
+}
+
+func TestGrantError(t *testing.T) {
+	t.Parallel()
+
+	tests := map[error]string{
+		grants.ErrGrantAlreadyUsed:   oauth.ErrorInvalidGrant,
+		grants.ErrGrantRevoked:       oauth.ErrorInvalidGrant,
+		grants.ErrGrantNotFound:      oauth.ErrorInvalidGrant,
+		grants.ErrGrantPending:       oauth.ErrorInvalidGrant,
+		grants.ErrInvalidProof:       oauth.ErrorInvalidDPoPProof,
+		grants.ErrGrantAlreadyExists: oauth.ErrorServerError,
+	}
+	for err, code := range tests {
+		if got := oauth.GrantError(err); got != code {
+			t.Errorf("Expected %v to be %q, got %q", err, code, got)
+		}
+	}
+}
+
+func TestAuthorizationCodeVerifier(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	server := newServer(t)
+	server.RefreshTokens = false
+	code := createCodeOrFail(ctx, t, server, "web")
+
+	// what the authorization request the code was issued for included
+	const (
+		redirectURI = "https://client.example.com/callback"
+		verifier    = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+		challenge   = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	)
+	server.Codes = oauth.CodeVerifierFunc(func(_ context.Context, grant grants.Grant, exchange oauth.CodeExchange) error {
+		if grant.ID != code.ID {
+			t.Errorf("Expected code %q to be verified, got %q", code.ID, grant.ID)
+		}
+		if exchange.RedirectURI != redirectURI {
+			return oauth.ErrCodeMismatch
+		}
+		return oauth.VerifyPKCE(challenge, oauth.CodeChallengeMethodS256, exchange.CodeVerifier)
+	})
+
+	for name, form := range map[string]url.Values{
+		"no-redirect":    {"code_verifier": {verifier}},
+		"wrong-redirect": {"redirect_uri": {"https://attacker.example.com/"}, "code_verifier": {verifier}},
+		"no-verifier":    {"redirect_uri": {redirectURI}},
+		"wrong-verifier": {"redirect_uri": {redirectURI}, "code_verifier": {challenge}},
+	} {
+		form.Set("grant_type", "authorization_code")
+		form.Set("code", code.ID)
+		res := tokenRequest(t, server, form)
+		if res.status != http.StatusBadRequest || res.err.Error != oauth.ErrorInvalidGrant {
+			t.Errorf("Expected %s to fail with %q, got %d %+v", name, oauth.ErrorInvalidGrant, res.status, res.err)
+		}
+	}
+
+	// none of those used the code up
+	res := tokenRequest(t, server, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code.ID},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	})
+	if res.status != http.StatusOK {
+		t.Fatalf("Expected status %d, got %d: %+v", http.StatusOK, res.status, res.err)
+	}
+}
+
+func TestVerifyPKCE(t *testing.T) {
+	t.Parallel()
+
+	// the example from appendix B of RFC 7636
+	const (
+		verifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+		challenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+	)
+	cases := map[string]struct {
+		challenge string
+		method    string
+		verifier  string
+		err       error
+	}{
+		"s256":           {challenge: challenge, method: oauth.CodeChallengeMethodS256, verifier: verifier},
+		"s256-wrong":     {challenge: challenge, method: oauth.CodeChallengeMethodS256, verifier: challenge, err: oauth.ErrCodeMismatch},
+		"plain":          {challenge: verifier, method: oauth.CodeChallengeMethodPlain, verifier: verifier},
+		"plain-default":  {challenge: verifier, verifier: verifier},
+		"plain-wrong":    {challenge: challenge, method: oauth.CodeChallengeMethodPlain, verifier: verifier, err: oauth.ErrCodeMismatch},
+		"no-verifier":    {challenge: challenge, method: oauth.CodeChallengeMethodS256, err: oauth.ErrCodeMismatch},
+		"no-challenge":   {verifier: verifier, err: oauth.ErrCodeMismatch},
+		"unknown-method": {challenge: challenge, method: "S512", verifier: verifier, err: oauth.ErrCodeMismatch},
+	}
+
+	for name, test := range cases {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			err := oauth.VerifyPKCE(test.challenge, test.method, test.verifier)
+			if !errors.Is(err, test.err) {
+				t.Errorf("Expected error %v, got %v", test.err, err)
+			}
+		})
+	}
+}
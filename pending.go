@@ -0,0 +1,32 @@
+package grants
+
+import (
+	"context"
+)
+
+// PendingExchanger is implemented by Storers that can exchange a Grant that's
+// still GrantStatePending, which is how sources that create pending Grants,
+// like one-time passcodes, redeem them without the Grant ever being active
+// and exchangeable on its own. Storers that wrap another Storer, like the ones
+// returned by WithHashedSources, don't implement it; it should be called on
+// the underlying Storer.
+type PendingExchanger interface {
+	// ExchangePendingGrant moves the pending Grant identified by `use`
+	// through GrantStateActive to GrantStateUsed, recording `use` like
+	// ExchangeGrant does, in a single atomic operation, returning the
+	// exchanged Grant. If the Grant isn't pending, it's left as it is
+	// and the error ExchangePending returns is returned. If no Grant has
+	// the ID in `use`, an ErrGrantNotFound error is returned.
+	ExchangePendingGrant(ctx context.Context, use GrantUse) (Grant, error)
+}
+
+// ExchangePending returns a copy of `g` moved from GrantStatePending through
+// GrantStateActive to GrantStateUsed. If `g` isn't pending, the error
+// Transition returns when moving it to GrantStateActive is returned.
+func (g Grant) ExchangePending() (Grant, error) {
+	active, err := g.Transition(GrantStateActive)
+	if err != nil {
+		return Grant{}, err
+	}
+	return active.Transition(GrantStateUsed)
+}
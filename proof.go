@@ -0,0 +1,66 @@
+package grants
+
+import (
+	"context"
+	"fmt"
+)
+
+// ProofVerifier is the interface used to check that the party exchanging a
+// Grant holds the key the Grant is bound to.
+type ProofVerifier interface {
+	// VerifyProof checks the Proof in `use` against the KeyThumbprint
+	// of `grant`, returning an error wrapping ErrInvalidProof if the
+	// proof is missing or doesn't match.
+	VerifyProof(ctx context.Context, grant Grant, use GrantUse) error
+}
+
+// WithProofVerifier returns a Storer that wraps `storer`, requiring that any
+// Grant with a KeyThumbprint set can only be exchanged by a GrantUse with a
+// Proof that `verifier` accepts. Grants without a KeyThumbprint are exchanged
+// as usual.
+//
+// Because a Grant's KeyThumbprint never changes after it's created, the proof
+// is checked before the exchange is attempted, and a failed proof leaves the
+// Grant unused.
+func WithProofVerifier(storer Storer, verifier ProofVerifier) Storer { //nolint:ireturn // wrapping an interface
+	return proofStorer{Storer: storer, verifier: verifier}
+}
+
+type proofStorer struct {
+	Storer
+	verifier ProofVerifier
+}
+
+// ExchangeGrant verifies the proof of possession in `use` before passing it
+// on to the wrapped Storer.
+func (p proofStorer) ExchangeGrant(ctx context.Context, use GrantUse) (Grant, error) {
+	err := p.verify(ctx, use)
+	if err != nil {
+		return Grant{}, err
+	}
+	return p.Storer.ExchangeGrant(ctx, use)
+}
+
+// RotateGrant verifies the proof of possession in `use` before passing it on
+// to the wrapped Storer.
+func (p proofStorer) RotateGrant(ctx context.Context, use GrantUse, next Grant) (Grant, error) {
+	err := p.verify(ctx, use)
+	if err != nil {
+		return Grant{}, err
+	}
+	return p.Storer.RotateGrant(ctx, use, next)
+}
+
+func (p proofStorer) verify(ctx context.Context, use GrantUse) error {
+	grant, err := p.Storer.GetGrant(ctx, use.Grant)
+	if err != nil {
+		return err
+	}
+	if grant.KeyThumbprint != "" {
+		err = p.verifier.VerifyProof(ctx, grant, use)
+		if err != nil {
+			return fmt.Errorf("error verifying proof for %s: %w", use.Grant, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package grants
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+)
+
+const (
+	ipv4Bits = 8 * net.IPv4len
+	ipv6Bits = 8 * net.IPv6len
+)
+
+// ErrInvalidBatchSize is returned when a job that works through Grants in
+// batches is asked to use batches of fewer than one Grant.
+var ErrInvalidBatchSize = errors.New("batch size must be at least 1")
+
+// DefaultIPRetentionPolicy keeps IP addresses for 90 days, then zeroes the
+// host part of them: IPv4 addresses are truncated to their /24 network, and
+// IPv6 addresses to their /48 network.
+var DefaultIPRetentionPolicy = IPRetentionPolicy{
+	MaxAge:           90 * 24 * time.Hour, //nolint:gomnd // 90 days
+	IPv4PrefixLength: 24,                  //nolint:gomnd // a /24 network
+	IPv6PrefixLength: 48,                  //nolint:gomnd // a /48 network
+}
+
+// IPRetentionPolicy describes how long the CreateIP and UseIP of a Grant are
+// kept in full, and what is kept after that.
+type IPRetentionPolicy struct {
+	// MaxAge is how long after a Grant is created its IP addresses are
+	// kept in full.
+	MaxAge time.Duration
+
+	// IPv4PrefixLength is the number of leading bits of IPv4 addresses
+	// to keep once they're older than MaxAge; the rest are zeroed. If
+	// zero, IPv4 addresses are removed entirely.
+	IPv4PrefixLength int
+
+	// IPv6PrefixLength is the number of leading bits of IPv6 addresses
+	// to keep once they're older than MaxAge; the rest are zeroed. If
+	// zero, IPv6 addresses are removed entirely.
+	IPv6PrefixLength int
+}
+
+// Truncate returns what should be kept of `ip` once it's older than the
+// policy's MaxAge. Values that can't be parsed as an IP address are removed
+// entirely, as there's no way to know what part of them is safe to keep.
+func (p IPRetentionPolicy) Truncate(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+	if v4 := parsed.To4(); v4 != nil {
+		if p.IPv4PrefixLength <= 0 {
+			return ""
+		}
+		if p.IPv4PrefixLength >= ipv4Bits {
+			return v4.String()
+		}
+		return v4.Mask(net.CIDRMask(p.IPv4PrefixLength, ipv4Bits)).String()
+	}
+	if p.IPv6PrefixLength <= 0 {
+		return ""
+	}
+	if p.IPv6PrefixLength >= ipv6Bits {
+		return parsed.String()
+	}
+	return parsed.Mask(net.CIDRMask(p.IPv6PrefixLength, ipv6Bits)).String()
+}
+
+// IPRetainer is implemented by Storers that can enforce an IPRetentionPolicy.
+// Storers that wrap another Storer, like the ones returned by
+// WithHashedSources, don't implement it; the retention job should be run
+// against the underlying Storer.
+type IPRetainer interface {
+	// TruncateIPs applies `policy` to up to `limit` Grants created
+	// before `createdBefore` whose IP addresses haven't been truncated
+	// yet, leaving the rest of each Grant intact. It returns the number
+	// of Grants updated; a result less than `limit` means there are no
+	// Grants left to update.
+	TruncateIPs(ctx context.Context, createdBefore time.Time, policy IPRetentionPolicy, limit int) (int, error)
+}
+
+// ApplyIPRetention truncates the IP addresses of every Grant in `retainer`
+// that is older than the MaxAge of `policy`, `batchSize` Grants at a time,
+// returning the number of Grants updated. It's meant to be run periodically.
+// If `batchSize` is less than 1, an ErrInvalidBatchSize error is returned.
+func ApplyIPRetention(ctx context.Context, retainer IPRetainer, policy IPRetentionPolicy, batchSize int) (int, error) {
+	if batchSize < 1 {
+		return 0, ErrInvalidBatchSize
+	}
+	cutoff := time.Now().Add(-policy.MaxAge)
+	var total int
+	for {
+		count, err := retainer.TruncateIPs(ctx, cutoff, policy, batchSize)
+		total += count
+		if err != nil {
+			return total, err
+		}
+		if count < batchSize {
+			return total, nil
+		}
+	}
+}
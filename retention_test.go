@@ -0,0 +1,61 @@
+package grants_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lockbox.dev/grants"
+)
+
+func TestIPRetentionPolicyTruncate(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		policy   grants.IPRetentionPolicy
+		ip       string
+		expected string
+	}{
+		"ipv4-default":    {policy: grants.DefaultIPRetentionPolicy, ip: "192.168.1.2", expected: "192.168.1.0"},
+		"ipv6-default":    {policy: grants.DefaultIPRetentionPolicy, ip: "2001:db8:85a3::8a2e:370:7334", expected: "2001:db8:85a3::"},
+		"ipv4-in-ipv6":    {policy: grants.DefaultIPRetentionPolicy, ip: "::ffff:10.1.2.3", expected: "10.1.2.0"},
+		"already-done":    {policy: grants.DefaultIPRetentionPolicy, ip: "192.168.1.0", expected: "192.168.1.0"},
+		"empty":           {policy: grants.DefaultIPRetentionPolicy, ip: "", expected: ""},
+		"unparseable":     {policy: grants.DefaultIPRetentionPolicy, ip: "not an ip", expected: ""},
+		"ipv4-removed":    {policy: grants.IPRetentionPolicy{IPv6PrefixLength: 48}, ip: "192.168.1.2", expected: ""},
+		"ipv6-removed":    {policy: grants.IPRetentionPolicy{IPv4PrefixLength: 24}, ip: "2001:db8::1", expected: ""},
+		"ipv4-kept-whole": {policy: grants.IPRetentionPolicy{IPv4PrefixLength: 32}, ip: "192.168.1.2", expected: "192.168.1.2"},
+		"ipv4-16":         {policy: grants.IPRetentionPolicy{IPv4PrefixLength: 16}, ip: "192.168.1.2", expected: "192.168.0.0"},
+	}
+
+	for name, testCase := range cases {
+		name, testCase := name, testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got := testCase.policy.Truncate(testCase.ip)
+			if got != testCase.expected {
+				t.Errorf("Expected %q to be truncated to %q, got %q", testCase.ip, testCase.expected, got)
+			}
+		})
+	}
+}
+
+// emptyRetainer is an IPRetainer with no Grants to update.
+type emptyRetainer struct{}
+
+func (emptyRetainer) TruncateIPs(_ context.Context, _ time.Time, _ grants.IPRetentionPolicy, _ int) (int, error) {
+	return 0, nil
+}
+
+func TestApplyIPRetentionInvalidBatchSize(t *testing.T) {
+	t.Parallel()
+
+	for _, batchSize := range []int{0, -1} {
+		_, err := grants.ApplyIPRetention(context.Background(), emptyRetainer{}, grants.DefaultIPRetentionPolicy, batchSize)
+		if !errors.Is(err, grants.ErrInvalidBatchSize) {
+			t.Errorf("Expected error %v for a batch size of %d, got %v", grants.ErrInvalidBatchSize, batchSize, err)
+		}
+	}
+}
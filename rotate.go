@@ -0,0 +1,19 @@
+package grants
+
+// ChildOf returns a copy of `child` with its AncestorIDs set to record that it
+// was created from `parent`: the AncestorIDs of `parent`, followed by the ID
+// of `parent`. Any AncestorIDs already set on `child` are replaced.
+//
+// A family of Grants always belongs to a single tenant, so `child` is put in
+// the tenant of `parent`. If `child` already names a different tenant, an
+// ErrTenantMismatch error is returned.
+func ChildOf(parent, child Grant) (Grant, error) {
+	if child.TenantID != "" && child.TenantID != parent.TenantID {
+		return Grant{}, ErrTenantMismatch
+	}
+	child.TenantID = parent.TenantID
+	ancestors := make([]string, 0, len(parent.AncestorIDs)+1)
+	ancestors = append(ancestors, parent.AncestorIDs...)
+	child.AncestorIDs = append(ancestors, parent.ID)
+	return child, nil
+}
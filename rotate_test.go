@@ -0,0 +1,49 @@
+package grants_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"lockbox.dev/grants"
+)
+
+func TestChildOf(t *testing.T) {
+	t.Parallel()
+
+	parent := grants.Grant{ID: "parent", TenantID: "acme", AncestorIDs: []string{"root"}}
+	cases := map[string]struct {
+		child    grants.Grant
+		expected grants.Grant
+		err      error
+	}{
+		"same-tenant": {
+			child:    grants.Grant{ID: "child", TenantID: "acme", AncestorIDs: []string{"replaced"}},
+			expected: grants.Grant{ID: "child", TenantID: "acme", AncestorIDs: []string{"root", "parent"}},
+		},
+		"no-tenant": {
+			child:    grants.Grant{ID: "child"},
+			expected: grants.Grant{ID: "child", TenantID: "acme", AncestorIDs: []string{"root", "parent"}},
+		},
+		"other-tenant": {
+			child: grants.Grant{ID: "child", TenantID: "globex"},
+			err:   grants.ErrTenantMismatch,
+		},
+	}
+
+	for name, test := range cases {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			res, err := grants.ChildOf(parent, test.child)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Expected error %v, got %v", test.err, err)
+			}
+			if diff := cmp.Diff(test.expected, res); diff != "" {
+				t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+			}
+		})
+	}
+}
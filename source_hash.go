@@ -0,0 +1,181 @@
+package grants
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// HashedSourceIDPrefix is the prefix of every SourceID that has been
+	// hashed by a SourceHasher. The full format is
+	// "hmac:<key ID>:<hex-encoded HMAC-SHA256>".
+	HashedSourceIDPrefix = "hmac:"
+
+	// HashedSourceIDPattern is a regular expression matching the full
+	// format of every SourceID that has been hashed by a SourceHasher,
+	// in a syntax both Go and PostgreSQL understand.
+	HashedSourceIDPattern = "^" + HashedSourceIDPrefix + "[^:]+:[0-9a-f]{64}$"
+)
+
+//nolint:gochecknoglobals // compiled once, never modified
+var hashedSourceIDRegexp = regexp.MustCompile(HashedSourceIDPattern)
+
+var (
+	// ErrNoSourceHashKeys is returned when a SourceHasher is created
+	// without any keys.
+	ErrNoSourceHashKeys = errors.New("at least one source hash key is required")
+	// ErrInvalidSourceHashKey is returned when a SourceHashKey has no ID,
+	// an ID containing a colon, or no secret.
+	ErrInvalidSourceHashKey = errors.New("source hash keys need an ID without colons and a secret")
+)
+
+// SourceHashKey is a secret used to hash SourceIDs, identified by an ID that is
+// stored alongside the hashes it produces so the key can be rotated.
+type SourceHashKey struct {
+	ID     string
+	Secret []byte
+}
+
+// SourceHasher turns SourceIDs into HMACs, so the credentials they're often
+// derived from aren't stored in plaintext.
+//
+// The first key is the current key, and is used to hash every new SourceID.
+// The rest are previous keys, and are only used to find Grants created before
+// the current key was introduced.
+type SourceHasher struct {
+	keys []SourceHashKey
+}
+
+// NewSourceHasher returns a SourceHasher that hashes with `current`, and can
+// still find Grants hashed with any of the `previous` keys.
+func NewSourceHasher(current SourceHashKey, previous ...SourceHashKey) (SourceHasher, error) {
+	keys := append([]SourceHashKey{current}, previous...)
+	for _, key := range keys {
+		if key.ID == "" || strings.Contains(key.ID, ":") || len(key.Secret) < 1 {
+			return SourceHasher{}, fmt.Errorf("%w: key %q", ErrInvalidSourceHashKey, key.ID)
+		}
+	}
+	return SourceHasher{keys: keys}, nil
+}
+
+// Hash returns the SourceID to store for `sourceType` and `sourceID`, using
+// the current key.
+func (h SourceHasher) Hash(sourceType, sourceID string) (string, error) {
+	if len(h.keys) < 1 {
+		return "", ErrNoSourceHashKeys
+	}
+	return hashSourceID(h.keys[0], sourceType, sourceID), nil
+}
+
+// Candidates returns every SourceID a Grant for `sourceType` and `sourceID`
+// could be stored under: its hash under each key, current key first, followed
+// by the plaintext `sourceID` for Grants that haven't been hashed yet.
+func (h SourceHasher) Candidates(sourceType, sourceID string) []string {
+	res := make([]string, 0, len(h.keys)+1)
+	for _, key := range h.keys {
+		res = append(res, hashSourceID(key, sourceType, sourceID))
+	}
+	return append(res, sourceID)
+}
+
+// IsHashedSourceID returns true if `sourceID` was produced by a SourceHasher.
+func IsHashedSourceID(sourceID string) bool {
+	return hashedSourceIDRegexp.MatchString(sourceID)
+}
+
+func hashSourceID(key SourceHashKey, sourceType, sourceID string) string {
+	mac := hmac.New(sha256.New, key.Secret)
+	// include the source type so the same ID from two different sources
+	// never produces the same hash
+	mac.Write([]byte(sourceType)) //nolint:errcheck // hash writes never return errors
+	mac.Write([]byte{0})          //nolint:errcheck // hash writes never return errors
+	mac.Write([]byte(sourceID))   //nolint:errcheck // hash writes never return errors
+	return HashedSourceIDPrefix + key.ID + ":" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// WithHashedSources returns a Storer that wraps `storer`, storing the HMAC of
+// every Grant's SourceID instead of the SourceID itself.
+//
+// Lookups by source and the uniqueness of sources keep working transparently:
+// GetGrantBySource and CreateGrant check the hash under every key the
+// SourceHasher knows about, as well as the plaintext SourceID, so Grants
+// created before hashing was turned on or before a key rotation are still
+// found. Grants returned from the Storer will have the hashed SourceID.
+//
+// A SourceID is recognized as hashed by its format, as described by
+// HashedSourceIDPattern, so a plaintext SourceID that happens to match that
+// format is treated as if it were already hashed, and is left as it is by
+// migrations like the PostgreSQL Storer's HashSourceIDs.
+func WithHashedSources(storer Storer, hasher SourceHasher) Storer { //nolint:ireturn // wrapping an interface
+	return hashedSourceStorer{Storer: storer, hasher: hasher}
+}
+
+type hashedSourceStorer struct {
+	Storer
+	hasher SourceHasher
+}
+
+// CreateGrant hashes the SourceID of `grant` before storing it, returning an
+// ErrGrantSourceAlreadyUsed error if the source is already stored under any
+// key.
+func (h hashedSourceStorer) CreateGrant(ctx context.Context, grant Grant) error {
+	grant, err := h.hash(ctx, grant)
+	if err != nil {
+		return err
+	}
+	return h.Storer.CreateGrant(ctx, grant)
+}
+
+// RotateGrant hashes the SourceID of `next` before passing it on to the
+// wrapped Storer, returning an ErrGrantSourceAlreadyUsed error if the source
+// of `next` is already stored under any key.
+func (h hashedSourceStorer) RotateGrant(ctx context.Context, use GrantUse, next Grant) (Grant, error) {
+	next, err := h.hash(ctx, next)
+	if err != nil {
+		return Grant{}, err
+	}
+	return h.Storer.RotateGrant(ctx, use, next)
+}
+
+// hash returns `grant` with its SourceID hashed under the current key, after
+// checking that its source isn't stored under any other key.
+func (h hashedSourceStorer) hash(ctx context.Context, grant Grant) (Grant, error) {
+	hashed, err := h.hasher.Hash(grant.SourceType, grant.SourceID)
+	if err != nil {
+		return Grant{}, err
+	}
+	// the current key's hash is covered by the wrapped Storer's own
+	// uniqueness check, but the others need to be checked here
+	for _, candidate := range h.hasher.Candidates(grant.SourceType, grant.SourceID)[1:] {
+		_, err = h.Storer.GetGrantBySource(ctx, grant.SourceType, candidate)
+		if err == nil {
+			return Grant{}, ErrGrantSourceAlreadyUsed
+		}
+		if !errors.Is(err, ErrGrantNotFound) {
+			return Grant{}, err
+		}
+	}
+	grant.SourceID = hashed
+	return grant, nil
+}
+
+// GetGrantBySource retrieves the Grant for `sourceType` and `sourceID`,
+// whichever key its SourceID was hashed with.
+func (h hashedSourceStorer) GetGrantBySource(ctx context.Context, sourceType, sourceID string) (Grant, error) {
+	for _, candidate := range h.hasher.Candidates(sourceType, sourceID) {
+		grant, err := h.Storer.GetGrantBySource(ctx, sourceType, candidate)
+		if err == nil {
+			return grant, nil
+		}
+		if !errors.Is(err, ErrGrantNotFound) {
+			return Grant{}, err
+		}
+	}
+	return Grant{}, ErrGrantNotFound
+}
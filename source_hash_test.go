@@ -0,0 +1,43 @@
+package grants_test
+
+import (
+	"strings"
+	"testing"
+
+	"lockbox.dev/grants"
+)
+
+func TestIsHashedSourceID(t *testing.T) {
+	t.Parallel()
+
+	hasher, err := grants.NewSourceHasher(grants.SourceHashKey{ID: "k1", Secret: []byte("secret")})
+	if err != nil {
+		t.Fatalf("Unexpected error creating hasher: %s", err)
+	}
+	hashed, err := hasher.Hash("email", "test@example.com")
+	if err != nil {
+		t.Fatalf("Unexpected error hashing source ID: %s", err)
+	}
+
+	cases := map[string]struct {
+		sourceID string
+		expected bool
+	}{
+		"hashed":         {sourceID: hashed, expected: true},
+		"plaintext":      {sourceID: "test@example.com"},
+		"prefixed":       {sourceID: "hmac:not-actually-hashed"},
+		"short-hmac":     {sourceID: "hmac:k1:abc123"},
+		"uppercase-hmac": {sourceID: "hmac:k1:" + strings.Repeat("A", 64)},
+	}
+
+	for name, test := range cases {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := grants.IsHashedSourceID(test.sourceID); got != test.expected {
+				t.Errorf("Expected IsHashedSourceID(%q) to be %v, got %v", test.sourceID, test.expected, got)
+			}
+		})
+	}
+}
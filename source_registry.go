@@ -0,0 +1,136 @@
+package grants
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	// ErrSourceTypeNotRegistered is returned when a Grant is being created
+	// or a credential verified for a source type that hasn't been
+	// registered with the SourceRegistry being used. This usually
+	// indicates a programming error or a login method that has been
+	// turned off.
+	ErrSourceTypeNotRegistered = errors.New("source type not registered")
+	// ErrSourceTypeAlreadyRegistered is returned when a source type is
+	// being registered with a SourceRegistry that already has a verifier
+	// for it. This usually indicates a programming error.
+	ErrSourceTypeAlreadyRegistered = errors.New("source type already registered")
+	// ErrInvalidSourceType is returned when a source type is being
+	// registered without a name or without a verifier.
+	ErrInvalidSourceType = errors.New("source types need a name and a verifier")
+)
+
+// SourceVerifier validates the credentials of a single source type, like an
+// email login code or an ID token, and turns them into SourceIDs.
+type SourceVerifier interface {
+	// VerifySource validates `credential`, returning the SourceID a
+	// Grant created from it should have. The same credential must always
+	// produce the same SourceID, so the uniqueness of sources can block
+	// the credential from being used twice.
+	VerifySource(ctx context.Context, credential string) (string, error)
+}
+
+// SourceVerifierFunc is a function that fills the SourceVerifier interface.
+type SourceVerifierFunc func(ctx context.Context, credential string) (string, error)
+
+// VerifySource calls `f`.
+func (f SourceVerifierFunc) VerifySource(ctx context.Context, credential string) (string, error) {
+	return f(ctx, credential)
+}
+
+// SourceRegistry keeps track of the source types Grants can be created from,
+// and the SourceVerifier for each of them. The zero value is an empty
+// SourceRegistry, ready to be used. A SourceRegistry is safe for concurrent
+// use.
+type SourceRegistry struct {
+	verifiers map[string]SourceVerifier
+	lock      sync.RWMutex
+}
+
+// Register makes `sourceType` available, using `verifier` to validate its
+// credentials. Each source type can only be registered once.
+func (r *SourceRegistry) Register(sourceType string, verifier SourceVerifier) error {
+	if sourceType == "" || verifier == nil {
+		return ErrInvalidSourceType
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.verifiers[sourceType]; ok {
+		return fmt.Errorf("%w: %q", ErrSourceTypeAlreadyRegistered, sourceType)
+	}
+	if r.verifiers == nil {
+		r.verifiers = map[string]SourceVerifier{}
+	}
+	r.verifiers[sourceType] = verifier
+	return nil
+}
+
+// Registered returns true if `sourceType` has been registered.
+func (r *SourceRegistry) Registered(sourceType string) bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	_, ok := r.verifiers[sourceType]
+	return ok
+}
+
+// SourceTypes returns every registered source type, sorted.
+func (r *SourceRegistry) SourceTypes() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	res := make([]string, 0, len(r.verifiers))
+	for sourceType := range r.verifiers {
+		res = append(res, sourceType)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// Verify validates `credential` with the verifier registered for
+// `sourceType`, returning the SourceID a Grant created from it should have.
+// An error wrapping ErrSourceTypeNotRegistered is returned if no verifier is
+// registered for `sourceType`.
+func (r *SourceRegistry) Verify(ctx context.Context, sourceType, credential string) (string, error) {
+	r.lock.RLock()
+	verifier, ok := r.verifiers[sourceType]
+	r.lock.RUnlock()
+	if !ok {
+		return "", fmt.Errorf("%w: %q", ErrSourceTypeNotRegistered, sourceType)
+	}
+	return verifier.VerifySource(ctx, credential)
+}
+
+// WithSourceRegistry returns a Storer that wraps `storer`, only allowing
+// Grants to be created for source types registered with `registry`.
+// CreateGrant returns an error wrapping ErrSourceTypeNotRegistered for any
+// other source type. Existing Grants are read, exchanged, and revoked as
+// usual, whatever their source type.
+func WithSourceRegistry(storer Storer, registry *SourceRegistry) Storer { //nolint:ireturn // wrapping an interface
+	return registryStorer{Storer: storer, registry: registry}
+}
+
+type registryStorer struct {
+	Storer
+	registry *SourceRegistry
+}
+
+// CreateGrant checks that the SourceType of `grant` is registered before
+// passing it on to the wrapped Storer.
+func (r registryStorer) CreateGrant(ctx context.Context, grant Grant) error {
+	if !r.registry.Registered(grant.SourceType) {
+		return fmt.Errorf("%w: %q", ErrSourceTypeNotRegistered, grant.SourceType)
+	}
+	return r.Storer.CreateGrant(ctx, grant)
+}
+
+// RotateGrant checks that the SourceType of `next` is registered before
+// passing it on to the wrapped Storer.
+func (r registryStorer) RotateGrant(ctx context.Context, use GrantUse, next Grant) (Grant, error) {
+	if !r.registry.Registered(next.SourceType) {
+		return Grant{}, fmt.Errorf("%w: %q", ErrSourceTypeNotRegistered, next.SourceType)
+	}
+	return r.Storer.RotateGrant(ctx, use, next)
+}
@@ -0,0 +1,119 @@
+package grants_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"lockbox.dev/grants"
+)
+
+var errBadCredential = errors.New("bad credential")
+
+func upperVerifier(_ context.Context, credential string) (string, error) {
+	if credential == "" {
+		return "", errBadCredential
+	}
+	return strings.ToUpper(credential), nil
+}
+
+func TestSourceRegistryVerify(t *testing.T) {
+	t.Parallel()
+
+	var registry grants.SourceRegistry
+	err := registry.Register("upper", grants.SourceVerifierFunc(upperVerifier))
+	if err != nil {
+		t.Fatalf("Unexpected error registering source type: %+v\n", err)
+	}
+	err = registry.Register("lower", grants.SourceVerifierFunc(func(_ context.Context, credential string) (string, error) {
+		return strings.ToLower(credential), nil
+	}))
+	if err != nil {
+		t.Fatalf("Unexpected error registering source type: %+v\n", err)
+	}
+
+	err = registry.Register("upper", grants.SourceVerifierFunc(upperVerifier))
+	if !errors.Is(err, grants.ErrSourceTypeAlreadyRegistered) {
+		t.Errorf("Expected error to be %v, got %v", grants.ErrSourceTypeAlreadyRegistered, err)
+	}
+	err = registry.Register("", grants.SourceVerifierFunc(upperVerifier))
+	if !errors.Is(err, grants.ErrInvalidSourceType) {
+		t.Errorf("Expected error to be %v, got %v", grants.ErrInvalidSourceType, err)
+	}
+	err = registry.Register("nil", nil)
+	if !errors.Is(err, grants.ErrInvalidSourceType) {
+		t.Errorf("Expected error to be %v, got %v", grants.ErrInvalidSourceType, err)
+	}
+
+	if diff := cmp.Diff([]string{"lower", "upper"}, registry.SourceTypes()); diff != "" {
+		t.Errorf("Unexpected source types diff (-wanted, +got): %s", diff)
+	}
+
+	sourceID, err := registry.Verify(context.Background(), "upper", "test")
+	if err != nil {
+		t.Fatalf("Unexpected error verifying credential: %+v\n", err)
+	}
+	if sourceID != "TEST" {
+		t.Errorf("Expected source ID %q, got %q", "TEST", sourceID)
+	}
+	_, err = registry.Verify(context.Background(), "upper", "")
+	if !errors.Is(err, errBadCredential) {
+		t.Errorf("Expected error to be %v, got %v", errBadCredential, err)
+	}
+	_, err = registry.Verify(context.Background(), "missing", "test")
+	if !errors.Is(err, grants.ErrSourceTypeNotRegistered) {
+		t.Errorf("Expected error to be %v, got %v", grants.ErrSourceTypeNotRegistered, err)
+	}
+}
+
+func TestSourceRegistryCreateGrant(t *testing.T) {
+	t.Parallel()
+
+	runTest(t, func(t *testing.T, storer grants.Storer, ctx context.Context) {
+		var registry grants.SourceRegistry
+		err := registry.Register("upper", grants.SourceVerifierFunc(upperVerifier))
+		if err != nil {
+			t.Fatalf("Unexpected error registering source type: %+v\n", err)
+		}
+		wrapped := grants.WithSourceRegistry(storer, &registry)
+
+		sourceID, err := registry.Verify(ctx, "upper", "TestSourceRegistryCreateGrant-"+uuidOrFail(t))
+		if err != nil {
+			t.Fatalf("Unexpected error verifying credential: %+v\n", err)
+		}
+		grant := grants.Grant{
+			ID:          uuidOrFail(t),
+			SourceType:  "upper",
+			SourceID:    sourceID,
+			AncestorIDs: []string{},
+			ProfileID:   uuidOrFail(t),
+			ClientID:    "testrunner",
+		}
+		err = wrapped.CreateGrant(ctx, grant)
+		if err != nil {
+			t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+		}
+		resp, err := wrapped.GetGrantBySource(ctx, "upper", sourceID)
+		if err != nil {
+			t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+		}
+		if resp.ID != grant.ID {
+			t.Errorf("Expected grant %s, got %s", grant.ID, resp.ID)
+		}
+
+		unregistered := grant
+		unregistered.ID = uuidOrFail(t)
+		unregistered.SourceType = "manual"
+		err = wrapped.CreateGrant(ctx, unregistered)
+		if !errors.Is(err, grants.ErrSourceTypeNotRegistered) {
+			t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrSourceTypeNotRegistered, storer, err)
+		}
+		_, err = storer.GetGrant(ctx, unregistered.ID)
+		if !errors.Is(err, grants.ErrGrantNotFound) {
+			t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+		}
+	})
+}
@@ -0,0 +1,435 @@
+// Package device implements the OAuth 2.0 device authorization grant, as
+// described by RFC 8628, as a grant source. It lets devices with limited input,
+// like TVs and command line tools, be granted access by a user approving the
+// request on another device.
+//
+// A device starts the flow with Start, which returns a device code it keeps to
+// itself and a short user code it shows to the user. The user enters the user
+// code on another device, where it's approved or denied. Meanwhile, the
+// device polls with its device code; once the request is approved, polling
+// returns a Grant for the user who approved it, which can then be exchanged.
+//
+// The device code itself is never stored. DeviceAuthorizations and Grants
+// created by this package are identified by the SHA-256 hash of the device
+// code, and the Grants have a SourceType of "device_code".
+package device
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+const (
+	// SourceType is the SourceType of every Grant created by this
+	// package.
+	SourceType = "device_code"
+
+	// DefaultTTL is how long a device authorization can be approved for
+	// when no TTL is set on the Source.
+	DefaultTTL = 10 * time.Minute
+
+	// DefaultInterval is how long devices must wait between polls when
+	// no Interval is set on the Source. It's the default RFC 8628 sets
+	// for clients when no interval is given.
+	DefaultInterval = 5 * time.Second
+
+	// userCodeAlphabet is the set of characters user codes are made of:
+	// uppercase consonants, which are easy to type and can't spell
+	// words, as suggested by RFC 8628, section 6.1.
+	userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+	// userCodeLength is the number of characters in a user code, giving
+	// roughly 34 bits of entropy.
+	userCodeLength = 8
+
+	// deviceCodeSize is the number of random bytes in a device code,
+	// giving device codes 256 bits of entropy.
+	deviceCodeSize = 32
+
+	// maxUserCodeAttempts is how many times a new user code is generated
+	// when the one generated collides with an existing one.
+	maxUserCodeAttempts = 5
+)
+
+var (
+	// ErrInvalidDeviceCode is returned when a device code is presented
+	// that isn't shaped like a device code.
+	ErrInvalidDeviceCode = errors.New("invalid device code")
+	// ErrInvalidUserCode is returned when a user code is presented that
+	// isn't shaped like a user code.
+	ErrInvalidUserCode = errors.New("invalid user code")
+)
+
+// Request describes the access a device is asking for.
+type Request struct {
+	ClientID string   // the client requesting access
+	Scopes   []string // the scopes the client is requesting
+}
+
+// Authorization is what a device needs to continue the flow, corresponding to
+// the device authorization response of RFC 8628, section 3.2.
+type Authorization struct {
+	// DeviceCode is the code the device polls with. It must only ever be
+	// known to the device.
+	DeviceCode string
+
+	// UserCode is the code the user enters to approve the request.
+	UserCode string
+
+	// VerificationURI is where the user should enter UserCode.
+	VerificationURI string
+
+	// VerificationURIComplete is VerificationURI with UserCode included,
+	// for devices that can display it as a QR code or similar. It's empty
+	// if the Source has no VerificationURI.
+	VerificationURIComplete string
+
+	// ExpiresAt is when the request expires if it hasn't been approved
+	// or denied.
+	ExpiresAt time.Time
+
+	// Interval is the minimum time the device must wait between polls.
+	Interval time.Duration
+}
+
+// Approval describes the user approving a device's request.
+type Approval struct {
+	ProfileID string // the profile the Grant is for
+	AccountID string // the account the Grant is for
+	IP        string // the IP address the user approved the request from
+}
+
+// Source starts device authorizations, records users' decisions on them, and
+// answers devices' polls.
+type Source struct {
+	// Storer is where Grants are stored.
+	Storer grants.Storer
+
+	// Devices is where DeviceAuthorizations are stored.
+	Devices grants.DeviceStorer
+
+	// Notifier wakes Wait as soon as a request is approved or denied
+	// through a Source sharing it. If nil, or if the request is decided
+	// through a Source that doesn't share it, like one in another
+	// process, Wait notices the decision the next time the request's
+	// Interval passes instead.
+	Notifier *Notifier
+
+	// VerificationURI is where users approve requests, returned to
+	// devices to show to the user.
+	VerificationURI string
+
+	// TTL is how long a request can be approved for. If zero, DefaultTTL
+	// is used.
+	TTL time.Duration
+
+	// Interval is the minimum time devices must wait between polls. If
+	// zero, DefaultInterval is used.
+	Interval time.Duration
+
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+}
+
+// SourceID returns the SourceID of the Grant created for `deviceCode`, which
+// is also the ID of its DeviceAuthorization.
+func SourceID(deviceCode string) string {
+	sum := sha256.Sum256([]byte(deviceCode))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifySource checks that `credential` is shaped like a device code and
+// returns the SourceID of the Grant created for it, so a Source can be
+// registered with a grants.SourceRegistry.
+func (Source) VerifySource(_ context.Context, credential string) (string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(credential)
+	if err != nil || len(decoded) != deviceCodeSize {
+		return "", ErrInvalidDeviceCode
+	}
+	return SourceID(credential), nil
+}
+
+// NormalizeUserCode returns `userCode` in the form user codes are stored in,
+// ignoring case, dashes, and whitespace, so users don't need to type them
+// exactly as displayed. It returns an ErrInvalidUserCode error if `userCode`
+// can't be a user code.
+func NormalizeUserCode(userCode string) (string, error) {
+	var normalized strings.Builder
+	for _, char := range strings.ToUpper(userCode) {
+		switch {
+		case char == '-' || char == ' ' || char == '\t':
+			continue
+		case !strings.ContainsRune(userCodeAlphabet, char):
+			return "", ErrInvalidUserCode
+		}
+		normalized.WriteRune(char)
+	}
+	if normalized.Len() != userCodeLength {
+		return "", ErrInvalidUserCode
+	}
+	code := normalized.String()
+	return code[:userCodeLength/2] + "-" + code[userCodeLength/2:], nil
+}
+
+// generateUserCode returns a random user code in its normalized form.
+func generateUserCode() (string, error) {
+	var code strings.Builder
+	// only use bytes below the largest multiple of the alphabet's length,
+	// so every character is equally likely
+	limit := byte(256 / len(userCodeAlphabet) * len(userCodeAlphabet)) //nolint:gomnd // the number of values in a byte
+	for code.Len() < userCodeLength {
+		random, err := uuid.GenerateRandomBytes(userCodeLength)
+		if err != nil {
+			return "", err
+		}
+		for _, b := range random {
+			if b >= limit || code.Len() >= userCodeLength {
+				continue
+			}
+			code.WriteByte(userCodeAlphabet[int(b)%len(userCodeAlphabet)])
+		}
+	}
+	return NormalizeUserCode(code.String())
+}
+
+// Start records a request for access from a device, returning the codes the
+// device needs to continue the flow.
+func (s Source) Start(ctx context.Context, req Request) (Authorization, error) {
+	random, err := uuid.GenerateRandomBytes(deviceCodeSize)
+	if err != nil {
+		return Authorization{}, err
+	}
+	deviceCode := base64.RawURLEncoding.EncodeToString(random)
+	now := s.now()
+	auth := grants.DeviceAuthorization{
+		ID:        SourceID(deviceCode),
+		ClientID:  req.ClientID,
+		Scopes:    req.Scopes,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.ttl()),
+		Interval:  s.interval(),
+		Status:    grants.DeviceStatusPending,
+	}
+	// user codes are short enough that they'll occasionally collide, so
+	// try again with a new one when they do
+	for attempt := 0; attempt < maxUserCodeAttempts; attempt++ {
+		auth.UserCode, err = generateUserCode()
+		if err != nil {
+			return Authorization{}, err
+		}
+		err = s.Devices.CreateDeviceAuthorization(ctx, auth)
+		if !errors.Is(err, grants.ErrDeviceAuthorizationAlreadyExists) {
+			break
+		}
+	}
+	if err != nil {
+		return Authorization{}, err
+	}
+	res := Authorization{
+		DeviceCode:      deviceCode,
+		UserCode:        auth.UserCode,
+		VerificationURI: s.VerificationURI,
+		ExpiresAt:       auth.ExpiresAt,
+		Interval:        auth.Interval,
+	}
+	if s.VerificationURI != "" {
+		res.VerificationURIComplete, err = completeURI(s.VerificationURI, auth.UserCode)
+		if err != nil {
+			return Authorization{}, err
+		}
+	}
+	return res, nil
+}
+
+func completeURI(verificationURI, userCode string) (string, error) {
+	parsed, err := url.Parse(verificationURI)
+	if err != nil {
+		return "", fmt.Errorf("error parsing verification URI: %w", err)
+	}
+	query := parsed.Query()
+	query.Set("user_code", userCode)
+	parsed.RawQuery = query.Encode()
+	return parsed.String(), nil
+}
+
+// Lookup returns the DeviceAuthorization for `userCode`, so the client and
+// scopes it's for can be shown to the user before they approve or deny it.
+func (s Source) Lookup(ctx context.Context, userCode string) (grants.DeviceAuthorization, error) {
+	normalized, err := NormalizeUserCode(userCode)
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	return s.Devices.GetDeviceAuthorizationByUserCode(ctx, normalized)
+}
+
+// Approve approves the request for `userCode` on behalf of the user described
+// by `approval`, creating and returning a Grant for them that the device will
+// receive the next time it polls.
+//
+// Requests that have already been approved or denied return
+// grants.ErrDeviceAuthorizationNotPending, and requests that have expired
+// return grants.ErrDeviceCodeExpired.
+func (s Source) Approve(ctx context.Context, userCode string, approval Approval) (grants.Grant, error) {
+	auth, err := s.Lookup(ctx, userCode)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	now := s.now()
+	// check before creating the Grant, so requests that can't be
+	// approved don't leave revoked Grants behind
+	_, err = auth.Decide(grants.DeviceDecision{Status: grants.DeviceStatusApproved, Time: now})
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	grant, err := grants.FillGrantDefaults(grants.Grant{
+		SourceType: SourceType,
+		SourceID:   auth.ID,
+		CreatedAt:  now,
+		Scopes:     auth.Scopes,
+		AccountID:  approval.AccountID,
+		ProfileID:  approval.ProfileID,
+		ClientID:   auth.ClientID,
+		CreateIP:   approval.IP,
+	})
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	// creating the Grant first means two concurrent approvals can't both
+	// succeed: the second will fail, as the source is already used
+	err = s.Storer.CreateGrant(ctx, grant)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	_, err = s.Devices.DecideDeviceAuthorization(ctx, grants.DeviceDecision{
+		UserCode:  auth.UserCode,
+		Status:    grants.DeviceStatusApproved,
+		ProfileID: approval.ProfileID,
+		AccountID: approval.AccountID,
+		GrantID:   grant.ID,
+		Time:      now,
+	})
+	if err != nil {
+		_, revokeErr := s.Storer.RevokeGrant(ctx, grant.ID)
+		if revokeErr != nil {
+			yall.FromContext(ctx).WithField("grant", grant.ID).WithError(revokeErr).Error("error revoking grant after failing to approve its device authorization")
+		}
+		return grants.Grant{}, err
+	}
+	s.Notifier.notify(auth.ID)
+	return grant, nil
+}
+
+// Deny denies the request for `userCode`. Requests that have already been
+// approved or denied return grants.ErrDeviceAuthorizationNotPending.
+func (s Source) Deny(ctx context.Context, userCode string) error {
+	normalized, err := NormalizeUserCode(userCode)
+	if err != nil {
+		return err
+	}
+	auth, err := s.Devices.DecideDeviceAuthorization(ctx, grants.DeviceDecision{
+		UserCode: normalized,
+		Status:   grants.DeviceStatusDenied,
+		Time:     s.now(),
+	})
+	if err != nil {
+		return err
+	}
+	s.Notifier.notify(auth.ID)
+	return nil
+}
+
+// Poll answers a poll from the device holding `deviceCode`, returning the
+// Grant created for it once its request has been approved.
+//
+// Until then, it returns grants.ErrDeviceAuthorizationPending, or
+// grants.ErrDeviceSlowDown if the device is polling too quickly, in which case
+// the device must add grants.DeviceSlowDownIncrement to the interval it polls
+// at. Denied requests return grants.ErrDeviceAccessDenied, and expired
+// requests return grants.ErrDeviceCodeExpired.
+func (s Source) Poll(ctx context.Context, deviceCode string) (grants.Grant, error) {
+	auth, err := s.Devices.PollDeviceAuthorization(ctx, SourceID(deviceCode), s.now())
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return s.Storer.GetGrant(ctx, auth.GrantID)
+}
+
+// Wait is like Poll, but instead of returning
+// grants.ErrDeviceAuthorizationPending, it waits until the request is
+// approved, denied, or expires, or `ctx` is done. This lets a server hold a
+// device's poll open instead of making it poll repeatedly.
+//
+// Only the initial poll is subject to the request's Interval; while waiting,
+// the request is checked when a Source sharing the same Notifier decides it,
+// and every Interval in case it was decided elsewhere.
+func (s Source) Wait(ctx context.Context, deviceCode string) (grants.Grant, error) {
+	id := SourceID(deviceCode)
+	// subscribe before the first poll, so a decision made right after it
+	// isn't missed
+	decided, unsubscribe := s.Notifier.subscribe(id)
+	defer unsubscribe()
+
+	auth, err := s.Devices.PollDeviceAuthorization(ctx, id, s.now())
+	for errors.Is(err, grants.ErrDeviceAuthorizationPending) {
+		delay := auth.Interval
+		if delay <= 0 {
+			delay = DefaultInterval
+		}
+		if untilExpiry := auth.ExpiresAt.Sub(s.now()); untilExpiry < delay {
+			delay = untilExpiry
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return grants.Grant{}, ctx.Err()
+		case <-decided:
+			// the channel is closed once notified, so stop
+			// listening to it
+			decided = nil
+		case <-timer.C:
+		}
+		timer.Stop()
+		auth, err = s.Devices.GetDeviceAuthorization(ctx, id)
+		if err == nil {
+			err = auth.Check(s.now())
+		}
+	}
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return s.Storer.GetGrant(ctx, auth.GrantID)
+}
+
+func (s Source) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s Source) ttl() time.Duration {
+	if s.TTL > 0 {
+		return s.TTL
+	}
+	return DefaultTTL
+}
+
+func (s Source) interval() time.Duration {
+	if s.Interval > 0 {
+		return s.Interval
+	}
+	return DefaultInterval
+}
@@ -0,0 +1,345 @@
+package device_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/sources/device"
+	"lockbox.dev/grants/storers/memory"
+)
+
+type clock struct {
+	now  time.Time
+	lock sync.Mutex
+}
+
+func (c *clock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func newSource(t *testing.T) (device.Source, *clock) {
+	t.Helper()
+	storer, err := memory.NewStorer()
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+	now := &clock{now: time.Now().Round(time.Millisecond)}
+	return device.Source{
+		Storer:          storer,
+		Devices:         storer,
+		Notifier:        &device.Notifier{},
+		VerificationURI: "https://example.com/device",
+		TTL:             10 * time.Minute,
+		Interval:        5 * time.Second,
+		Now:             now.Now,
+	}, now
+}
+
+func startOrFail(ctx context.Context, t *testing.T, source device.Source) device.Authorization {
+	t.Helper()
+	auth, err := source.Start(ctx, device.Request{
+		ClientID: "tv",
+		Scopes:   []string{"https://scopes.impractical.co/test"},
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error starting device authorization: %s", err)
+	}
+	return auth
+}
+
+func TestStart(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, now := newSource(t)
+	auth := startOrFail(ctx, t, source)
+
+	if _, err := source.VerifySource(ctx, auth.DeviceCode); err != nil {
+		t.Errorf("Expected device code %q to verify, got %s", auth.DeviceCode, err)
+	}
+	normalized, err := device.NormalizeUserCode(auth.UserCode)
+	if err != nil {
+		t.Errorf("Unexpected error normalizing user code %q: %s", auth.UserCode, err)
+	}
+	if normalized != auth.UserCode {
+		t.Errorf("Expected user code %q to already be normalized, got %q", auth.UserCode, normalized)
+	}
+	if auth.VerificationURIComplete != "https://example.com/device?user_code="+auth.UserCode {
+		t.Errorf("Unexpected complete verification URI %q", auth.VerificationURIComplete)
+	}
+	if !auth.ExpiresAt.Equal(now.Now().Add(10 * time.Minute)) {
+		t.Errorf("Expected expiry of %s, got %s", now.Now().Add(10*time.Minute), auth.ExpiresAt)
+	}
+	if auth.Interval != 5*time.Second {
+		t.Errorf("Expected interval of %s, got %s", 5*time.Second, auth.Interval)
+	}
+}
+
+func TestNormalizeUserCode(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		input  string
+		output string
+		err    error
+	}{
+		"normalized":   {input: "BCDF-GHJK", output: "BCDF-GHJK"},
+		"lowercase":    {input: "bcdf-ghjk", output: "BCDF-GHJK"},
+		"no-dash":      {input: "BCDFGHJK", output: "BCDF-GHJK"},
+		"spaces":       {input: " bcdf ghjk ", output: "BCDF-GHJK"},
+		"vowel":        {input: "BCDF-GHJA", err: device.ErrInvalidUserCode},
+		"too-short":    {input: "BCDF-GHJ", err: device.ErrInvalidUserCode},
+		"too-long":     {input: "BCDF-GHJKL", err: device.ErrInvalidUserCode},
+		"empty":        {input: "", err: device.ErrInvalidUserCode},
+		"wrong-symbol": {input: "BCDF_GHJK", err: device.ErrInvalidUserCode},
+	}
+
+	for name, test := range tests {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			output, err := device.NormalizeUserCode(test.input)
+			if !errors.Is(err, test.err) {
+				t.Errorf("Expected error %v, got %v", test.err, err)
+			}
+			if output != test.output {
+				t.Errorf("Expected %q, got %q", test.output, output)
+			}
+		})
+	}
+}
+
+func TestApproveAndPoll(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, now := newSource(t)
+	auth := startOrFail(ctx, t, source)
+
+	_, err := source.Poll(ctx, auth.DeviceCode)
+	if !errors.Is(err, grants.ErrDeviceAuthorizationPending) {
+		t.Errorf("Expected error %v, got %v", grants.ErrDeviceAuthorizationPending, err)
+	}
+
+	found, err := source.Lookup(ctx, strings.ToLower(auth.UserCode))
+	if err != nil {
+		t.Fatalf("Unexpected error looking up user code: %s", err)
+	}
+	if found.ClientID != "tv" {
+		t.Errorf("Expected client ID %q, got %q", "tv", found.ClientID)
+	}
+
+	approved, err := source.Approve(ctx, auth.UserCode, device.Approval{ProfileID: "user", AccountID: "account", IP: "1.2.3.4"})
+	if err != nil {
+		t.Fatalf("Unexpected error approving: %s", err)
+	}
+	if approved.ProfileID != "user" || approved.ClientID != "tv" || approved.SourceType != device.SourceType || approved.SourceID != device.SourceID(auth.DeviceCode) {
+		t.Errorf("Unexpected approved grant %+v", approved)
+	}
+
+	now.Advance(auth.Interval)
+	grant, err := source.Poll(ctx, auth.DeviceCode)
+	if err != nil {
+		t.Fatalf("Unexpected error polling: %s", err)
+	}
+	if grant.ID != approved.ID {
+		t.Errorf("Expected grant %s, got %s", approved.ID, grant.ID)
+	}
+
+	_, err = source.Approve(ctx, auth.UserCode, device.Approval{ProfileID: "other"})
+	if !errors.Is(err, grants.ErrDeviceAuthorizationNotPending) {
+		t.Errorf("Expected error %v, got %v", grants.ErrDeviceAuthorizationNotPending, err)
+	}
+	err = source.Deny(ctx, auth.UserCode)
+	if !errors.Is(err, grants.ErrDeviceAuthorizationNotPending) {
+		t.Errorf("Expected error %v, got %v", grants.ErrDeviceAuthorizationNotPending, err)
+	}
+}
+
+func TestSlowDown(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, now := newSource(t)
+	auth := startOrFail(ctx, t, source)
+
+	_, err := source.Poll(ctx, auth.DeviceCode)
+	if !errors.Is(err, grants.ErrDeviceAuthorizationPending) {
+		t.Errorf("Expected error %v, got %v", grants.ErrDeviceAuthorizationPending, err)
+	}
+
+	now.Advance(auth.Interval - time.Second)
+	_, err = source.Poll(ctx, auth.DeviceCode)
+	if !errors.Is(err, grants.ErrDeviceSlowDown) {
+		t.Errorf("Expected error %v, got %v", grants.ErrDeviceSlowDown, err)
+	}
+
+	// the interval has grown, so the original interval is too soon now
+	now.Advance(auth.Interval)
+	_, err = source.Poll(ctx, auth.DeviceCode)
+	if !errors.Is(err, grants.ErrDeviceSlowDown) {
+		t.Errorf("Expected error %v, got %v", grants.ErrDeviceSlowDown, err)
+	}
+
+	now.Advance(auth.Interval + 2*grants.DeviceSlowDownIncrement)
+	_, err = source.Poll(ctx, auth.DeviceCode)
+	if !errors.Is(err, grants.ErrDeviceAuthorizationPending) {
+		t.Errorf("Expected error %v, got %v", grants.ErrDeviceAuthorizationPending, err)
+	}
+}
+
+func TestDeny(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, _ := newSource(t)
+	auth := startOrFail(ctx, t, source)
+
+	err := source.Deny(ctx, auth.UserCode)
+	if err != nil {
+		t.Fatalf("Unexpected error denying: %s", err)
+	}
+	_, err = source.Poll(ctx, auth.DeviceCode)
+	if !errors.Is(err, grants.ErrDeviceAccessDenied) {
+		t.Errorf("Expected error %v, got %v", grants.ErrDeviceAccessDenied, err)
+	}
+	_, err = source.Approve(ctx, auth.UserCode, device.Approval{ProfileID: "user"})
+	if !errors.Is(err, grants.ErrDeviceAuthorizationNotPending) {
+		t.Errorf("Expected error %v, got %v", grants.ErrDeviceAuthorizationNotPending, err)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, now := newSource(t)
+	auth := startOrFail(ctx, t, source)
+
+	now.Advance(10 * time.Minute)
+	_, err := source.Poll(ctx, auth.DeviceCode)
+	if !errors.Is(err, grants.ErrDeviceCodeExpired) {
+		t.Errorf("Expected error %v, got %v", grants.ErrDeviceCodeExpired, err)
+	}
+	_, err = source.Approve(ctx, auth.UserCode, device.Approval{ProfileID: "user"})
+	if !errors.Is(err, grants.ErrDeviceCodeExpired) {
+		t.Errorf("Expected error %v, got %v", grants.ErrDeviceCodeExpired, err)
+	}
+}
+
+func TestPollUnknownDeviceCode(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, _ := newSource(t)
+
+	_, err := source.Poll(ctx, "not a device code")
+	if !errors.Is(err, grants.ErrDeviceAuthorizationNotFound) {
+		t.Errorf("Expected error %v, got %v", grants.ErrDeviceAuthorizationNotFound, err)
+	}
+}
+
+func TestWaitNotified(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, _ := newSource(t)
+	// an interval long enough that the test would time out if Wait
+	// relied on it
+	source.Interval = time.Hour
+	auth := startOrFail(ctx, t, source)
+
+	type result struct {
+		grant grants.Grant
+		err   error
+	}
+	results := make(chan result, 1)
+	go func() {
+		grant, err := source.Wait(ctx, auth.DeviceCode)
+		results <- result{grant: grant, err: err}
+	}()
+
+	// give Wait a chance to start waiting; approving before it does is
+	// also fine, it just doesn't exercise the notification
+	time.Sleep(10 * time.Millisecond)
+	approved, err := source.Approve(ctx, auth.UserCode, device.Approval{ProfileID: "user"})
+	if err != nil {
+		t.Fatalf("Unexpected error approving: %s", err)
+	}
+
+	select {
+	case res := <-results:
+		if res.err != nil {
+			t.Fatalf("Unexpected error waiting: %s", res.err)
+		}
+		if res.grant.ID != approved.ID {
+			t.Errorf("Expected grant %s, got %s", approved.ID, res.grant.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait wasn't notified of the approval")
+	}
+}
+
+func TestWaitFallsBackToInterval(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, _ := newSource(t)
+	source.Interval = 10 * time.Millisecond
+	auth := startOrFail(ctx, t, source)
+
+	// deny through a Source that doesn't share the Notifier, like one in
+	// another process would
+	other := source
+	other.Notifier = nil
+
+	errs := make(chan error, 1)
+	go func() {
+		_, err := source.Wait(ctx, auth.DeviceCode)
+		errs <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	err := other.Deny(ctx, auth.UserCode)
+	if err != nil {
+		t.Fatalf("Unexpected error denying: %s", err)
+	}
+
+	select {
+	case err = <-errs:
+		if !errors.Is(err, grants.ErrDeviceAccessDenied) {
+			t.Errorf("Expected error %v, got %v", grants.ErrDeviceAccessDenied, err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Wait didn't notice the denial")
+	}
+}
+
+func TestWaitCanceled(t *testing.T) {
+	t.Parallel()
+
+	source, _ := newSource(t)
+	source.Interval = time.Hour
+	auth := startOrFail(context.Background(), t, source)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := source.Wait(ctx, auth.DeviceCode)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected error %v, got %v", context.DeadlineExceeded, err)
+	}
+}
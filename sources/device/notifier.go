@@ -0,0 +1,58 @@
+package device
+
+import (
+	"sync"
+)
+
+// Notifier lets a Source waiting on a request know as soon as it has been
+// decided by another Source in the same process. The zero value is ready to
+// use, and a nil Notifier never notifies anything.
+type Notifier struct {
+	waiters map[string][]chan struct{}
+	lock    sync.Mutex
+}
+
+// subscribe returns a channel that is closed the next time notify is called
+// for `id`, and a function that must be called once the channel is no longer
+// needed.
+func (n *Notifier) subscribe(id string) (<-chan struct{}, func()) {
+	if n == nil {
+		return nil, func() {}
+	}
+	ch := make(chan struct{})
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	if n.waiters == nil {
+		n.waiters = map[string][]chan struct{}{}
+	}
+	n.waiters[id] = append(n.waiters[id], ch)
+	return ch, func() {
+		n.lock.Lock()
+		defer n.lock.Unlock()
+		waiters := n.waiters[id]
+		for pos, waiter := range waiters {
+			if waiter == ch {
+				waiters = append(waiters[:pos], waiters[pos+1:]...)
+				break
+			}
+		}
+		if len(waiters) < 1 {
+			delete(n.waiters, id)
+			return
+		}
+		n.waiters[id] = waiters
+	}
+}
+
+// notify wakes everything subscribed to `id`.
+func (n *Notifier) notify(id string) {
+	if n == nil {
+		return
+	}
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	for _, waiter := range n.waiters[id] {
+		close(waiter)
+	}
+	delete(n.waiters, id)
+}
@@ -0,0 +1,85 @@
+package email
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultAttemptWindow is how long failed attempts are remembered by a
+// MemoryAttemptCounter with no Window set.
+const DefaultAttemptWindow = 15 * time.Minute
+
+// AttemptCounter keeps track of failed attempts to redeem login codes, keyed
+// by the IP address they came from.
+type AttemptCounter interface {
+	// Failures returns the number of recent failed attempts from
+	// `key`.
+	Failures(ctx context.Context, key string) (int, error)
+
+	// RecordFailure records a failed attempt from `key`.
+	RecordFailure(ctx context.Context, key string) error
+}
+
+// MemoryAttemptCounter is an in-memory AttemptCounter that forgets failed
+// attempts once they're older than its Window. It's suitable for single
+// instance deployments and tests; deployments with more than one instance
+// need an AttemptCounter they all share. The zero value is ready to be used,
+// and is safe for concurrent use.
+type MemoryAttemptCounter struct {
+	// Window is how long failed attempts are remembered. If zero,
+	// DefaultAttemptWindow is used.
+	Window time.Duration
+
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+
+	failures map[string][]time.Time
+	lock     sync.Mutex
+}
+
+// Failures returns the number of failed attempts from `key` within the
+// MemoryAttemptCounter's Window.
+func (m *MemoryAttemptCounter) Failures(_ context.Context, key string) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return len(m.prune(key)), nil
+}
+
+// RecordFailure records a failed attempt from `key`.
+func (m *MemoryAttemptCounter) RecordFailure(_ context.Context, key string) error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.failures == nil {
+		m.failures = map[string][]time.Time{}
+	}
+	m.failures[key] = append(m.prune(key), m.now())
+	return nil
+}
+
+// prune drops the failed attempts from `key` that are outside the Window,
+// returning the ones that are left. The lock must be held.
+func (m *MemoryAttemptCounter) prune(key string) []time.Time {
+	window := m.Window
+	if window <= 0 {
+		window = DefaultAttemptWindow
+	}
+	cutoff := m.now().Add(-window)
+	failures := m.failures[key]
+	for len(failures) > 0 && !failures[0].After(cutoff) {
+		failures = failures[1:]
+	}
+	if len(failures) < 1 {
+		delete(m.failures, key)
+		return nil
+	}
+	m.failures[key] = failures
+	return failures
+}
+
+func (m *MemoryAttemptCounter) now() time.Time {
+	if m.Now != nil {
+		return m.Now()
+	}
+	return time.Now()
+}
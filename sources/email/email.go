@@ -0,0 +1,212 @@
+// Package email implements email login as a grant source: a high-entropy login
+// code is emailed to the user, and presenting that code exchanges the Grant
+// created for it.
+//
+// The code itself is never stored. Grants created by this package have a
+// SourceType of "email" and a SourceID of the SHA-256 hash of the code, so
+// the code can be matched to its Grant without a database leak revealing
+// usable codes.
+package email
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+const (
+	// SourceType is the SourceType of every Grant created by this
+	// package.
+	SourceType = "email"
+
+	// DefaultTTL is how long a login code can be redeemed for when no TTL
+	// is set on the Source.
+	DefaultTTL = 15 * time.Minute
+
+	// DefaultMaxAttempts is how many failed attempts to redeem a code are
+	// allowed from a single IP address when no MaxAttempts is set on the
+	// Source.
+	DefaultMaxAttempts = 5
+
+	// codeSize is the number of random bytes in a login code, giving
+	// codes 256 bits of entropy.
+	codeSize = 32
+)
+
+var (
+	// ErrInvalidCode is returned when a login code is presented that
+	// doesn't match any Grant, or isn't shaped like a login code at all.
+	ErrInvalidCode = errors.New("invalid login code")
+	// ErrCodeExpired is returned when a login code is presented after its
+	// TTL has passed.
+	ErrCodeExpired = errors.New("login code expired")
+	// ErrTooManyAttempts is returned when a login code is presented from
+	// an IP address that has made too many failed attempts recently.
+	ErrTooManyAttempts = errors.New("too many failed attempts to redeem a login code")
+)
+
+// Request describes the Grant a user is asking an email login for.
+type Request struct {
+	Email     string   // the address to send the login code to
+	ProfileID string   // the profile the Grant is for
+	AccountID string   // the account the Grant is for
+	ClientID  string   // the client requesting access
+	Scopes    []string // the scopes the client is requesting
+	IP        string   // the IP address the request came from
+}
+
+// Source creates Grants for email logins, and redeems their login codes.
+type Source struct {
+	// Storer is where Grants are stored.
+	Storer grants.Storer
+
+	// Mailer delivers login codes.
+	Mailer Mailer
+
+	// Attempts tracks failed attempts to redeem login codes. If nil,
+	// failed attempts aren't limited, which is only appropriate when
+	// something else is rate limiting requests.
+	Attempts AttemptCounter
+
+	// TTL is how long a login code can be redeemed for. If zero,
+	// DefaultTTL is used.
+	TTL time.Duration
+
+	// MaxAttempts is how many failed attempts to redeem a login code are
+	// allowed from a single IP address before further attempts are
+	// rejected. If zero, DefaultMaxAttempts is used.
+	MaxAttempts int
+
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+}
+
+// SourceID returns the SourceID of the Grant created for `code`.
+func SourceID(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
+
+// VerifySource checks that `credential` is shaped like a login code and
+// returns the SourceID of the Grant created for it, so a Source can be
+// registered with a grants.SourceRegistry.
+func (Source) VerifySource(_ context.Context, credential string) (string, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(credential)
+	if err != nil || len(decoded) != codeSize {
+		return "", ErrInvalidCode
+	}
+	return SourceID(credential), nil
+}
+
+// Start creates a Grant for `req` and emails its login code to req.Email. The
+// Grant is returned, but the login code is only ever sent to the user. If the
+// code can't be sent, the Grant is revoked.
+func (s Source) Start(ctx context.Context, req Request) (grants.Grant, error) {
+	random, err := uuid.GenerateRandomBytes(codeSize)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	code := base64.RawURLEncoding.EncodeToString(random)
+	grant, err := grants.FillGrantDefaults(grants.Grant{
+		SourceType: SourceType,
+		SourceID:   SourceID(code),
+		CreatedAt:  s.now(),
+		Scopes:     req.Scopes,
+		AccountID:  req.AccountID,
+		ProfileID:  req.ProfileID,
+		ClientID:   req.ClientID,
+		CreateIP:   req.IP,
+	})
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	err = s.Storer.CreateGrant(ctx, grant)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	err = s.Mailer.SendLoginCode(ctx, Message{
+		To:        req.Email,
+		Code:      code,
+		ExpiresAt: grant.CreatedAt.Add(s.ttl()),
+	})
+	if err != nil {
+		_, revokeErr := s.Storer.RevokeGrant(ctx, grant.ID)
+		if revokeErr != nil {
+			yall.FromContext(ctx).WithField("grant", grant.ID).WithError(revokeErr).Error("error revoking grant after failing to send its login code")
+		}
+		return grants.Grant{}, fmt.Errorf("error sending login code: %w", err)
+	}
+	return grant, nil
+}
+
+// Redeem exchanges the Grant for `code`, presented from `ip`, returning the
+// exchanged Grant.
+//
+// Codes that don't match any Grant return ErrInvalidCode and count as a
+// failed attempt from `ip`; once `ip` has made MaxAttempts failed attempts,
+// ErrTooManyAttempts is returned without checking the code. Codes presented
+// after their TTL return ErrCodeExpired. Errors from exchanging the Grant,
+// like grants.ErrGrantAlreadyUsed, are returned as-is.
+func (s Source) Redeem(ctx context.Context, code, ip string) (grants.Grant, error) {
+	if s.Attempts != nil {
+		failures, err := s.Attempts.Failures(ctx, ip)
+		if err != nil {
+			return grants.Grant{}, err
+		}
+		if failures >= s.maxAttempts() {
+			return grants.Grant{}, ErrTooManyAttempts
+		}
+	}
+	grant, err := s.Storer.GetGrantBySource(ctx, SourceType, SourceID(code))
+	if errors.Is(err, grants.ErrGrantNotFound) {
+		if s.Attempts != nil {
+			err = s.Attempts.RecordFailure(ctx, ip)
+			if err != nil {
+				return grants.Grant{}, err
+			}
+		}
+		return grants.Grant{}, ErrInvalidCode
+	}
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	now := s.now()
+	if !now.Before(grant.CreatedAt.Add(s.ttl())) {
+		return grants.Grant{}, ErrCodeExpired
+	}
+	return s.Storer.ExchangeGrant(ctx, grants.GrantUse{
+		Grant: grant.ID,
+		IP:    ip,
+		Time:  now,
+	})
+}
+
+func (s Source) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s Source) ttl() time.Duration {
+	if s.TTL > 0 {
+		return s.TTL
+	}
+	return DefaultTTL
+}
+
+func (s Source) maxAttempts() int {
+	if s.MaxAttempts > 0 {
+		return s.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
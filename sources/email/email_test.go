@@ -0,0 +1,227 @@
+package email_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/sources/email"
+	"lockbox.dev/grants/storers/memory"
+)
+
+var errMailerDown = errors.New("mailer down")
+
+type clock struct {
+	now  time.Time
+	lock sync.Mutex
+}
+
+func (c *clock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type failingMailer struct{}
+
+func (failingMailer) SendLoginCode(_ context.Context, _ email.Message) error {
+	return errMailerDown
+}
+
+func newSource(t *testing.T) (email.Source, *email.CaptureMailer, *clock) {
+	t.Helper()
+	storer, err := memory.NewStorer()
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+	mailer := &email.CaptureMailer{}
+	now := &clock{now: time.Now().Round(time.Millisecond)}
+	return email.Source{
+		Storer:      storer,
+		Mailer:      mailer,
+		Attempts:    &email.MemoryAttemptCounter{Now: now.Now},
+		TTL:         10 * time.Minute,
+		MaxAttempts: 2,
+		Now:         now.Now,
+	}, mailer, now
+}
+
+func startOrFail(ctx context.Context, t *testing.T, source email.Source, mailer *email.CaptureMailer, address string) (grants.Grant, string) {
+	t.Helper()
+	grant, err := source.Start(ctx, email.Request{
+		Email:     address,
+		ProfileID: "profile",
+		ClientID:  "testrunner",
+		Scopes:    []string{"https://scopes.impractical.co/test"},
+		IP:        "192.168.1.2",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error starting login: %s", err)
+	}
+	msg, ok := mailer.Last(address)
+	if !ok {
+		t.Fatalf("Expected a login code to be sent to %s", address)
+	}
+	return grant, msg.Code
+}
+
+func TestStartAndRedeem(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, mailer, now := newSource(t)
+	grant, code := startOrFail(ctx, t, source, mailer, "test@example.com")
+
+	if grant.SourceType != email.SourceType {
+		t.Errorf("Expected source type %q, got %q", email.SourceType, grant.SourceType)
+	}
+	if grant.SourceID != email.SourceID(code) || grant.SourceID == code {
+		t.Errorf("Expected source ID to be the hash of the code, got %q", grant.SourceID)
+	}
+	if len(code) < 43 {
+		t.Errorf("Expected a high-entropy code, got %q", code)
+	}
+	msgs := mailer.Messages()
+	if len(msgs) != 1 {
+		t.Fatalf("Expected 1 message, got %d", len(msgs))
+	}
+	if !msgs[0].ExpiresAt.Equal(grant.CreatedAt.Add(source.TTL)) {
+		t.Errorf("Expected code to expire at %s, got %s", grant.CreatedAt.Add(source.TTL), msgs[0].ExpiresAt)
+	}
+
+	now.Advance(time.Minute)
+	redeemed, err := source.Redeem(ctx, code, "8.8.8.8")
+	if err != nil {
+		t.Fatalf("Unexpected error redeeming code: %s", err)
+	}
+	if redeemed.ID != grant.ID || !redeemed.Used() || redeemed.UseIP != "8.8.8.8" || !redeemed.UsedAt.Equal(now.Now()) {
+		t.Errorf("Expected grant %s to be used from 8.8.8.8 at %s, got %+v", grant.ID, now.Now(), redeemed)
+	}
+
+	_, err = source.Redeem(ctx, code, "8.8.8.8")
+	if !errors.Is(err, grants.ErrGrantAlreadyUsed) {
+		t.Errorf("Expected error to be %v, got %v", grants.ErrGrantAlreadyUsed, err)
+	}
+}
+
+func TestRedeemExpired(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, mailer, now := newSource(t)
+	grant, code := startOrFail(ctx, t, source, mailer, "test@example.com")
+
+	now.Advance(source.TTL)
+	_, err := source.Redeem(ctx, code, "8.8.8.8")
+	if !errors.Is(err, email.ErrCodeExpired) {
+		t.Errorf("Expected error to be %v, got %v", email.ErrCodeExpired, err)
+	}
+	stored, err := source.Storer.GetGrant(ctx, grant.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant: %s", err)
+	}
+	if stored.Used() {
+		t.Error("Expected expired grant not to be used")
+	}
+}
+
+func TestRedeemTooManyAttempts(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, mailer, now := newSource(t)
+	_, code := startOrFail(ctx, t, source, mailer, "test@example.com")
+
+	for i := 0; i < source.MaxAttempts; i++ {
+		_, err := source.Redeem(ctx, "not-the-code", "10.0.0.1")
+		if !errors.Is(err, email.ErrInvalidCode) {
+			t.Errorf("Expected error to be %v, got %v", email.ErrInvalidCode, err)
+		}
+	}
+	// even the right code is rejected once the limit is hit
+	_, err := source.Redeem(ctx, code, "10.0.0.1")
+	if !errors.Is(err, email.ErrTooManyAttempts) {
+		t.Errorf("Expected error to be %v, got %v", email.ErrTooManyAttempts, err)
+	}
+
+	// the limit only applies to the IP address that hit it, and is
+	// forgotten after the window passes
+	now.Advance(email.DefaultAttemptWindow + time.Second)
+	_, err = source.Redeem(ctx, "not-the-code", "10.0.0.1")
+	if !errors.Is(err, email.ErrInvalidCode) {
+		t.Errorf("Expected error to be %v, got %v", email.ErrInvalidCode, err)
+	}
+}
+
+func TestRedeemOtherIP(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, mailer, _ := newSource(t)
+	_, code := startOrFail(ctx, t, source, mailer, "test@example.com")
+
+	for i := 0; i < source.MaxAttempts; i++ {
+		_, err := source.Redeem(ctx, "not-the-code", "10.0.0.1")
+		if !errors.Is(err, email.ErrInvalidCode) {
+			t.Errorf("Expected error to be %v, got %v", email.ErrInvalidCode, err)
+		}
+	}
+	_, err := source.Redeem(ctx, code, "10.0.0.2")
+	if err != nil {
+		t.Errorf("Unexpected error redeeming code from another IP: %s", err)
+	}
+}
+
+func TestStartMailerFailure(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, _, _ := newSource(t)
+	source.Mailer = failingMailer{}
+	_, err := source.Start(ctx, email.Request{Email: "test@example.com", ProfileID: "profile", ClientID: "testrunner"})
+	if !errors.Is(err, errMailerDown) {
+		t.Fatalf("Expected error to be %v, got %v", errMailerDown, err)
+	}
+	list, err := source.Storer.ListGrantsByProfile(ctx, "profile", "", 10)
+	if err != nil {
+		t.Fatalf("Unexpected error listing grants: %s", err)
+	}
+	if len(list) != 1 || !list[0].Revoked() {
+		t.Errorf("Expected the grant to be revoked, got %+v", list)
+	}
+}
+
+func TestSourceRegistry(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, mailer, _ := newSource(t)
+	var registry grants.SourceRegistry
+	err := registry.Register(email.SourceType, source)
+	if err != nil {
+		t.Fatalf("Unexpected error registering source: %s", err)
+	}
+	source.Storer = grants.WithSourceRegistry(source.Storer, &registry)
+	grant, code := startOrFail(ctx, t, source, mailer, "test@example.com")
+
+	sourceID, err := registry.Verify(ctx, email.SourceType, code)
+	if err != nil {
+		t.Fatalf("Unexpected error verifying code: %s", err)
+	}
+	if sourceID != grant.SourceID {
+		t.Errorf("Expected source ID %q, got %q", grant.SourceID, sourceID)
+	}
+	_, err = registry.Verify(ctx, email.SourceType, "not-the-code")
+	if !errors.Is(err, email.ErrInvalidCode) {
+		t.Errorf("Expected error to be %v, got %v", email.ErrInvalidCode, err)
+	}
+}
@@ -0,0 +1,59 @@
+package email
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Message is a login code being delivered to a user.
+type Message struct {
+	To        string    // the address to deliver the code to
+	Code      string    // the login code
+	ExpiresAt time.Time // when the code can no longer be redeemed
+}
+
+// Mailer delivers login codes to users.
+type Mailer interface {
+	// SendLoginCode delivers `msg`. Implementations are responsible for
+	// turning the code into something the user can act on, like a link.
+	SendLoginCode(ctx context.Context, msg Message) error
+}
+
+// CaptureMailer is a Mailer that keeps every Message it's asked to send
+// instead of sending it, for use in tests. The zero value is ready to be
+// used, and is safe for concurrent use.
+type CaptureMailer struct {
+	messages []Message
+	lock     sync.Mutex
+}
+
+// SendLoginCode records `msg`.
+func (c *CaptureMailer) SendLoginCode(_ context.Context, msg Message) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.messages = append(c.messages, msg)
+	return nil
+}
+
+// Messages returns every Message sent so far, in the order they were sent.
+func (c *CaptureMailer) Messages() []Message {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	res := make([]Message, len(c.messages))
+	copy(res, c.messages)
+	return res
+}
+
+// Last returns the most recent Message sent to `to`, and false if no Message
+// has been sent to `to`.
+func (c *CaptureMailer) Last(to string) (Message, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for pos := len(c.messages) - 1; pos >= 0; pos-- {
+		if c.messages[pos].To == to {
+			return c.messages[pos], true
+		}
+	}
+	return Message{}, false
+}
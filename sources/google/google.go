@@ -0,0 +1,143 @@
+// Package google implements signing in with Google as a grant source: an ID
+// token issued by Google is verified locally against Google's published keys,
+// and a Grant is created for it.
+//
+// Grants created by this package have a SourceType of "google_id" and a
+// SourceID derived from the token's subject and issue time. Each ID token
+// produces exactly one SourceID, so presenting the same token twice returns a
+// grants.ErrGrantSourceAlreadyUsed error instead of creating a second Grant.
+//
+// Google is an OpenID Connect provider, so this package is a preconfigured
+// oidc.Provider; the types it uses are the ones from the oidc package.
+package google
+
+import (
+	"context"
+	"time"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/sources/oidc"
+)
+
+const (
+	// SourceType is the SourceType of every Grant created by this
+	// package.
+	SourceType = "google_id"
+
+	// CertsURL is where Google publishes the JSON Web Key Set its ID
+	// tokens are signed with.
+	CertsURL = "https://www.googleapis.com/oauth2/v3/certs"
+
+	// DefaultLeeway is how much clock drift is allowed when checking the
+	// expiration and issue time of a token when no Leeway is set on the
+	// Verifier.
+	DefaultLeeway = oidc.DefaultLeeway
+
+	// DefaultKeySetTTL is how long an HTTPKeySetFetcher with no TTL set
+	// reuses a key set before fetching it again.
+	DefaultKeySetTTL = oidc.DefaultKeySetTTL
+
+	// DefaultMinRefreshInterval is how long an HTTPKeySetFetcher with no
+	// MinRefreshInterval set waits between refreshes of its key set.
+	DefaultMinRefreshInterval = oidc.DefaultMinRefreshInterval
+)
+
+var (
+	// ErrInvalidToken is returned when an ID token can't be parsed, isn't
+	// signed by a trusted key, or is missing required claims.
+	ErrInvalidToken = oidc.ErrInvalidToken
+	// ErrWrongIssuer is returned when an ID token wasn't issued by
+	// Google.
+	ErrWrongIssuer = oidc.ErrWrongIssuer
+	// ErrWrongAudience is returned when an ID token wasn't issued for one
+	// of the Verifier's audiences.
+	ErrWrongAudience = oidc.ErrWrongAudience
+	// ErrTokenExpired is returned when an ID token has expired.
+	ErrTokenExpired = oidc.ErrTokenExpired
+)
+
+// Issuers are the values Google uses for the iss claim of its ID tokens.
+var Issuers = []string{"https://accounts.google.com", "accounts.google.com"}
+
+type (
+	// Audience is the aud claim of an ID token.
+	Audience = oidc.Audience
+	// Claims are the claims of an ID token used to verify it and create
+	// a Grant.
+	Claims = oidc.Claims
+	// KeySetFetcher supplies the JSON Web Key Set ID tokens are verified
+	// against.
+	KeySetFetcher = oidc.KeySetFetcher
+	// StaticKeySet is a KeySetFetcher that always returns the same key
+	// set.
+	StaticKeySet = oidc.StaticKeySet
+	// HTTPKeySetFetcher is a KeySetFetcher that downloads a key set over
+	// HTTP and caches it.
+	HTTPKeySetFetcher = oidc.HTTPKeySetFetcher
+	// Request describes the Grant a user is signing in with Google for.
+	Request = oidc.Request
+)
+
+// SourceID returns the SourceID of the Grant created for the ID token with
+// `claims`.
+func SourceID(claims Claims) string {
+	return oidc.SourceID(claims)
+}
+
+// Verifier checks ID tokens issued by Google.
+type Verifier struct {
+	// Audiences are the OAuth client IDs tokens may be issued for. At
+	// least one is required.
+	Audiences []string
+
+	// Keys supplies the keys tokens are signed with, usually an
+	// HTTPKeySetFetcher for CertsURL.
+	Keys KeySetFetcher
+
+	// Leeway is how much clock drift is allowed when checking the
+	// expiration and issue time of a token. If zero, DefaultLeeway is
+	// used.
+	Leeway time.Duration
+
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+}
+
+// Provider returns the oidc.Provider for Google, configured by `v`.
+func (v Verifier) Provider() oidc.Provider {
+	return oidc.Provider{
+		SourceType: SourceType,
+		Issuers:    Issuers,
+		Audiences:  v.Audiences,
+		Keys:       v.Keys,
+		Leeway:     v.Leeway,
+		Now:        v.Now,
+	}
+}
+
+// Verify checks the signature of `token` against the Verifier's keys, and that
+// its iss, aud, and exp claims are acceptable, returning its claims. Errors
+// wrap ErrInvalidToken, ErrWrongIssuer, ErrWrongAudience, or ErrTokenExpired.
+func (v Verifier) Verify(ctx context.Context, token string) (Claims, error) {
+	return v.Provider().Verify(ctx, token)
+}
+
+// VerifySource verifies the ID token `credential` and returns the SourceID of
+// the Grant created for it, so a Verifier can be registered with a
+// grants.SourceRegistry.
+func (v Verifier) VerifySource(ctx context.Context, credential string) (string, error) {
+	return v.Provider().VerifySource(ctx, credential)
+}
+
+// Source creates Grants from Google ID tokens.
+type Source struct {
+	Storer   grants.Storer
+	Verifier Verifier
+}
+
+// Login verifies req.Token and creates a Grant for it, returning the Grant.
+// If the token has already been used to create a Grant, a
+// grants.ErrGrantSourceAlreadyUsed error is returned.
+func (s Source) Login(ctx context.Context, req Request) (grants.Grant, error) {
+	return oidc.Source{Storer: s.Storer, Provider: s.Verifier.Provider()}.Login(ctx, req)
+}
@@ -0,0 +1,257 @@
+package google_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/jose"
+	"lockbox.dev/grants/sources/google"
+	"lockbox.dev/grants/storers/memory"
+)
+
+const audience = "test-client.apps.googleusercontent.com"
+
+type signer struct {
+	key crypto.Signer
+	kid string
+	alg string
+	jwk jose.JWK
+}
+
+func newRSASigner(t *testing.T, kid string) signer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating key: %s", err)
+	}
+	return newSigner(t, key, kid, "RS256")
+}
+
+func newSigner(t *testing.T, key crypto.Signer, kid, alg string) signer {
+	t.Helper()
+	jwk, err := jose.NewJWK(key.Public())
+	if err != nil {
+		t.Fatalf("Error creating JWK: %s", err)
+	}
+	jwk.KeyID = kid
+	jwk.Algorithm = alg
+	return signer{key: key, kid: kid, alg: alg, jwk: jwk}
+}
+
+func (s signer) sign(t *testing.T, claims google.Claims) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("Error encoding claims: %s", err)
+	}
+	token, err := jose.Sign(jose.Header{Algorithm: s.alg, Type: "JWT", KeyID: s.kid}, payload, s.key)
+	if err != nil {
+		t.Fatalf("Error signing token: %s", err)
+	}
+	return token
+}
+
+func validClaims(now time.Time) google.Claims {
+	return google.Claims{
+		Issuer:        "https://accounts.google.com",
+		Subject:       "110169484474386276334",
+		Audience:      google.Audience{audience},
+		IssuedAt:      now.Add(-time.Minute).Unix(),
+		Expiry:        now.Add(time.Hour).Unix(),
+		Email:         "test@example.com",
+		EmailVerified: true,
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	key := newRSASigner(t, "current")
+	other := newRSASigner(t, "current")
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Error generating key: %s", err)
+	}
+	ec := newSigner(t, ecKey, "ec", "ES256")
+	verifier := google.Verifier{
+		Audiences: []string{"other-client", audience},
+		Keys:      google.StaticKeySet{Keys: []jose.JWK{key.jwk, ec.jwk}},
+		Now:       func() time.Time { return now },
+	}
+
+	cases := map[string]struct {
+		token    func() string
+		expected error
+	}{
+		"valid":    {token: func() string { return key.sign(t, validClaims(now)) }},
+		"valid-ec": {token: func() string { return ec.sign(t, validClaims(now)) }},
+		"valid-issuer-without-scheme": {token: func() string {
+			claims := validClaims(now)
+			claims.Issuer = "accounts.google.com"
+			return key.sign(t, claims)
+		}},
+		"valid-multiple-audiences": {token: func() string {
+			claims := validClaims(now)
+			claims.Audience = google.Audience{"someone-else", audience}
+			return key.sign(t, claims)
+		}},
+		"wrong-issuer": {expected: google.ErrWrongIssuer, token: func() string {
+			claims := validClaims(now)
+			claims.Issuer = "https://evil.example.com"
+			return key.sign(t, claims)
+		}},
+		"wrong-audience": {expected: google.ErrWrongAudience, token: func() string {
+			claims := validClaims(now)
+			claims.Audience = google.Audience{"someone-else"}
+			return key.sign(t, claims)
+		}},
+		"expired": {expected: google.ErrTokenExpired, token: func() string {
+			claims := validClaims(now)
+			claims.Expiry = now.Add(-2 * google.DefaultLeeway).Unix()
+			return key.sign(t, claims)
+		}},
+		"issued-in-future": {expected: google.ErrInvalidToken, token: func() string {
+			claims := validClaims(now)
+			claims.IssuedAt = now.Add(2 * google.DefaultLeeway).Unix()
+			return key.sign(t, claims)
+		}},
+		"no-subject": {expected: google.ErrInvalidToken, token: func() string {
+			claims := validClaims(now)
+			claims.Subject = ""
+			return key.sign(t, claims)
+		}},
+		"wrong-key": {expected: google.ErrInvalidToken, token: func() string { return other.sign(t, validClaims(now)) }},
+		"unknown-key": {expected: google.ErrInvalidToken, token: func() string {
+			unknown := newRSASigner(t, "unknown")
+			return unknown.sign(t, validClaims(now))
+		}},
+		"algorithm-mismatch": {expected: google.ErrInvalidToken, token: func() string {
+			mismatched := key
+			mismatched.alg = "PS256"
+			return mismatched.sign(t, validClaims(now))
+		}},
+		"malformed": {expected: google.ErrInvalidToken, token: func() string { return "not.a.token" }},
+	}
+
+	for name, testCase := range cases {
+		name, testCase := name, testCase
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			claims, err := verifier.Verify(context.Background(), testCase.token())
+			if testCase.expected == nil {
+				if err != nil {
+					t.Fatalf("Unexpected error verifying token: %s", err)
+				}
+				if claims.Subject != validClaims(now).Subject {
+					t.Errorf("Expected subject %q, got %q", validClaims(now).Subject, claims.Subject)
+				}
+				return
+			}
+			if !errors.Is(err, testCase.expected) {
+				t.Errorf("Expected error to be %v, got %v", testCase.expected, err)
+			}
+		})
+	}
+}
+
+func TestLoginBlocksReplay(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	now := time.Now()
+	key := newRSASigner(t, "current")
+	storer, err := memory.NewStorer()
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+	source := google.Source{
+		Storer: storer,
+		Verifier: google.Verifier{
+			Audiences: []string{audience},
+			Keys:      google.StaticKeySet{Keys: []jose.JWK{key.jwk}},
+		},
+	}
+	token := key.sign(t, validClaims(now))
+	req := google.Request{Token: token, ProfileID: "profile", ClientID: "testrunner", IP: "192.168.1.2"}
+
+	grant, err := source.Login(ctx, req)
+	if err != nil {
+		t.Fatalf("Unexpected error logging in: %s", err)
+	}
+	if grant.SourceType != google.SourceType {
+		t.Errorf("Expected source type %q, got %q", google.SourceType, grant.SourceType)
+	}
+	if grant.SourceID != google.SourceID(validClaims(now)) {
+		t.Errorf("Expected source ID %q, got %q", google.SourceID(validClaims(now)), grant.SourceID)
+	}
+	stored, err := storer.GetGrantBySource(ctx, google.SourceType, grant.SourceID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant: %s", err)
+	}
+	if stored.ID != grant.ID {
+		t.Errorf("Expected grant %s, got %s", grant.ID, stored.ID)
+	}
+
+	_, err = source.Login(ctx, req)
+	if !errors.Is(err, grants.ErrGrantSourceAlreadyUsed) {
+		t.Errorf("Expected error to be %v, got %v", grants.ErrGrantSourceAlreadyUsed, err)
+	}
+
+	// a new token for the same user is a new source
+	later := validClaims(now)
+	later.IssuedAt++
+	req.Token = key.sign(t, later)
+	_, err = source.Login(ctx, req)
+	if err != nil {
+		t.Errorf("Unexpected error logging in with a new token: %s", err)
+	}
+}
+
+func TestHTTPKeySetFetcherRefreshesOnUnknownKey(t *testing.T) {
+	t.Parallel()
+
+	now := time.Now()
+	oldKey := newRSASigner(t, "old")
+	newKey := newRSASigner(t, "new")
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		keys := jose.KeySet{Keys: []jose.JWK{oldKey.jwk}}
+		// the provider rotates its keys after the first request
+		if atomic.AddInt32(&requests, 1) > 1 {
+			keys.Keys = append(keys.Keys, newKey.jwk)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(keys); err != nil {
+			t.Errorf("Error encoding key set: %s", err)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	verifier := google.Verifier{
+		Audiences: []string{audience},
+		Keys:      &google.HTTPKeySetFetcher{URL: server.URL, Client: server.Client()},
+	}
+	for _, key := range []signer{oldKey, oldKey, newKey} {
+		_, err := verifier.Verify(context.Background(), key.sign(t, validClaims(now)))
+		if err != nil {
+			t.Fatalf("Unexpected error verifying token signed by %q: %s", key.kid, err)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("Expected the key set to be fetched twice, got %d", got)
+	}
+}
@@ -0,0 +1,84 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// discoveryPath is where OpenID Connect Discovery 1.0 says a provider's
+// metadata is served, relative to its issuer.
+const discoveryPath = "/.well-known/openid-configuration"
+
+// ErrIssuerMismatch is returned when a provider's discovery document names a
+// different issuer than the one it was fetched for, which OpenID Connect
+// Discovery requires be rejected.
+var ErrIssuerMismatch = errors.New("discovery document issuer doesn't match")
+
+// Metadata is the subset of an OpenID Connect provider's discovery document
+// needed to verify its ID tokens.
+type Metadata struct {
+	Issuer                           string   `json:"issuer"`
+	JWKSURI                          string   `json:"jwks_uri"`
+	AuthorizationEndpoint            string   `json:"authorization_endpoint,omitempty"`
+	TokenEndpoint                    string   `json:"token_endpoint,omitempty"`
+	IDTokenSigningAlgValuesSupported []string `json:"id_token_signing_alg_values_supported,omitempty"`
+}
+
+// Discover fetches the discovery document of the provider identified by
+// `issuer`, using `client`, or a client that times out after DefaultTimeout if
+// `client` is nil.
+func Discover(ctx context.Context, client *http.Client, issuer string) (Metadata, error) {
+	if client == nil {
+		client = defaultClient
+	}
+	target := strings.TrimSuffix(issuer, "/") + discoveryPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return Metadata{}, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // nothing to do about errors closing the body
+	if resp.StatusCode != http.StatusOK {
+		return Metadata{}, fmt.Errorf("unexpected status %d fetching discovery document from %s", resp.StatusCode, target) //nolint:goerr113 // error for display, not handling
+	}
+	var metadata Metadata
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&metadata)
+	if err != nil {
+		return Metadata{}, fmt.Errorf("error decoding discovery document from %s: %w", target, err)
+	}
+	if metadata.Issuer != issuer {
+		return Metadata{}, fmt.Errorf("%w: expected %q, got %q", ErrIssuerMismatch, issuer, metadata.Issuer)
+	}
+	if metadata.JWKSURI == "" {
+		return Metadata{}, fmt.Errorf("discovery document from %s has no jwks_uri", target) //nolint:goerr113 // error for display, not handling
+	}
+	return metadata, nil
+}
+
+// NewProvider returns a Provider for the OpenID Connect provider identified by
+// `issuer`, configured from its discovery document. Tokens must be issued for
+// one of `audiences`, and Grants created from them will have a SourceType of
+// `sourceType`. The provider's keys are fetched from its jwks_uri using
+// `client`, or a client that times out after DefaultTimeout if `client` is
+// nil, and cached.
+func NewProvider(ctx context.Context, client *http.Client, sourceType, issuer string, audiences []string) (Provider, error) {
+	metadata, err := Discover(ctx, client, issuer)
+	if err != nil {
+		return Provider{}, err
+	}
+	provider := Provider{
+		SourceType: sourceType,
+		Issuers:    []string{metadata.Issuer},
+		Audiences:  audiences,
+		Keys:       &HTTPKeySetFetcher{URL: metadata.JWKSURI, Client: client},
+	}
+	return provider, provider.Validate()
+}
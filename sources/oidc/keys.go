@@ -0,0 +1,186 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"lockbox.dev/grants/jose"
+)
+
+const (
+	// DefaultKeySetTTL is how long an HTTPKeySetFetcher with no TTL set
+	// reuses a key set before fetching it again.
+	DefaultKeySetTTL = time.Hour
+
+	// DefaultMinRefreshInterval is how long an HTTPKeySetFetcher with no
+	// MinRefreshInterval set waits between refreshes of its key set.
+	DefaultMinRefreshInterval = time.Minute
+
+	// DefaultTimeout is how long requests made to a provider wait for a
+	// response when no http.Client is supplied.
+	DefaultTimeout = 10 * time.Second
+
+	// maxResponseSize is the most that's read of a key set or discovery
+	// document, so a misbehaving provider can't exhaust our memory.
+	maxResponseSize = 1 << 20
+)
+
+// defaultClient is used to make requests to providers when no http.Client is
+// supplied. Unlike http.DefaultClient, it doesn't wait forever for a response.
+//
+//nolint:gochecknoglobals // shared so connections are reused, never modified
+var defaultClient = &http.Client{Timeout: DefaultTimeout}
+
+// KeySetFetcher supplies the JSON Web Key Set ID tokens are verified against.
+type KeySetFetcher interface {
+	// FetchKeySet returns the current key set. Implementations may cache
+	// the key set, but should return a fresh copy when `refresh` is true,
+	// which is used when a token is signed by a key that isn't in the
+	// cached key set. As anyone can create such a token, implementations
+	// may limit how often they refresh the key set.
+	FetchKeySet(ctx context.Context, refresh bool) (jose.KeySet, error)
+}
+
+// StaticKeySet is a KeySetFetcher that always returns the same key set. It's
+// useful for tests, and for providers whose keys are configured by hand.
+type StaticKeySet jose.KeySet
+
+// FetchKeySet returns `s`.
+func (s StaticKeySet) FetchKeySet(_ context.Context, _ bool) (jose.KeySet, error) {
+	return jose.KeySet(s), nil
+}
+
+// HTTPKeySetFetcher is a KeySetFetcher that downloads a key set over HTTP and
+// caches it. The zero value isn't usable; URL must be set. It's safe for
+// concurrent use, and concurrent calls share a single download.
+type HTTPKeySetFetcher struct {
+	// URL is where the key set is downloaded from.
+	URL string
+
+	// Client is used to download the key set. If nil, a client that
+	// times out after DefaultTimeout is used.
+	Client *http.Client
+
+	// TTL is how long a downloaded key set is used before it's
+	// downloaded again. If zero, DefaultKeySetTTL is used.
+	TTL time.Duration
+
+	// MinRefreshInterval is how long to wait after refreshing the key set
+	// before refreshing it again. Refreshes requested sooner than that
+	// get the cached key set instead. If zero,
+	// DefaultMinRefreshInterval is used.
+	MinRefreshInterval time.Duration
+
+	cached      jose.KeySet
+	fetchedAt   time.Time
+	refreshedAt time.Time
+	download    *keySetDownload
+	lock        sync.Mutex
+}
+
+// keySetDownload is a download of a key set in progress, which callers of
+// FetchKeySet wait on instead of starting their own.
+type keySetDownload struct {
+	done chan struct{}
+	keys jose.KeySet
+	err  error
+}
+
+// FetchKeySet returns the cached key set, downloading it first if it's
+// missing or older than the TTL. If `refresh` is true, the key set is
+// downloaded again unless it was already refreshed less than
+// MinRefreshInterval ago.
+func (h *HTTPKeySetFetcher) FetchKeySet(ctx context.Context, refresh bool) (jose.KeySet, error) {
+	h.lock.Lock()
+	if h.fresh(refresh) {
+		keys := h.cached
+		h.lock.Unlock()
+		return keys, nil
+	}
+	download := h.download
+	if download != nil {
+		// someone else is already downloading the key set, wait
+		// for them to finish
+		h.lock.Unlock()
+		select {
+		case <-download.done:
+			return download.keys, download.err
+		case <-ctx.Done():
+			return jose.KeySet{}, ctx.Err()
+		}
+	}
+	download = &keySetDownload{done: make(chan struct{})}
+	h.download = download
+	if refresh {
+		h.refreshedAt = time.Now()
+	}
+	h.lock.Unlock()
+
+	// don't hold the lock while downloading, so a slow provider doesn't
+	// block callers that can use the cached key set
+	download.keys, download.err = h.get(ctx)
+
+	h.lock.Lock()
+	if download.err == nil {
+		h.cached = download.keys
+		h.fetchedAt = time.Now()
+	}
+	h.download = nil
+	h.lock.Unlock()
+	close(download.done)
+	return download.keys, download.err
+}
+
+// fresh returns whether the cached key set can be used instead of downloading
+// it again. The lock must be held when calling it.
+func (h *HTTPKeySetFetcher) fresh(refresh bool) bool {
+	ttl := h.TTL
+	if ttl <= 0 {
+		ttl = DefaultKeySetTTL
+	}
+	if h.fetchedAt.IsZero() || time.Since(h.fetchedAt) >= ttl {
+		return false
+	}
+	if !refresh {
+		return true
+	}
+	// refreshes are requested for tokens signed by unknown keys, which
+	// anyone can create, so they're limited to stop them from turning
+	// every login attempt into a request to the provider
+	minRefresh := h.MinRefreshInterval
+	if minRefresh <= 0 {
+		minRefresh = DefaultMinRefreshInterval
+	}
+	return time.Since(h.refreshedAt) < minRefresh
+}
+
+// get downloads the key set.
+func (h *HTTPKeySetFetcher) get(ctx context.Context) (jose.KeySet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, h.URL, nil)
+	if err != nil {
+		return jose.KeySet{}, err
+	}
+	client := h.Client
+	if client == nil {
+		client = defaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return jose.KeySet{}, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // nothing to do about errors closing the body
+	if resp.StatusCode != http.StatusOK {
+		return jose.KeySet{}, fmt.Errorf("unexpected status %d fetching key set from %s", resp.StatusCode, h.URL) //nolint:goerr113 // error for display, not handling
+	}
+	var keys jose.KeySet
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxResponseSize)).Decode(&keys)
+	if err != nil {
+		return jose.KeySet{}, fmt.Errorf("error decoding key set from %s: %w", h.URL, err)
+	}
+	return keys, nil
+}
@@ -0,0 +1,281 @@
+// Package oidc implements signing in with any OpenID Connect identity
+// provider, like Okta, Azure AD, or Keycloak, as a grant source: an ID token
+// issued by the provider is verified locally against the provider's published
+// keys, and a Grant is created for it.
+//
+// Each provider is configured as a Provider with its own SourceType, so
+// Grants from different providers never collide. The SourceID of a Grant is
+// derived from the token's subject and issue time, so each ID token produces
+// exactly one SourceID, and presenting the same token twice returns a
+// grants.ErrGrantSourceAlreadyUsed error instead of creating a second Grant.
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/jose"
+)
+
+// DefaultLeeway is how much clock drift is allowed when checking the
+// expiration and issue time of a token when no Leeway is set on the Provider.
+const DefaultLeeway = time.Minute
+
+var (
+	// ErrInvalidToken is returned when an ID token can't be parsed, isn't
+	// signed by a trusted key, or is missing required claims.
+	ErrInvalidToken = errors.New("invalid ID token")
+	// ErrWrongIssuer is returned when an ID token wasn't issued by the
+	// Provider it's being verified for.
+	ErrWrongIssuer = errors.New("ID token has an untrusted issuer")
+	// ErrWrongAudience is returned when an ID token wasn't issued for one
+	// of the Provider's audiences.
+	ErrWrongAudience = errors.New("ID token has an untrusted audience")
+	// ErrTokenExpired is returned when an ID token has expired.
+	ErrTokenExpired = errors.New("ID token expired")
+	// ErrInvalidProvider is returned when a Provider is missing required
+	// configuration.
+	ErrInvalidProvider = errors.New("providers need a source type, an issuer, an audience, and keys")
+)
+
+// Audience is the aud claim of an ID token, which may be encoded as either a
+// single string or an array of strings.
+type Audience []string
+
+// UnmarshalJSON decodes either form of the aud claim.
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+	var multiple []string
+	err := json.Unmarshal(data, &multiple)
+	if err != nil {
+		return err
+	}
+	*a = Audience(multiple)
+	return nil
+}
+
+// Claims are the claims of an ID token used to verify it and create a Grant.
+type Claims struct {
+	Issuer        string   `json:"iss"`
+	Subject       string   `json:"sub"`
+	Audience      Audience `json:"aud"`
+	Expiry        int64    `json:"exp"`
+	IssuedAt      int64    `json:"iat"`
+	Email         string   `json:"email,omitempty"`
+	EmailVerified bool     `json:"email_verified,omitempty"`
+}
+
+// SourceID returns the SourceID of the Grant created for the ID token with
+// `claims`. The subject and issue time identify a token, as providers don't
+// issue two ID tokens for the same subject in the same second.
+func SourceID(claims Claims) string {
+	return claims.Subject + ":" + strconv.FormatInt(claims.IssuedAt, 10)
+}
+
+// Provider is the configuration of an OpenID Connect identity provider, and
+// verifies the ID tokens it issues. Providers can be configured by hand, or
+// from the provider's discovery document using NewProvider.
+type Provider struct {
+	// SourceType is the SourceType of Grants created from this
+	// provider's tokens, like "okta" or "keycloak".
+	SourceType string
+
+	// Issuers are the values the provider uses for the iss claim of its
+	// ID tokens. Most providers only use one.
+	Issuers []string
+
+	// Audiences are the OAuth client IDs tokens may be issued for. At
+	// least one is required.
+	Audiences []string
+
+	// Keys supplies the keys tokens are signed with: usually an
+	// HTTPKeySetFetcher for the provider's jwks_uri, or a StaticKeySet
+	// holding the provider's JWKS document.
+	Keys KeySetFetcher
+
+	// Leeway is how much clock drift is allowed when checking the
+	// expiration and issue time of a token. If zero, DefaultLeeway is
+	// used.
+	Leeway time.Duration
+
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+}
+
+// Validate returns an error wrapping ErrInvalidProvider if `p` is missing any
+// required configuration.
+func (p Provider) Validate() error {
+	if p.SourceType == "" || len(p.Issuers) < 1 || len(p.Audiences) < 1 || p.Keys == nil {
+		return fmt.Errorf("%w: %q", ErrInvalidProvider, p.SourceType)
+	}
+	return nil
+}
+
+// Verify checks the signature of `token` against the Provider's keys, and
+// that its iss, aud, and exp claims are acceptable, returning its claims.
+// Errors wrap ErrInvalidToken, ErrWrongIssuer, ErrWrongAudience, or
+// ErrTokenExpired.
+func (p Provider) Verify(ctx context.Context, token string) (Claims, error) {
+	parsed, err := jose.Parse(token)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %s", ErrInvalidToken, err.Error())
+	}
+	key, err := p.key(ctx, parsed.Header)
+	if err != nil {
+		return Claims{}, err
+	}
+	var claims Claims
+	err = parsed.UnmarshalClaims(key, &claims)
+	if err != nil {
+		return Claims{}, fmt.Errorf("%w: %s", ErrInvalidToken, err.Error())
+	}
+	err = p.checkClaims(claims)
+	if err != nil {
+		return Claims{}, err
+	}
+	return claims, nil
+}
+
+// VerifySource verifies the ID token `credential` and returns the SourceID of
+// the Grant created for it, so a Provider can be registered with a
+// grants.SourceRegistry under its SourceType.
+func (p Provider) VerifySource(ctx context.Context, credential string) (string, error) {
+	claims, err := p.Verify(ctx, credential)
+	if err != nil {
+		return "", err
+	}
+	return SourceID(claims), nil
+}
+
+// key returns the public key `header` says the token was signed with, fetching
+// the key set again if the key isn't in the cached copy, in case the keys have
+// been rotated.
+func (p Provider) key(ctx context.Context, header jose.Header) (crypto.PublicKey, error) {
+	if header.KeyID == "" {
+		return nil, fmt.Errorf("%w: no kid header", ErrInvalidToken)
+	}
+	keys, err := p.Keys.FetchKeySet(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	jwk, err := keys.Key(header.KeyID)
+	if errors.Is(err, jose.ErrKeyNotFound) {
+		keys, err = p.Keys.FetchKeySet(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		jwk, err = keys.Key(header.KeyID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err.Error())
+	}
+	// don't let the token pick an algorithm the key wasn't meant for
+	if jwk.Algorithm != "" && jwk.Algorithm != header.Algorithm {
+		return nil, fmt.Errorf("%w: key %q is for %s, token uses %s", ErrInvalidToken, jwk.KeyID, jwk.Algorithm, header.Algorithm)
+	}
+	key, err := jwk.PublicKey()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidToken, err.Error())
+	}
+	return key, nil
+}
+
+func (p Provider) checkClaims(claims Claims) error {
+	if claims.Subject == "" || claims.IssuedAt == 0 || claims.Expiry == 0 {
+		return fmt.Errorf("%w: sub, iat, and exp claims are required", ErrInvalidToken)
+	}
+	if !contains(p.Issuers, claims.Issuer) {
+		return fmt.Errorf("%w: %q", ErrWrongIssuer, claims.Issuer)
+	}
+	var audienceOK bool
+	for _, aud := range claims.Audience {
+		if contains(p.Audiences, aud) {
+			audienceOK = true
+			break
+		}
+	}
+	if !audienceOK {
+		return fmt.Errorf("%w: %q", ErrWrongAudience, []string(claims.Audience))
+	}
+	now := time.Now()
+	if p.Now != nil {
+		now = p.Now()
+	}
+	leeway := p.Leeway
+	if leeway == 0 {
+		leeway = DefaultLeeway
+	}
+	if !now.Before(time.Unix(claims.Expiry, 0).Add(leeway)) {
+		return fmt.Errorf("%w: at %s", ErrTokenExpired, time.Unix(claims.Expiry, 0))
+	}
+	if time.Unix(claims.IssuedAt, 0).After(now.Add(leeway)) {
+		return fmt.Errorf("%w: issued in the future at %s", ErrInvalidToken, time.Unix(claims.IssuedAt, 0))
+	}
+	return nil
+}
+
+func contains(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Request describes the Grant a user is signing in with a provider for.
+type Request struct {
+	Token     string   // the ID token issued by the provider
+	ProfileID string   // the profile the Grant is for
+	AccountID string   // the account the Grant is for
+	ClientID  string   // the client requesting access
+	Scopes    []string // the scopes the client is requesting
+	IP        string   // the IP address the request came from
+}
+
+// Source creates Grants from the ID tokens of a single Provider.
+type Source struct {
+	Storer   grants.Storer
+	Provider Provider
+}
+
+// Login verifies req.Token and creates a Grant for it with the Provider's
+// SourceType, returning the Grant. If the token has already been used to
+// create a Grant, a grants.ErrGrantSourceAlreadyUsed error is returned.
+func (s Source) Login(ctx context.Context, req Request) (grants.Grant, error) {
+	err := s.Provider.Validate()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	claims, err := s.Provider.Verify(ctx, req.Token)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	grant, err := grants.FillGrantDefaults(grants.Grant{
+		SourceType: s.Provider.SourceType,
+		SourceID:   SourceID(claims),
+		Scopes:     req.Scopes,
+		AccountID:  req.AccountID,
+		ProfileID:  req.ProfileID,
+		ClientID:   req.ClientID,
+		CreateIP:   req.IP,
+	})
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	err = s.Storer.CreateGrant(ctx, grant)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return grant, nil
+}
@@ -0,0 +1,301 @@
+package oidc_test
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/jose"
+	"lockbox.dev/grants/sources/oidc"
+	"lockbox.dev/grants/storers/memory"
+)
+
+// fakeProvider is an OpenID Connect provider serving a discovery document and
+// JWKS over HTTP, that can issue ID tokens.
+type fakeProvider struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+
+	// issuer overrides the issuer in the discovery document, if set
+	issuer string
+}
+
+func newFakeProvider(t *testing.T, kid string) *fakeProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("Error generating key: %s", err)
+	}
+	provider := &fakeProvider{key: key, kid: kid}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		issuer := provider.server.URL
+		if provider.issuer != "" {
+			issuer = provider.issuer
+		}
+		writeJSON(t, w, oidc.Metadata{
+			Issuer:                           issuer,
+			JWKSURI:                          provider.server.URL + "/keys",
+			AuthorizationEndpoint:            provider.server.URL + "/authorize",
+			TokenEndpoint:                    provider.server.URL + "/token",
+			IDTokenSigningAlgValuesSupported: []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, _ *http.Request) {
+		writeJSON(t, w, provider.keySet(t))
+	})
+	provider.server = httptest.NewServer(mux)
+	t.Cleanup(provider.server.Close)
+	return provider
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, body interface{}) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		t.Errorf("Error encoding response: %s", err)
+	}
+}
+
+func (f *fakeProvider) keySet(t *testing.T) jose.KeySet {
+	t.Helper()
+	jwk, err := jose.NewJWK(f.key.Public())
+	if err != nil {
+		t.Fatalf("Error creating JWK: %s", err)
+	}
+	jwk.KeyID = f.kid
+	jwk.Algorithm = "RS256"
+	jwk.Use = "sig"
+	return jose.KeySet{Keys: []jose.JWK{jwk}}
+}
+
+func (f *fakeProvider) claims(audience string) oidc.Claims {
+	now := time.Now()
+	return oidc.Claims{
+		Issuer:   f.server.URL,
+		Subject:  "00u1a2b3c4d5e6f7g8h9",
+		Audience: oidc.Audience{audience},
+		IssuedAt: now.Unix(),
+		Expiry:   now.Add(time.Hour).Unix(),
+	}
+}
+
+func (f *fakeProvider) issue(t *testing.T, claims oidc.Claims) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("Error encoding claims: %s", err)
+	}
+	token, err := jose.Sign(jose.Header{Algorithm: "RS256", Type: "JWT", KeyID: f.kid}, payload, f.key)
+	if err != nil {
+		t.Fatalf("Error signing token: %s", err)
+	}
+	return token
+}
+
+func TestDiscoveredProvider(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	fake := newFakeProvider(t, "okta-key")
+	provider, err := oidc.NewProvider(ctx, fake.server.Client(), "okta", fake.server.URL, []string{"grants-client"})
+	if err != nil {
+		t.Fatalf("Unexpected error discovering provider: %s", err)
+	}
+
+	claims, err := provider.Verify(ctx, fake.issue(t, fake.claims("grants-client")))
+	if err != nil {
+		t.Fatalf("Unexpected error verifying token: %s", err)
+	}
+	if claims.Subject != "00u1a2b3c4d5e6f7g8h9" {
+		t.Errorf("Expected subject %q, got %q", "00u1a2b3c4d5e6f7g8h9", claims.Subject)
+	}
+
+	_, err = provider.Verify(ctx, fake.issue(t, fake.claims("some-other-client")))
+	if !errors.Is(err, oidc.ErrWrongAudience) {
+		t.Errorf("Expected error to be %v, got %v", oidc.ErrWrongAudience, err)
+	}
+	wrongIssuer := fake.claims("grants-client")
+	wrongIssuer.Issuer = "https://evil.example.com"
+	_, err = provider.Verify(ctx, fake.issue(t, wrongIssuer))
+	if !errors.Is(err, oidc.ErrWrongIssuer) {
+		t.Errorf("Expected error to be %v, got %v", oidc.ErrWrongIssuer, err)
+	}
+	expired := fake.claims("grants-client")
+	expired.IssuedAt = time.Now().Add(-2 * time.Hour).Unix()
+	expired.Expiry = time.Now().Add(-time.Hour).Unix()
+	_, err = provider.Verify(ctx, fake.issue(t, expired))
+	if !errors.Is(err, oidc.ErrTokenExpired) {
+		t.Errorf("Expected error to be %v, got %v", oidc.ErrTokenExpired, err)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	t.Parallel()
+
+	fake := newFakeProvider(t, "key")
+	fake.issuer = "https://impostor.example.com"
+	_, err := oidc.NewProvider(context.Background(), fake.server.Client(), "keycloak", fake.server.URL, []string{"grants-client"})
+	if !errors.Is(err, oidc.ErrIssuerMismatch) {
+		t.Errorf("Expected error to be %v, got %v", oidc.ErrIssuerMismatch, err)
+	}
+}
+
+func TestLoginPerProviderSourceTypes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	storer, err := memory.NewStorer()
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+	okta := newFakeProvider(t, "okta-key")
+	keycloak := newFakeProvider(t, "keycloak-key")
+
+	oktaProvider, err := oidc.NewProvider(ctx, okta.server.Client(), "okta", okta.server.URL, []string{"grants-client"})
+	if err != nil {
+		t.Fatalf("Unexpected error discovering provider: %s", err)
+	}
+	// providers can also be configured by hand, with their JWKS document
+	keycloakProvider := oidc.Provider{
+		SourceType: "keycloak",
+		Issuers:    []string{keycloak.server.URL},
+		Audiences:  []string{"grants-client"},
+		Keys:       oidc.StaticKeySet(keycloak.keySet(t)),
+	}
+
+	var registry grants.SourceRegistry
+	for _, provider := range []oidc.Provider{oktaProvider, keycloakProvider} {
+		err = registry.Register(provider.SourceType, provider)
+		if err != nil {
+			t.Fatalf("Unexpected error registering %s: %s", provider.SourceType, err)
+		}
+	}
+	wrapped := grants.WithSourceRegistry(storer, &registry)
+
+	for _, testCase := range []struct {
+		fake     *fakeProvider
+		provider oidc.Provider
+	}{{okta, oktaProvider}, {keycloak, keycloakProvider}} {
+		source := oidc.Source{Storer: wrapped, Provider: testCase.provider}
+		// both providers use the same subject and issue time, but
+		// that's not a collision, because the source types differ
+		claims := testCase.fake.claims("grants-client")
+		claims.IssuedAt = 1700000000
+		req := oidc.Request{Token: testCase.fake.issue(t, claims), ProfileID: "profile", ClientID: "testrunner"}
+		var grant grants.Grant
+		grant, err = source.Login(ctx, req)
+		if err != nil {
+			t.Fatalf("Unexpected error logging in with %s: %s", testCase.provider.SourceType, err)
+		}
+		if grant.SourceType != testCase.provider.SourceType {
+			t.Errorf("Expected source type %q, got %q", testCase.provider.SourceType, grant.SourceType)
+		}
+		var sourceID string
+		sourceID, err = registry.Verify(ctx, testCase.provider.SourceType, req.Token)
+		if err != nil {
+			t.Fatalf("Unexpected error verifying token: %s", err)
+		}
+		if sourceID != grant.SourceID {
+			t.Errorf("Expected source ID %q, got %q", grant.SourceID, sourceID)
+		}
+		_, err = source.Login(ctx, req)
+		if !errors.Is(err, grants.ErrGrantSourceAlreadyUsed) {
+			t.Errorf("Expected error to be %v, got %v", grants.ErrGrantSourceAlreadyUsed, err)
+		}
+	}
+
+	// a token from one provider isn't accepted by another
+	_, err = keycloakProvider.Verify(ctx, okta.issue(t, okta.claims("grants-client")))
+	if !errors.Is(err, oidc.ErrInvalidToken) {
+		t.Errorf("Expected error to be %v, got %v", oidc.ErrInvalidToken, err)
+	}
+}
+
+func TestProviderValidate(t *testing.T) {
+	t.Parallel()
+
+	_, err := oidc.Source{Provider: oidc.Provider{SourceType: "incomplete"}}.Login(context.Background(), oidc.Request{})
+	if !errors.Is(err, oidc.ErrInvalidProvider) {
+		t.Errorf("Expected error to be %v, got %v", oidc.ErrInvalidProvider, err)
+	}
+}
+
+func TestHTTPKeySetFetcherLimitsRefreshes(t *testing.T) {
+	t.Parallel()
+
+	provider := newFakeProvider(t, "key")
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		writeJSON(t, w, provider.keySet(t))
+	}))
+	t.Cleanup(server.Close)
+
+	fetcher := &oidc.HTTPKeySetFetcher{URL: server.URL, Client: server.Client()}
+	ctx := context.Background()
+	// the first refresh is allowed, so rotated keys are picked up right
+	// away, but tokens signed by unknown keys can't force any more
+	for _, refresh := range []bool{false, true, true, true} {
+		_, err := fetcher.FetchKeySet(ctx, refresh)
+		if err != nil {
+			t.Fatalf("Unexpected error fetching key set: %s", err)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 2 {
+		t.Errorf("Expected the key set to be fetched twice, got %d", got)
+	}
+}
+
+func TestHTTPKeySetFetcherSharesDownloads(t *testing.T) {
+	t.Parallel()
+
+	provider := newFakeProvider(t, "key")
+	var requests int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		<-release
+		writeJSON(t, w, provider.keySet(t))
+	}))
+	t.Cleanup(server.Close)
+
+	fetcher := &oidc.HTTPKeySetFetcher{URL: server.URL, Client: server.Client()}
+	var wg sync.WaitGroup
+	errs := make(chan error, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			keys, err := fetcher.FetchKeySet(context.Background(), false)
+			if err == nil && len(keys.Keys) != 1 {
+				err = fmt.Errorf("expected 1 key, got %d", len(keys.Keys)) //nolint:goerr113 // error for display, not handling
+			}
+			errs <- err
+		}()
+	}
+	// give every caller a chance to start waiting on the download
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Unexpected error fetching key set: %s", err)
+		}
+	}
+	if got := atomic.LoadInt32(&requests); got != 1 {
+		t.Errorf("Expected the key set to be fetched once, got %d", got)
+	}
+}
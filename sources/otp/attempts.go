@@ -0,0 +1,51 @@
+package otp
+
+import (
+	"context"
+	"sync"
+)
+
+// AttemptCounter keeps track of wrong codes presented for each Grant.
+type AttemptCounter interface {
+	// RecordFailure records a wrong code presented for the Grant with
+	// the ID `grantID`, returning the number of wrong codes presented
+	// for it so far, including this one.
+	RecordFailure(ctx context.Context, grantID string) (int, error)
+}
+
+// MemoryAttemptCounter is an in-memory AttemptCounter. The zero value is
+// ready to be used, and is safe for concurrent use.
+//
+// MemoryAttemptCounter only counts the wrong codes presented to the process
+// it's in, so it's only suitable for single instance deployments and tests.
+// Deployments with more than one instance need an AttemptCounter they all
+// share, like one backed by their database, or every instance will tolerate
+// MaxAttempts wrong codes of its own.
+//
+// Failures are remembered until Forget is called, so Forget should be called
+// for Grants once they can no longer be redeemed, or periodically for Grants
+// older than the Source's TTL.
+type MemoryAttemptCounter struct {
+	failures map[string]int
+	lock     sync.Mutex
+}
+
+// RecordFailure records a wrong code presented for the Grant with the ID
+// `grantID`.
+func (m *MemoryAttemptCounter) RecordFailure(_ context.Context, grantID string) (int, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.failures == nil {
+		m.failures = map[string]int{}
+	}
+	m.failures[grantID]++
+	return m.failures[grantID], nil
+}
+
+// Forget discards the wrong codes recorded for the Grant with the ID
+// `grantID`.
+func (m *MemoryAttemptCounter) Forget(grantID string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	delete(m.failures, grantID)
+}
@@ -0,0 +1,322 @@
+// Package otp implements one-time passcode login as a grant source: a short
+// numeric code is sent to the user, and presenting that code along with the ID
+// of the Grant it was sent for exchanges the Grant.
+//
+// Short codes can be guessed, so every Grant only tolerates a limited number
+// of wrong codes before it's revoked, and the code itself is never stored.
+// Wrong codes are counted by an AttemptCounter, which must be shared by every
+// instance of a deployment for the limit to hold; MemoryAttemptCounter only
+// counts the wrong codes presented to the instance it's in.
+// Grants created by this package have a SourceType of "otp" and a SourceID of
+// an HMAC of the Grant's ID and code, keyed with a secret, so a database leak
+// doesn't reveal codes that can be recovered by trying every possibility.
+//
+// Grants are created in the grants.GrantStatePending state, so they can't be
+// exchanged with their ID alone; Redeem exchanges them once the right code is
+// presented, in a single step if the Storer implements grants.PendingExchanger.
+// Because the SourceID is compared directly, the Storer used
+// must store SourceIDs as given, so it must not be wrapped with
+// grants.WithHashedSources.
+package otp
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+const (
+	// SourceType is the SourceType of every Grant created by this
+	// package.
+	SourceType = "otp"
+
+	// DefaultDigits is the number of digits in a code when no Digits is
+	// set on the Source.
+	DefaultDigits = 6
+
+	// MinDigits is the fewest digits a code can have.
+	MinDigits = 6
+
+	// MaxDigits is the most digits a code can have.
+	MaxDigits = 8
+
+	// DefaultTTL is how long a code can be redeemed for when no TTL is set
+	// on the Source.
+	DefaultTTL = 10 * time.Minute
+
+	// DefaultMaxAttempts is how many wrong codes a Grant tolerates before
+	// it's revoked when no MaxAttempts is set on the Source.
+	DefaultMaxAttempts = 5
+)
+
+var (
+	// ErrInvalidCode is returned when a code is presented that doesn't
+	// match the Grant it's presented for.
+	ErrInvalidCode = errors.New("invalid one-time passcode")
+	// ErrCodeExpired is returned when a code is presented after its TTL
+	// has passed.
+	ErrCodeExpired = errors.New("one-time passcode expired")
+	// ErrTooManyAttempts is returned when the wrong code has been
+	// presented for a Grant too many times, and it has been revoked.
+	ErrTooManyAttempts = errors.New("too many wrong one-time passcodes, grant revoked")
+	// ErrInvalidDigits is returned when a Source is configured with a
+	// number of Digits outside of MinDigits and MaxDigits.
+	ErrInvalidDigits = errors.New("one-time passcodes must have between 6 and 8 digits")
+	// ErrNoKey is returned when a Source is used without a Key.
+	ErrNoKey = errors.New("a key is required to hash one-time passcodes")
+	// ErrNoAttemptCounter is returned when a Source is used without an
+	// AttemptCounter.
+	ErrNoAttemptCounter = errors.New("an attempt counter is required to limit wrong one-time passcodes")
+)
+
+// Request describes the Grant a user is asking a one-time passcode login for.
+type Request struct {
+	Destination string   // where to send the code, like a phone number
+	ProfileID   string   // the profile the Grant is for
+	AccountID   string   // the account the Grant is for
+	ClientID    string   // the client requesting access
+	Scopes      []string // the scopes the client is requesting
+	IP          string   // the IP address the request came from
+}
+
+// Source creates Grants for one-time passcode logins, and redeems them.
+type Source struct {
+	// Storer is where Grants are stored. It must not be wrapped with
+	// grants.WithHashedSources.
+	Storer grants.Storer
+
+	// Sender delivers codes.
+	Sender Sender
+
+	// Attempts counts wrong codes presented for each Grant. It's
+	// required.
+	//
+	// Every instance of a deployment must share the same count: an
+	// AttemptCounter that only counts the wrong codes presented to one
+	// instance, like MemoryAttemptCounter, lets each instance tolerate
+	// MaxAttempts wrong codes, multiplying how many guesses can be made.
+	Attempts AttemptCounter
+
+	// Key is the secret codes are hashed with. It's required.
+	Key []byte
+
+	// Digits is the number of digits in each code, between MinDigits and
+	// MaxDigits. If zero, DefaultDigits is used.
+	Digits int
+
+	// TTL is how long a code can be redeemed for. If zero, DefaultTTL is
+	// used.
+	TTL time.Duration
+
+	// MaxAttempts is how many wrong codes a Grant tolerates before it's
+	// revoked. If zero, DefaultMaxAttempts is used.
+	MaxAttempts int
+
+	// Now returns the current time. If nil, time.Now is used.
+	Now func() time.Time
+}
+
+// SourceID returns the SourceID of the Grant with the ID `grantID` that was
+// sent `code`, using `key` to hash them.
+func SourceID(key []byte, grantID, code string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(grantID)) //nolint:errcheck // hash writes never return errors
+	mac.Write([]byte{0})       //nolint:errcheck // hash writes never return errors
+	mac.Write([]byte(code))    //nolint:errcheck // hash writes never return errors
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateCode returns a uniformly random code of `digits` digits.
+func generateCode(digits int) (string, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil) //nolint:gomnd // decimal digits
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%0*d", digits, n), nil
+}
+
+// Start creates a pending Grant for `req` and sends its code to
+// req.Destination. The Grant is returned so its ID can be presented with the
+// code later, but the code is only ever sent to the user. If the code can't
+// be sent, the Grant is revoked.
+func (s Source) Start(ctx context.Context, req Request) (grants.Grant, error) {
+	err := s.validate()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	digits, err := s.digits()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	code, err := generateCode(digits)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	grant, err := grants.FillGrantDefaults(grants.Grant{
+		SourceType: SourceType,
+		CreatedAt:  s.now(),
+		Scopes:     req.Scopes,
+		AccountID:  req.AccountID,
+		ProfileID:  req.ProfileID,
+		ClientID:   req.ClientID,
+		CreateIP:   req.IP,
+		State:      grants.GrantStatePending,
+	})
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	grant.SourceID = SourceID(s.Key, grant.ID, code)
+	err = s.Storer.CreateGrant(ctx, grant)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	err = s.Sender.SendCode(ctx, Message{
+		To:        req.Destination,
+		Code:      code,
+		ExpiresAt: grant.CreatedAt.Add(s.ttl()),
+	})
+	if err != nil {
+		_, revokeErr := s.Storer.RevokeGrant(ctx, grant.ID)
+		if revokeErr != nil {
+			yall.FromContext(ctx).WithField("grant", grant.ID).WithError(revokeErr).Error("error revoking grant after failing to send its code")
+		}
+		return grants.Grant{}, fmt.Errorf("error sending one-time passcode: %w", err)
+	}
+	return grant, nil
+}
+
+// Redeem checks `code` against the Grant with the ID `grantID`, and exchanges
+// the Grant from `ip` if it matches, returning the exchanged Grant.
+//
+// Codes that don't match return ErrInvalidCode and count as a wrong attempt
+// for the Grant; once MaxAttempts wrong codes have been presented, the Grant
+// is revoked and ErrTooManyAttempts is returned. Codes presented after their
+// TTL expire the Grant and return ErrCodeExpired. Errors from retrieving or
+// exchanging the Grant, like grants.ErrGrantNotFound or
+// grants.ErrGrantRevoked, are returned as-is.
+//
+// If the Storer implements grants.PendingExchanger, the Grant is exchanged
+// straight from pending. Otherwise, it's made active and then exchanged, and
+// revoked if that exchange fails, so it's never left active.
+func (s Source) Redeem(ctx context.Context, grantID, code, ip string) (grants.Grant, error) {
+	err := s.validate()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	grant, err := s.Storer.GetGrant(ctx, grantID)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	if grant.SourceType != SourceType {
+		return grants.Grant{}, ErrInvalidCode
+	}
+	if grant.State != grants.GrantStatePending {
+		_, err = grant.Transition(grants.GrantStateActive)
+		return grants.Grant{}, err
+	}
+	now := s.now()
+	if !now.Before(grant.CreatedAt.Add(s.ttl())) {
+		_, err = s.Storer.TransitionGrant(ctx, grant.ID, grants.GrantStateExpired)
+		if err != nil {
+			return grants.Grant{}, err
+		}
+		return grants.Grant{}, ErrCodeExpired
+	}
+	expected := SourceID(s.Key, grant.ID, code)
+	if !hmac.Equal([]byte(expected), []byte(grant.SourceID)) {
+		return grants.Grant{}, s.recordFailure(ctx, grant.ID)
+	}
+	use := grants.GrantUse{
+		Grant: grant.ID,
+		IP:    ip,
+		Time:  now,
+	}
+	if pending, ok := s.Storer.(grants.PendingExchanger); ok {
+		return pending.ExchangePendingGrant(ctx, use)
+	}
+	_, err = s.Storer.TransitionGrant(ctx, grant.ID, grants.GrantStateActive)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	exchanged, err := s.Storer.ExchangeGrant(ctx, use)
+	if err != nil {
+		_, revokeErr := s.Storer.RevokeGrant(ctx, grant.ID)
+		if revokeErr != nil {
+			yall.FromContext(ctx).WithField("grant", grant.ID).WithError(revokeErr).Error("error revoking grant after failing to exchange it")
+		}
+		return grants.Grant{}, err
+	}
+	return exchanged, nil
+}
+
+// recordFailure counts a wrong code for the Grant with the ID `grantID`,
+// revoking the Grant if it has had too many. It returns the error Redeem
+// should return.
+func (s Source) recordFailure(ctx context.Context, grantID string) error {
+	failures, err := s.Attempts.RecordFailure(ctx, grantID)
+	if err != nil {
+		return err
+	}
+	if failures < s.maxAttempts() {
+		return ErrInvalidCode
+	}
+	_, err = s.Storer.RevokeGrant(ctx, grantID)
+	if err != nil && !errors.Is(err, grants.ErrGrantRevoked) {
+		return err
+	}
+	return ErrTooManyAttempts
+}
+
+// validate returns an error if `s` is missing something it can't work
+// without.
+func (s Source) validate() error {
+	if len(s.Key) < 1 {
+		return ErrNoKey
+	}
+	if s.Attempts == nil {
+		return ErrNoAttemptCounter
+	}
+	return nil
+}
+
+func (s Source) now() time.Time {
+	if s.Now != nil {
+		return s.Now()
+	}
+	return time.Now()
+}
+
+func (s Source) digits() (int, error) {
+	if s.Digits == 0 {
+		return DefaultDigits, nil
+	}
+	if s.Digits < MinDigits || s.Digits > MaxDigits {
+		return 0, ErrInvalidDigits
+	}
+	return s.Digits, nil
+}
+
+func (s Source) ttl() time.Duration {
+	if s.TTL > 0 {
+		return s.TTL
+	}
+	return DefaultTTL
+}
+
+func (s Source) maxAttempts() int {
+	if s.MaxAttempts > 0 {
+		return s.MaxAttempts
+	}
+	return DefaultMaxAttempts
+}
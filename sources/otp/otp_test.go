@@ -0,0 +1,325 @@
+package otp_test
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"sync"
+	"testing"
+	"time"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/sources/otp"
+	"lockbox.dev/grants/storers/memory"
+)
+
+var (
+	errSenderDown   = errors.New("sender down")
+	errExchangeDown = errors.New("exchange down")
+)
+
+type clock struct {
+	now  time.Time
+	lock sync.Mutex
+}
+
+func (c *clock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
+
+type failingSender struct{}
+
+func (failingSender) SendCode(_ context.Context, _ otp.Message) error {
+	return errSenderDown
+}
+
+// failingExchanger is a grants.Storer that can't exchange Grants. It doesn't
+// implement grants.PendingExchanger, so Redeem activates Grants before
+// exchanging them.
+type failingExchanger struct {
+	grants.Storer
+}
+
+func (failingExchanger) ExchangeGrant(_ context.Context, _ grants.GrantUse) (grants.Grant, error) {
+	return grants.Grant{}, errExchangeDown
+}
+
+func newSource(t *testing.T) (otp.Source, *otp.CaptureSender, *clock) {
+	t.Helper()
+	storer, err := memory.NewStorer()
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+	sender := &otp.CaptureSender{}
+	now := &clock{now: time.Now().Round(time.Millisecond)}
+	return otp.Source{
+		Storer:      storer,
+		Sender:      sender,
+		Attempts:    &otp.MemoryAttemptCounter{},
+		Key:         []byte("test key"),
+		TTL:         10 * time.Minute,
+		MaxAttempts: 3,
+		Now:         now.Now,
+	}, sender, now
+}
+
+func startOrFail(ctx context.Context, t *testing.T, source otp.Source, sender *otp.CaptureSender, destination string) (grants.Grant, string) {
+	t.Helper()
+	grant, err := source.Start(ctx, otp.Request{
+		Destination: destination,
+		ProfileID:   "user",
+		ClientID:    "testrunner",
+		Scopes:      []string{"https://scopes.impractical.co/test"},
+		IP:          "1.2.3.4",
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error starting login: %s", err)
+	}
+	msg, ok := sender.Last(destination)
+	if !ok {
+		t.Fatalf("No code sent to %s", destination)
+	}
+	return grant, msg.Code
+}
+
+// wrongCode returns a code of the same length as `code` that doesn't match
+// it.
+func wrongCode(code string) string {
+	if code[0] == '0' {
+		return "1" + code[1:]
+	}
+	return "0" + code[1:]
+}
+
+func TestStartAndRedeem(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, sender, _ := newSource(t)
+	grant, code := startOrFail(ctx, t, source, sender, "+15555550100")
+
+	if !regexp.MustCompile(`^[0-9]{6}$`).MatchString(code) {
+		t.Errorf("Expected a 6 digit code, got %q", code)
+	}
+	if grant.State != grants.GrantStatePending {
+		t.Errorf("Expected grant to be %q, got %q", grants.GrantStatePending, grant.State)
+	}
+	if grant.SourceType != otp.SourceType || grant.SourceID != otp.SourceID(source.Key, grant.ID, code) {
+		t.Errorf("Unexpected source %q/%q", grant.SourceType, grant.SourceID)
+	}
+
+	// the grant can't be exchanged without the code
+	_, err := source.Storer.ExchangeGrant(ctx, grants.GrantUse{Grant: grant.ID, IP: "8.8.8.8", Time: time.Now()})
+	if !errors.Is(err, grants.ErrGrantPending) {
+		t.Errorf("Expected error %v, got %v", grants.ErrGrantPending, err)
+	}
+
+	redeemed, err := source.Redeem(ctx, grant.ID, code, "8.8.8.8")
+	if err != nil {
+		t.Fatalf("Unexpected error redeeming code: %s", err)
+	}
+	if redeemed.ID != grant.ID || !redeemed.Used() || redeemed.UseIP != "8.8.8.8" {
+		t.Errorf("Unexpected redeemed grant %+v", redeemed)
+	}
+
+	_, err = source.Redeem(ctx, grant.ID, code, "8.8.8.8")
+	if !errors.Is(err, grants.ErrGrantAlreadyUsed) {
+		t.Errorf("Expected error %v, got %v", grants.ErrGrantAlreadyUsed, err)
+	}
+}
+
+func TestDigits(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, sender, _ := newSource(t)
+	source.Digits = 8
+	_, code := startOrFail(ctx, t, source, sender, "+15555550100")
+	if !regexp.MustCompile(`^[0-9]{8}$`).MatchString(code) {
+		t.Errorf("Expected an 8 digit code, got %q", code)
+	}
+
+	for _, digits := range []int{5, 9} {
+		source.Digits = digits
+		_, err := source.Start(ctx, otp.Request{Destination: "+15555550100"})
+		if !errors.Is(err, otp.ErrInvalidDigits) {
+			t.Errorf("Expected error %v for %d digits, got %v", otp.ErrInvalidDigits, digits, err)
+		}
+	}
+}
+
+func TestLockout(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, sender, _ := newSource(t)
+	grant, code := startOrFail(ctx, t, source, sender, "+15555550100")
+
+	for i := 0; i < source.MaxAttempts-1; i++ {
+		_, err := source.Redeem(ctx, grant.ID, wrongCode(code), "8.8.8.8")
+		if !errors.Is(err, otp.ErrInvalidCode) {
+			t.Fatalf("Expected error %v on attempt %d, got %v", otp.ErrInvalidCode, i+1, err)
+		}
+	}
+	_, err := source.Redeem(ctx, grant.ID, wrongCode(code), "8.8.8.8")
+	if !errors.Is(err, otp.ErrTooManyAttempts) {
+		t.Fatalf("Expected error %v, got %v", otp.ErrTooManyAttempts, err)
+	}
+
+	stored, err := source.Storer.GetGrant(ctx, grant.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant: %s", err)
+	}
+	if !stored.Revoked() {
+		t.Errorf("Expected grant to be revoked, got %q", stored.State)
+	}
+
+	// the right code doesn't help once the grant is revoked
+	_, err = source.Redeem(ctx, grant.ID, code, "8.8.8.8")
+	if !errors.Is(err, grants.ErrGrantRevoked) {
+		t.Errorf("Expected error %v, got %v", grants.ErrGrantRevoked, err)
+	}
+}
+
+func TestNoAttemptCounter(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, sender, _ := newSource(t)
+	grant, code := startOrFail(ctx, t, source, sender, "+15555550100")
+
+	// without a counter, wrong codes could be guessed forever
+	source.Attempts = nil
+	_, err := source.Start(ctx, otp.Request{Destination: "+15555550100"})
+	if !errors.Is(err, otp.ErrNoAttemptCounter) {
+		t.Errorf("Expected error %v starting login, got %v", otp.ErrNoAttemptCounter, err)
+	}
+	_, err = source.Redeem(ctx, grant.ID, code, "8.8.8.8")
+	if !errors.Is(err, otp.ErrNoAttemptCounter) {
+		t.Errorf("Expected error %v redeeming code, got %v", otp.ErrNoAttemptCounter, err)
+	}
+}
+
+func TestWrongCodeThenRight(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, sender, _ := newSource(t)
+	grant, code := startOrFail(ctx, t, source, sender, "+15555550100")
+
+	_, err := source.Redeem(ctx, grant.ID, wrongCode(code), "8.8.8.8")
+	if !errors.Is(err, otp.ErrInvalidCode) {
+		t.Fatalf("Expected error %v, got %v", otp.ErrInvalidCode, err)
+	}
+	_, err = source.Redeem(ctx, grant.ID, code, "8.8.8.8")
+	if err != nil {
+		t.Errorf("Unexpected error redeeming code: %s", err)
+	}
+}
+
+func TestCodeForAnotherGrant(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, sender, _ := newSource(t)
+	first, _ := startOrFail(ctx, t, source, sender, "+15555550100")
+	_, code := startOrFail(ctx, t, source, sender, "+15555550101")
+
+	_, err := source.Redeem(ctx, first.ID, code, "8.8.8.8")
+	// the codes could coincide, in which case the first grant is
+	// legitimately redeemed
+	if err != nil && !errors.Is(err, otp.ErrInvalidCode) {
+		t.Errorf("Expected error %v, got %v", otp.ErrInvalidCode, err)
+	}
+}
+
+func TestExpired(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, sender, now := newSource(t)
+	grant, code := startOrFail(ctx, t, source, sender, "+15555550100")
+
+	now.Advance(10 * time.Minute)
+	_, err := source.Redeem(ctx, grant.ID, code, "8.8.8.8")
+	if !errors.Is(err, otp.ErrCodeExpired) {
+		t.Fatalf("Expected error %v, got %v", otp.ErrCodeExpired, err)
+	}
+	stored, err := source.Storer.GetGrant(ctx, grant.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant: %s", err)
+	}
+	if stored.State != grants.GrantStateExpired {
+		t.Errorf("Expected grant to be %q, got %q", grants.GrantStateExpired, stored.State)
+	}
+}
+
+func TestSendFailureRevokes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, _, _ := newSource(t)
+	source.Sender = failingSender{}
+
+	_, err := source.Start(ctx, otp.Request{Destination: "+15555550100", ProfileID: "user"})
+	if !errors.Is(err, errSenderDown) {
+		t.Fatalf("Expected error %v, got %v", errSenderDown, err)
+	}
+	list, err := source.Storer.ListGrantsByProfile(ctx, "user", "", 10)
+	if err != nil {
+		t.Fatalf("Unexpected error listing grants: %s", err)
+	}
+	if len(list) != 1 || !list[0].Revoked() {
+		t.Errorf("Expected a single revoked grant, got %+v", list)
+	}
+}
+
+func TestRedeemOtherSource(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, _, _ := newSource(t)
+	grant, err := grants.FillGrantDefaults(grants.Grant{SourceType: "manual", SourceID: "manual", ProfileID: "user"})
+	if err != nil {
+		t.Fatalf("Unexpected error filling grant defaults: %s", err)
+	}
+	err = source.Storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant: %s", err)
+	}
+	_, err = source.Redeem(ctx, grant.ID, "123456", "8.8.8.8")
+	if !errors.Is(err, otp.ErrInvalidCode) {
+		t.Errorf("Expected error %v, got %v", otp.ErrInvalidCode, err)
+	}
+}
+
+func TestExchangeFailureRevokes(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	source, sender, _ := newSource(t)
+	source.Storer = failingExchanger{Storer: source.Storer}
+	grant, code := startOrFail(ctx, t, source, sender, "+15555550100")
+
+	_, err := source.Redeem(ctx, grant.ID, code, "8.8.8.8")
+	if !errors.Is(err, errExchangeDown) {
+		t.Fatalf("Expected error %v, got %v", errExchangeDown, err)
+	}
+	// the grant was activated before the exchange failed, and mustn't be
+	// left exchangeable with its ID alone
+	stored, err := source.Storer.GetGrant(ctx, grant.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant: %s", err)
+	}
+	if stored.State != grants.GrantStateRevoked {
+		t.Errorf("Expected grant to be %q, got %q", grants.GrantStateRevoked, stored.State)
+	}
+}
@@ -0,0 +1,49 @@
+package otp
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Message is a one-time passcode being delivered to a user.
+type Message struct {
+	To        string    // where to deliver the code, like a phone number
+	Code      string    // the one-time passcode
+	ExpiresAt time.Time // when the code can no longer be redeemed
+}
+
+// Sender delivers one-time passcodes to users, for example by SMS.
+type Sender interface {
+	// SendCode delivers `msg`.
+	SendCode(ctx context.Context, msg Message) error
+}
+
+// CaptureSender is a Sender that keeps every Message it's asked to send
+// instead of sending it, for use in tests. The zero value is ready to be
+// used, and is safe for concurrent use.
+type CaptureSender struct {
+	messages []Message
+	lock     sync.Mutex
+}
+
+// SendCode records `msg`.
+func (c *CaptureSender) SendCode(_ context.Context, msg Message) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.messages = append(c.messages, msg)
+	return nil
+}
+
+// Last returns the most recent Message sent to `to`, and false if no Message
+// has been sent to `to`.
+func (c *CaptureSender) Last(to string) (Message, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for pos := len(c.messages) - 1; pos >= 0; pos-- {
+		if c.messages[pos].To == to {
+			return c.messages[pos], true
+		}
+	}
+	return Message{}, false
+}
@@ -0,0 +1,143 @@
+package grants
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrGrantPending is returned when a grant is being used, but is
+	// still waiting on something, like the user approving it, before it
+	// can be exchanged.
+	ErrGrantPending = errors.New("grant is pending, cannot be exchanged yet")
+	// ErrGrantExpired is returned when a grant is being used, but expired
+	// before it was exchanged.
+	ErrGrantExpired = errors.New("grant expired, cannot be exchanged")
+	// ErrGrantDenied is returned when a grant is being used, but the user
+	// denied it.
+	ErrGrantDenied = errors.New("grant was denied, cannot be exchanged")
+	// ErrInvalidGrantState is returned when a grant is being stored or
+	// moved to a GrantState that isn't defined, or is being created in a
+	// GrantState other than GrantStatePending or GrantStateActive. This
+	// usually indicates a programming error.
+	ErrInvalidGrantState = errors.New("invalid grant state")
+	// ErrInvalidGrantTransition is returned when a grant is being moved
+	// to a GrantState it can't reach from its current GrantState, and no
+	// more specific error applies.
+	ErrInvalidGrantTransition = errors.New("grant can't move to that state")
+)
+
+// GrantState is where a Grant is in its lifecycle. Grants start out
+// GrantStatePending or GrantStateActive, and can only move between states as
+// described by CanTransitionTo; GrantStateUsed, GrantStateRevoked,
+// GrantStateExpired, and GrantStateDenied are final.
+type GrantState string
+
+const (
+	// GrantStatePending is the state of a Grant waiting on something,
+	// like the user approving it, before it can be exchanged. It can
+	// become GrantStateActive, GrantStateRevoked, GrantStateExpired, or
+	// GrantStateDenied.
+	GrantStatePending GrantState = "pending"
+	// GrantStateActive is the state of a Grant that can be exchanged. It
+	// can become GrantStateUsed, GrantStateRevoked, or GrantStateExpired.
+	GrantStateActive GrantState = "active"
+	// GrantStateUsed is the state of a Grant that has been exchanged.
+	GrantStateUsed GrantState = "used"
+	// GrantStateRevoked is the state of a Grant that has been revoked.
+	GrantStateRevoked GrantState = "revoked"
+	// GrantStateExpired is the state of a Grant that expired before it
+	// was exchanged.
+	GrantStateExpired GrantState = "expired"
+	// GrantStateDenied is the state of a pending Grant the user denied.
+	GrantStateDenied GrantState = "denied"
+)
+
+//nolint:gochecknoglobals // a lookup table, never modified
+var grantTransitions = map[GrantState][]GrantState{
+	GrantStatePending: {GrantStateActive, GrantStateRevoked, GrantStateExpired, GrantStateDenied},
+	GrantStateActive:  {GrantStateUsed, GrantStateRevoked, GrantStateExpired},
+}
+
+// Valid returns true if `s` is one of the GrantStates defined by this package.
+func (s GrantState) Valid() bool {
+	switch s {
+	case GrantStatePending, GrantStateActive, GrantStateUsed, GrantStateRevoked, GrantStateExpired, GrantStateDenied:
+		return true
+	}
+	return false
+}
+
+// CanTransitionTo returns true if a Grant in state `s` can move to `next`.
+func (s GrantState) CanTransitionTo(next GrantState) bool {
+	for _, candidate := range grantTransitions[s] {
+		if candidate == next {
+			return true
+		}
+	}
+	return false
+}
+
+// Predecessors returns every GrantState that can move to `s`.
+func (s GrantState) Predecessors() []GrantState {
+	var res []GrantState
+	for _, from := range []GrantState{GrantStatePending, GrantStateActive} {
+		if from.CanTransitionTo(s) {
+			res = append(res, from)
+		}
+	}
+	return res
+}
+
+// Err returns the error explaining why a Grant in state `s` can't be
+// exchanged, or nil if it can be.
+func (s GrantState) Err() error {
+	switch s {
+	case GrantStateActive:
+		return nil
+	case GrantStatePending:
+		return ErrGrantPending
+	case GrantStateUsed:
+		return ErrGrantAlreadyUsed
+	case GrantStateRevoked:
+		return ErrGrantRevoked
+	case GrantStateExpired:
+		return ErrGrantExpired
+	case GrantStateDenied:
+		return ErrGrantDenied
+	}
+	return fmt.Errorf("%w: %q", ErrInvalidGrantState, s)
+}
+
+// Transition returns a copy of `g` moved to `next`. If `g` can't move to
+// `next`, the error explaining why its current state can't be left is
+// returned, like ErrGrantAlreadyUsed or ErrGrantRevoked, or
+// ErrInvalidGrantTransition if there's no more specific error.
+func (g Grant) Transition(next GrantState) (Grant, error) {
+	if !next.Valid() {
+		return Grant{}, fmt.Errorf("%w: %q", ErrInvalidGrantState, next)
+	}
+	if g.State.CanTransitionTo(next) {
+		g.State = next
+		return g, nil
+	}
+	if err := g.State.Err(); err != nil {
+		return Grant{}, err
+	}
+	return Grant{}, fmt.Errorf("%w: %s to %s", ErrInvalidGrantTransition, g.State, next)
+}
+
+// ResolveState returns `grant` with its State set to the state it should be
+// created in: GrantStateActive if it's unset. An ErrInvalidGrantState error
+// is returned if `grant` has any State other than GrantStatePending or
+// GrantStateActive, as Grants can't be created in a final state.
+func ResolveState(grant Grant) (Grant, error) {
+	switch grant.State {
+	case "":
+		grant.State = GrantStateActive
+	case GrantStatePending, GrantStateActive:
+	default:
+		return Grant{}, fmt.Errorf("%w: grants can't be created %s", ErrInvalidGrantState, grant.State)
+	}
+	return grant, nil
+}
@@ -0,0 +1,66 @@
+package grants_test
+
+import (
+	"errors"
+	"testing"
+
+	"lockbox.dev/grants"
+)
+
+func TestGrantTransition(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]struct {
+		from grants.GrantState
+		to   grants.GrantState
+		err  error
+	}{
+		"pending-active":  {from: grants.GrantStatePending, to: grants.GrantStateActive},
+		"pending-denied":  {from: grants.GrantStatePending, to: grants.GrantStateDenied},
+		"pending-expired": {from: grants.GrantStatePending, to: grants.GrantStateExpired},
+		"pending-revoked": {from: grants.GrantStatePending, to: grants.GrantStateRevoked},
+		"pending-used":    {from: grants.GrantStatePending, to: grants.GrantStateUsed, err: grants.ErrGrantPending},
+		"active-used":     {from: grants.GrantStateActive, to: grants.GrantStateUsed},
+		"active-revoked":  {from: grants.GrantStateActive, to: grants.GrantStateRevoked},
+		"active-expired":  {from: grants.GrantStateActive, to: grants.GrantStateExpired},
+		"active-denied":   {from: grants.GrantStateActive, to: grants.GrantStateDenied, err: grants.ErrInvalidGrantTransition},
+		"active-pending":  {from: grants.GrantStateActive, to: grants.GrantStatePending, err: grants.ErrInvalidGrantTransition},
+		"used-used":       {from: grants.GrantStateUsed, to: grants.GrantStateUsed, err: grants.ErrGrantAlreadyUsed},
+		"used-revoked":    {from: grants.GrantStateUsed, to: grants.GrantStateRevoked, err: grants.ErrGrantAlreadyUsed},
+		"revoked-used":    {from: grants.GrantStateRevoked, to: grants.GrantStateUsed, err: grants.ErrGrantRevoked},
+		"revoked-revoked": {from: grants.GrantStateRevoked, to: grants.GrantStateRevoked, err: grants.ErrGrantRevoked},
+		"expired-used":    {from: grants.GrantStateExpired, to: grants.GrantStateUsed, err: grants.ErrGrantExpired},
+		"denied-active":   {from: grants.GrantStateDenied, to: grants.GrantStateActive, err: grants.ErrGrantDenied},
+		"unknown-target":  {from: grants.GrantStateActive, to: "lost", err: grants.ErrInvalidGrantState},
+		"unknown-source":  {from: "lost", to: grants.GrantStateUsed, err: grants.ErrInvalidGrantState},
+	}
+
+	for name, test := range cases {
+		name, test := name, test
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			res, err := grants.Grant{ID: "test", State: test.from}.Transition(test.to)
+			if !errors.Is(err, test.err) {
+				t.Fatalf("Expected error %v, got %v", test.err, err)
+			}
+			if err == nil && res.State != test.to {
+				t.Errorf("Expected state %q, got %q", test.to, res.State)
+			}
+		})
+	}
+}
+
+func TestGrantStateAccessors(t *testing.T) {
+	t.Parallel()
+
+	for _, state := range []grants.GrantState{grants.GrantStatePending, grants.GrantStateActive, grants.GrantStateUsed, grants.GrantStateRevoked, grants.GrantStateExpired, grants.GrantStateDenied} {
+		grant := grants.Grant{State: state}
+		if grant.Used() != (state == grants.GrantStateUsed) {
+			t.Errorf("Expected Used() to be %v for %q", state == grants.GrantStateUsed, state)
+		}
+		if grant.Revoked() != (state == grants.GrantStateRevoked) {
+			t.Errorf("Expected Revoked() to be %v for %q", state == grants.GrantStateRevoked, state)
+		}
+	}
+}
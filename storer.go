@@ -5,10 +5,43 @@ import (
 )
 
 // Storer is the interface that Grants are persisted and used through.
+//
+// Every method is scoped to the tenant returned by TenantFromContext: Grants
+// belonging to other tenants are never returned or modified, and behave as if
+// they don't exist. CreateGrant stores Grants under the tenant returned by
+// ResolveTenant, in the state returned by ResolveState.
+//
+// Every change to the State of a Grant is enforced to follow the transitions
+// described by GrantState: ExchangeGrant moves Grants from GrantStateActive to
+// GrantStateUsed, and RevokeGrant moves them to GrantStateRevoked.
 type Storer interface {
 	CreateGrant(ctx context.Context, g Grant) error
 	ExchangeGrant(ctx context.Context, g GrantUse) (Grant, error)
 	RevokeGrant(ctx context.Context, id string) (Grant, error)
 	GetGrant(ctx context.Context, id string) (Grant, error)
 	GetGrantBySource(ctx context.Context, sourceType, sourceID string) (Grant, error)
+
+	// TransitionGrant moves the Grant identified by `id` to `state`,
+	// returning the updated Grant, or the error Grant.Transition returns
+	// if it can't make that move.
+	TransitionGrant(ctx context.Context, id string, state GrantState) (Grant, error)
+
+	// RotateGrant exchanges the Grant identified by `use` and creates
+	// `next` as its child, with AncestorIDs set as described by ChildOf,
+	// in a single atomic operation, returning the created Grant. This is
+	// how refresh is modeled: either the old Grant is used and the new
+	// one exists, or neither happened.
+	RotateGrant(ctx context.Context, use GrantUse, next Grant) (Grant, error)
+
+	// ListGrantsByProfile returns up to `limit` Grants for `profileID`,
+	// ordered by ID, starting after the Grant with the ID `after`. Pass
+	// an empty `after` to start from the beginning, and the ID of the
+	// last Grant returned to get the next page. If `limit` is less than
+	// 1, no Grants are returned.
+	ListGrantsByProfile(ctx context.Context, profileID, after string, limit int) ([]Grant, error)
+
+	// AnonymizeProfile scrubs the personal data from every Grant for
+	// `profileID`, as described by Anonymizer, in a single atomic
+	// operation.
+	AnonymizeProfile(ctx context.Context, profileID string) error
 }
@@ -0,0 +1,507 @@
+package bolt
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"lockbox.dev/grants"
+)
+
+var (
+	// grantsBucket holds every Grant, encoded as JSON, keyed by ID.
+	// IDs are unique across every tenant.
+	grantsBucket = []byte("grants")
+
+	// sourcesBucket maps the tenant, SourceType, and SourceID of each
+	// Grant to its ID, keeping sources unique within a tenant.
+	sourcesBucket = []byte("sources")
+
+	// ancestorsBucket has a key for every ancestor of every Grant,
+	// made of the tenant, the ancestor's ID, and the Grant's ID, so the
+	// descendants of a Grant can be found with a prefix scan.
+	ancestorsBucket = []byte("ancestors")
+
+	// profilesBucket has a key for every Grant, made of the tenant, the
+	// ProfileID, and the Grant's ID, so the Grants for a profile can be
+	// found, ordered by ID, with a prefix scan.
+	profilesBucket = []byte("profiles")
+
+	buckets = [][]byte{grantsBucket, sourcesBucket, ancestorsBucket, profilesBucket}
+)
+
+// Storer is an implementation of the Storer interface backed by bbolt, an
+// embedded key-value store that persists Grants to a single file.
+//
+// Every change is made in a single bbolt read-write transaction, which bbolt
+// only runs one of at a time and syncs to disk before it returns, so
+// concurrent exchanges and revocations of the same Grant see each other's
+// changes, and either take effect completely or not at all.
+type Storer struct {
+	db               *bbolt.DB
+	anonymizationKey []byte
+}
+
+// Option configures optional behavior of a Storer.
+type Option func(*Storer)
+
+// WithAnonymizationKey configures a Storer to derive the pseudonyms
+// AnonymizeProfile replaces personal data with from `key`, as described by
+// grants.Anonymizer. The key should be stored apart from the database file.
+func WithAnonymizationKey(key []byte) Option {
+	return func(s *Storer) {
+		s.anonymizationKey = key
+	}
+}
+
+// NewStorer returns a Storer that keeps its Grants in `db`, creating the
+// buckets it needs if they don't exist yet. The caller is responsible for
+// closing `db` when it's done with the Storer.
+func NewStorer(db *bbolt.DB, opts ...Option) (*Storer, error) {
+	err := db.Update(func(tx *bbolt.Tx) error {
+		for _, bucket := range buckets {
+			_, err := tx.CreateBucketIfNotExists(bucket)
+			if err != nil {
+				return fmt.Errorf("error creating bucket %s: %w", bucket, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	storer := &Storer{
+		db: db,
+	}
+	for _, opt := range opts {
+		opt(storer)
+	}
+	return storer, nil
+}
+
+// indexKey joins `parts` into a key for one of the index buckets. The parts
+// are separated by a NUL byte, so a prefix of whole parts never matches a
+// longer value of the last part.
+func indexKey(parts ...string) []byte {
+	return []byte(strings.Join(parts, "\x00"))
+}
+
+// indexPrefix returns the prefix every indexKey starting with `parts` has.
+func indexPrefix(parts ...string) []byte {
+	return append(indexKey(parts...), 0)
+}
+
+func sourceKey(grant Grant) []byte {
+	return indexKey(grant.TenantID, grant.SourceType, grant.SourceID)
+}
+
+func profileKey(grant Grant) []byte {
+	return indexKey(grant.TenantID, grant.ProfileID, grant.ID)
+}
+
+// scanIndex returns the last part of every key in `bucket` that starts with
+// `prefix`, in order, skipping any that sort before or equal to `after`.
+// It stops once it has `limit` results, if `limit` is greater than 0.
+func scanIndex(tx *bbolt.Tx, bucket, prefix []byte, after string, limit int) []string {
+	var res []string
+	cursor := tx.Bucket(bucket).Cursor()
+	start := prefix
+	if after != "" {
+		start = append(append([]byte{}, prefix...), after...)
+	}
+	for key, _ := cursor.Seek(start); key != nil && bytes.HasPrefix(key, prefix); key, _ = cursor.Next() {
+		id := string(key[len(prefix):])
+		if id <= after {
+			continue
+		}
+		res = append(res, id)
+		if limit > 0 && len(res) >= limit {
+			break
+		}
+	}
+	return res
+}
+
+// getByID returns the Grant with an ID of `id`, regardless of its tenant, or
+// an ErrGrantNotFound error if there is none.
+func getByID(tx *bbolt.Tx, id string) (Grant, error) {
+	raw := tx.Bucket(grantsBucket).Get([]byte(id))
+	if raw == nil {
+		return Grant{}, grants.ErrGrantNotFound
+	}
+	grant, err := decodeGrant(raw)
+	if err != nil {
+		return Grant{}, fmt.Errorf("error decoding grant %s: %w", id, err)
+	}
+	return grant, nil
+}
+
+// getForTenant returns the Grant with an ID of `id` belonging to the tenant
+// `ctx` is scoped to, or an ErrGrantNotFound error if there is none. Grants
+// belonging to other tenants are treated as if they don't exist.
+func getForTenant(ctx context.Context, tx *bbolt.Tx, id string) (Grant, error) {
+	grant, err := getByID(tx, id)
+	if err != nil {
+		return Grant{}, err
+	}
+	if grant.TenantID != grants.TenantFromContext(ctx) {
+		return Grant{}, grants.ErrGrantNotFound
+	}
+	return grant, nil
+}
+
+// putGrant stores `grant`, updating the indexes that point to it. `previous`
+// is the version of `grant` being replaced, or an empty Grant if `grant` is
+// new, and is used to clean up the index entries that no longer apply. The
+// ancestors of a Grant never change, so they're only indexed for new Grants.
+func putGrant(tx *bbolt.Tx, previous, grant Grant) error {
+	encoded, err := grant.encode()
+	if err != nil {
+		return fmt.Errorf("error encoding grant %s: %w", grant.ID, err)
+	}
+	err = tx.Bucket(grantsBucket).Put([]byte(grant.ID), encoded)
+	if err != nil {
+		return err
+	}
+	sources := tx.Bucket(sourcesBucket)
+	profiles := tx.Bucket(profilesBucket)
+	if previous.ID != "" {
+		if !bytes.Equal(sourceKey(previous), sourceKey(grant)) {
+			err = sources.Delete(sourceKey(previous))
+			if err != nil {
+				return err
+			}
+		}
+		if !bytes.Equal(profileKey(previous), profileKey(grant)) {
+			err = profiles.Delete(profileKey(previous))
+			if err != nil {
+				return err
+			}
+		}
+	}
+	err = sources.Put(sourceKey(grant), []byte(grant.ID))
+	if err != nil {
+		return err
+	}
+	err = profiles.Put(profileKey(grant), nil)
+	if err != nil {
+		return err
+	}
+	if previous.ID != "" {
+		return nil
+	}
+	ancestors := tx.Bucket(ancestorsBucket)
+	for _, ancestor := range grant.AncestorIDs {
+		err = ancestors.Put(indexKey(grant.TenantID, ancestor, grant.ID), nil)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CreateGrant inserts the passed Grant into the Storer,
+// returning an ErrGrantAlreadyExists error if a Grant
+// with the same ID already exists in the Storer, or an
+// ErrGrantSourceAlreadyUsed error if a Grant with the
+// same SourceType and SourceID already exists in the Storer
+// for the same tenant.
+func (s *Storer) CreateGrant(ctx context.Context, grant grants.Grant) error {
+	grant, err := grants.ResolveTenant(ctx, grant)
+	if err != nil {
+		return err
+	}
+	grant, err = grants.ResolveState(grant)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		return createGrant(tx, grant)
+	})
+}
+
+// createGrant inserts `grant` using `tx`, after checking that its ID and
+// source haven't been used yet.
+func createGrant(tx *bbolt.Tx, grant grants.Grant) error {
+	record := toBolt(grant)
+	// IDs are unique across every tenant, so check for them without
+	// regard to the tenant
+	if tx.Bucket(grantsBucket).Get([]byte(record.ID)) != nil {
+		return grants.ErrGrantAlreadyExists
+	}
+	if tx.Bucket(sourcesBucket).Get(sourceKey(record)) != nil {
+		return grants.ErrGrantSourceAlreadyUsed
+	}
+	return putGrant(tx, Grant{}, record)
+}
+
+// ExchangeGrant applies the GrantUse to the Storer, marking
+// the Grant in the Storer with an ID matching the Grant
+// property of the GrantUse as used and recording metadata
+// about the IP and time the Grant was used. If no Grant
+// has an ID matching the Grant property of the GrantUse,
+// an ErrGrantNotFound error is returned. If the Grant in
+// the Storer with an ID matching the Grant property of the
+// GrantUse is already marked as used, an ErrGrantAlreadyUsed
+// error will be returned.
+func (s *Storer) ExchangeGrant(ctx context.Context, use grants.GrantUse) (grants.Grant, error) {
+	var res grants.Grant
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		res, err = exchangeGrant(ctx, tx, use, grants.GrantStateActive)
+		return err
+	})
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return res, nil
+}
+
+// ExchangePendingGrant applies the GrantUse to the Storer like ExchangeGrant
+// does, but to a Grant that's still pending, moving it through
+// grants.GrantStateActive to grants.GrantStateUsed in a single transaction. If
+// the Grant isn't pending, the error returned by grants.Grant.ExchangePending
+// is returned.
+func (s *Storer) ExchangePendingGrant(ctx context.Context, use grants.GrantUse) (grants.Grant, error) {
+	var res grants.Grant
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		var err error
+		res, err = exchangeGrant(ctx, tx, use, grants.GrantStatePending)
+		return err
+	})
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return res, nil
+}
+
+// exchangeGrant applies `use` using `tx` to a Grant in the `from` state,
+// which must be grants.GrantStateActive or grants.GrantStatePending,
+// returning the exchanged Grant.
+func exchangeGrant(ctx context.Context, tx *bbolt.Tx, use grants.GrantUse, from grants.GrantState) (grants.Grant, error) {
+	found, err := getForTenant(ctx, tx, use.Grant)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	var newGrant grants.Grant
+	if from == grants.GrantStatePending {
+		newGrant, err = fromBolt(found).ExchangePending()
+	} else {
+		newGrant, err = fromBolt(found).Transition(grants.GrantStateUsed)
+	}
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	newGrant.UseIP = use.IP
+	newGrant.UsedAt = use.Time
+
+	// the UseIP is new, so it needs to be truncated by the next run of
+	// the retention job if the grant is old enough
+	err = putGrant(tx, found, toBolt(newGrant))
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return newGrant, nil
+}
+
+// RotateGrant exchanges the Grant identified by `use` and creates `next` as
+// its child in a single transaction, returning the created Grant. The
+// AncestorIDs of `next` are set to the AncestorIDs of the exchanged Grant,
+// followed by its ID. If either step fails, neither takes effect.
+func (s *Storer) RotateGrant(ctx context.Context, use grants.GrantUse, next grants.Grant) (grants.Grant, error) {
+	next, err := grants.ResolveTenant(ctx, next)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	next, err = grants.ResolveState(next)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	// the tenant may have been set on `next` instead of the context, and
+	// the parent has to be found in the same one
+	ctx = grants.WithTenant(ctx, next.TenantID)
+	err = s.db.Update(func(tx *bbolt.Tx) error {
+		exchanged, err := exchangeGrant(ctx, tx, use, grants.GrantStateActive)
+		if err != nil {
+			return err
+		}
+		next, err = grants.ChildOf(exchanged, next)
+		if err != nil {
+			return err
+		}
+		return createGrant(tx, next)
+	})
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return next, nil
+}
+
+// GetGrant retrieves the Grant specified by `id` from the
+// Storer. If no Grant has an ID matching the `id` parameter,
+// an ErrGrantNotFound error is returned.
+func (s *Storer) GetGrant(ctx context.Context, id string) (grants.Grant, error) {
+	var res Grant
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		var err error
+		res, err = getForTenant(ctx, tx, id)
+		return err
+	})
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return fromBolt(res), nil
+}
+
+// GetGrantBySource retrieves the Grant specified by `sourceType` and
+// `sourceID` from the Storer. If no Grant has a source type and source ID
+// matching these parameters, an ErrGrantNotFound error is returned.
+func (s *Storer) GetGrantBySource(ctx context.Context, sourceType, sourceID string) (grants.Grant, error) {
+	var res Grant
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		id := tx.Bucket(sourcesBucket).Get(indexKey(grants.TenantFromContext(ctx), sourceType, sourceID))
+		if id == nil {
+			return grants.ErrGrantNotFound
+		}
+		var err error
+		res, err = getByID(tx, string(id))
+		return err
+	})
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return fromBolt(res), nil
+}
+
+// RevokeGrant marks the Grant specified by `id` as revoked, meaning it can no
+// longer be exchanged. If no Grant matches the specified ID, an
+// ErrGrantNotFound error is returned. If the Grant matching the ID is already
+// marked as revoked in the Storer, an ErrGrantRevoked error is returned. If
+// the Grant matching the ID is already marked as used in the Storer, an
+// ErrGrantAlreadyUsed error is returned.
+func (s *Storer) RevokeGrant(ctx context.Context, id string) (grants.Grant, error) {
+	return s.TransitionGrant(ctx, id, grants.GrantStateRevoked)
+}
+
+// TransitionGrant moves the Grant specified by `id` to `state`, returning the
+// updated Grant. If no Grant matches the specified ID, an ErrGrantNotFound
+// error is returned. If the Grant can't move to `state`, the error returned by
+// grants.Grant.Transition is returned.
+func (s *Storer) TransitionGrant(ctx context.Context, id string, state grants.GrantState) (grants.Grant, error) {
+	var res grants.Grant
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		found, err := getForTenant(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		res, err = fromBolt(found).Transition(state)
+		if err != nil {
+			return err
+		}
+		updated := found
+		updated.State = string(res.State)
+		return putGrant(tx, found, updated)
+	})
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return res, nil
+}
+
+// AnonymizeProfile scrubs the personal data from every Grant with a ProfileID
+// matching `profileID`, as described by grants.Anonymizer. Either every
+// matching Grant is anonymized, or none are. If the Storer wasn't configured
+// with WithAnonymizationKey, a grants.ErrNoAnonymizationKey error is returned.
+func (s *Storer) AnonymizeProfile(ctx context.Context, profileID string) error {
+	anonymizer, err := grants.NewAnonymizer(s.anonymizationKey)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx *bbolt.Tx) error {
+		// collect the matches before modifying anything, as modifying
+		// a bucket while iterating over it isn't safe
+		ids := scanIndex(tx, profilesBucket, indexPrefix(grants.TenantFromContext(ctx), profileID), "", 0)
+		for _, id := range ids {
+			found, err := getByID(tx, id)
+			if err != nil {
+				return err
+			}
+			anonymized := toBolt(anonymizer.Anonymize(fromBolt(found)))
+			anonymized.IPsTruncated = found.IPsTruncated
+			err = putGrant(tx, found, anonymized)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListGrantsByProfile returns up to `limit` Grants with a ProfileID matching
+// `profileID`, ordered by ID, starting after the Grant with the ID `after`.
+func (s *Storer) ListGrantsByProfile(ctx context.Context, profileID, after string, limit int) ([]grants.Grant, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+	var res []grants.Grant
+	err := s.db.View(func(tx *bbolt.Tx) error {
+		ids := scanIndex(tx, profilesBucket, indexPrefix(grants.TenantFromContext(ctx), profileID), after, limit)
+		for _, id := range ids {
+			found, err := getByID(tx, id)
+			if err != nil {
+				return err
+			}
+			res = append(res, fromBolt(found))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// TruncateIPs applies `policy` to the CreateIP and UseIP of up to `limit`
+// Grants created before `createdBefore` whose IP addresses haven't been
+// truncated yet, returning the number of Grants updated. Each batch is
+// updated in a single transaction. Retention applies to the Grants of every
+// tenant, regardless of the tenant `ctx` is scoped to.
+func (s *Storer) TruncateIPs(_ context.Context, createdBefore time.Time, policy grants.IPRetentionPolicy, limit int) (int, error) {
+	var updated int
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		// collect the matches before modifying anything, as modifying
+		// a bucket while iterating over it isn't safe
+		var matches []Grant
+		cursor := tx.Bucket(grantsBucket).Cursor()
+		for key, value := cursor.First(); key != nil && len(matches) < limit; key, value = cursor.Next() {
+			found, err := decodeGrant(value)
+			if err != nil {
+				return fmt.Errorf("error decoding grant %s: %w", key, err)
+			}
+			if found.IPsTruncated || !found.CreatedAt.Before(createdBefore) {
+				continue
+			}
+			matches = append(matches, found)
+		}
+		for _, match := range matches {
+			truncated := match
+			truncated.CreateIP = policy.Truncate(match.CreateIP)
+			truncated.UseIP = policy.Truncate(match.UseIP)
+			truncated.IPsTruncated = true
+			err := putGrant(tx, match, truncated)
+			if err != nil {
+				return err
+			}
+		}
+		updated = len(matches)
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return updated, nil
+}
@@ -0,0 +1,104 @@
+package bolt
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	bbolt "go.etcd.io/bbolt"
+
+	"lockbox.dev/grants"
+)
+
+func TestPersistence(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "grants.db")
+	open := func() (*bbolt.DB, *Storer) {
+		t.Helper()
+		db, err := bbolt.Open(path, 0o600, nil) //nolint:gomnd // file permissions, not magic
+		if err != nil {
+			t.Fatalf("Error opening database: %s", err)
+		}
+		storer, err := NewStorer(db)
+		if err != nil {
+			t.Fatalf("Error creating storer: %s", err)
+		}
+		return db, storer
+	}
+	ctx := context.Background()
+
+	db, storer := open()
+	parent := grants.Grant{
+		ID:          "parent",
+		SourceType:  "manual",
+		SourceID:    "TestPersistence",
+		AncestorIDs: []string{},
+		CreatedAt:   time.Now().Round(time.Millisecond),
+		Scopes:      []string{"https://scopes.impractical.co/test"},
+		ProfileID:   "tester",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(ctx, parent)
+	if err != nil {
+		t.Fatalf("Error creating grant: %s", err)
+	}
+	child, err := storer.RotateGrant(ctx, grants.GrantUse{Grant: parent.ID, IP: "8.8.8.8", Time: time.Now().Round(time.Millisecond)}, grants.Grant{
+		ID:         "child",
+		SourceType: "refresh_token",
+		SourceID:   "TestPersistence-child",
+		ProfileID:  "tester",
+		ClientID:   "testrunner",
+		State:      grants.GrantStateActive,
+	})
+	if err != nil {
+		t.Fatalf("Error rotating grant: %s", err)
+	}
+	used, err := storer.GetGrant(ctx, parent.ID)
+	if err != nil {
+		t.Fatalf("Error retrieving grant: %s", err)
+	}
+	err = db.Close()
+	if err != nil {
+		t.Fatalf("Error closing database: %s", err)
+	}
+
+	// everything is still there after reopening the file, indexes
+	// included
+	db, storer = open()
+	t.Cleanup(func() {
+		if closeErr := db.Close(); closeErr != nil {
+			t.Errorf("Error closing database: %s", closeErr)
+		}
+	})
+	resp, err := storer.GetGrantBySource(ctx, parent.SourceType, parent.SourceID)
+	if err != nil {
+		t.Fatalf("Error retrieving grant by source: %s", err)
+	}
+	if diff := cmp.Diff(used, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+	_, err = storer.ExchangeGrant(ctx, grants.GrantUse{Grant: parent.ID, IP: "8.8.8.8", Time: time.Now().Round(time.Millisecond)})
+	if !errors.Is(err, grants.ErrGrantAlreadyUsed) {
+		t.Errorf("Expected error to be %v, got %v", grants.ErrGrantAlreadyUsed, err)
+	}
+	list, err := storer.ListGrantsByProfile(ctx, "tester", "", 10) //nolint:gomnd // more than we need
+	if err != nil {
+		t.Fatalf("Error listing grants: %s", err)
+	}
+	if diff := cmp.Diff([]grants.Grant{child, used}, list); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+	revoked, err := storer.RevokeGrantFamily(ctx, parent.ID)
+	if err != nil {
+		t.Fatalf("Error revoking grant family: %s", err)
+	}
+	if diff := cmp.Diff([]string{child.ID}, revoked); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+}
@@ -0,0 +1,61 @@
+package bolt
+
+import (
+	"context"
+	"sort"
+
+	bbolt "go.etcd.io/bbolt"
+
+	"lockbox.dev/grants"
+)
+
+// RevokeGrantFamily revokes the Grant specified by `id`, its ancestors, and
+// every Grant descended from any of them in a single transaction, returning
+// the IDs of the Grants it revoked. Grants that can't be revoked are skipped.
+// If no Grant has an ID matching `id`, an ErrGrantNotFound error is returned.
+func (s *Storer) RevokeGrantFamily(ctx context.Context, id string) ([]string, error) {
+	tenantID := grants.TenantFromContext(ctx)
+	var res []string
+	err := s.db.Update(func(tx *bbolt.Tx) error {
+		found, err := getForTenant(ctx, tx, id)
+		if err != nil {
+			return err
+		}
+		roots := append([]string{found.ID}, found.AncestorIDs...)
+
+		// the family is the grants themselves, and anything descended
+		// from them
+		family := map[string]struct{}{}
+		for _, root := range roots {
+			family[root] = struct{}{}
+			for _, descendant := range scanIndex(tx, ancestorsBucket, indexPrefix(tenantID, root), "", 0) {
+				family[descendant] = struct{}{}
+			}
+		}
+
+		for member := range family {
+			candidate, err := getForTenant(ctx, tx, member)
+			if err != nil {
+				// ancestors may have been created in a
+				// different tenant, or not at all
+				continue
+			}
+			if !grants.GrantState(candidate.State).CanTransitionTo(grants.GrantStateRevoked) {
+				continue
+			}
+			revoked := candidate
+			revoked.State = string(grants.GrantStateRevoked)
+			err = putGrant(tx, candidate, revoked)
+			if err != nil {
+				return err
+			}
+			res = append(res, candidate.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(res)
+	return res, nil
+}
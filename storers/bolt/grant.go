@@ -0,0 +1,79 @@
+package bolt
+
+import (
+	"encoding/json"
+	"time"
+
+	"lockbox.dev/grants"
+)
+
+// Grant is a representation of a Grant
+// suitable for storage in our Storer.
+type Grant struct {
+	ID            string    `json:"id"`
+	TenantID      string    `json:"tenant_id"`
+	SourceType    string    `json:"source_type"`
+	SourceID      string    `json:"source_id"`
+	AncestorIDs   []string  `json:"ancestor_ids"`
+	CreatedAt     time.Time `json:"created_at"`
+	UsedAt        time.Time `json:"used_at"`
+	Scopes        []string  `json:"scopes"`
+	AccountID     string    `json:"account_id"`
+	ProfileID     string    `json:"profile_id"`
+	ClientID      string    `json:"client_id"`
+	CreateIP      string    `json:"create_ip"`
+	UseIP         string    `json:"use_ip"`
+	KeyThumbprint string    `json:"key_thumbprint"`
+	State         string    `json:"state"`
+	IPsTruncated  bool      `json:"ips_truncated"`
+}
+
+func decodeGrant(raw []byte) (Grant, error) {
+	var grant Grant
+	err := json.Unmarshal(raw, &grant)
+	return grant, err
+}
+
+func (g Grant) encode() ([]byte, error) {
+	return json.Marshal(g)
+}
+
+func fromBolt(grant Grant) grants.Grant {
+	return grants.Grant{
+		ID:            grant.ID,
+		TenantID:      grant.TenantID,
+		SourceType:    grant.SourceType,
+		SourceID:      grant.SourceID,
+		AncestorIDs:   grant.AncestorIDs,
+		CreatedAt:     grant.CreatedAt,
+		UsedAt:        grant.UsedAt,
+		Scopes:        grant.Scopes,
+		AccountID:     grant.AccountID,
+		ProfileID:     grant.ProfileID,
+		ClientID:      grant.ClientID,
+		CreateIP:      grant.CreateIP,
+		UseIP:         grant.UseIP,
+		KeyThumbprint: grant.KeyThumbprint,
+		State:         grants.GrantState(grant.State),
+	}
+}
+
+func toBolt(grant grants.Grant) Grant {
+	return Grant{
+		ID:            grant.ID,
+		TenantID:      grant.TenantID,
+		SourceType:    grant.SourceType,
+		SourceID:      grant.SourceID,
+		AncestorIDs:   grant.AncestorIDs,
+		CreatedAt:     grant.CreatedAt,
+		UsedAt:        grant.UsedAt,
+		Scopes:        grant.Scopes,
+		AccountID:     grant.AccountID,
+		ProfileID:     grant.ProfileID,
+		ClientID:      grant.ClientID,
+		CreateIP:      grant.CreateIP,
+		UseIP:         grant.UseIP,
+		KeyThumbprint: grant.KeyThumbprint,
+		State:         string(grant.State),
+	}
+}
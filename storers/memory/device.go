@@ -0,0 +1,178 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	memdb "github.com/hashicorp/go-memdb"
+
+	"lockbox.dev/grants"
+)
+
+// getDeviceAuthorization returns the DeviceAuthorization matching `args` in
+// `index`, or an ErrDeviceAuthorizationNotFound error if there is none.
+// DeviceAuthorizations belonging to tenants other than the one `ctx` is scoped
+// to are treated as if they don't exist.
+func getDeviceAuthorization(ctx context.Context, txn *memdb.Txn, index string, args ...interface{}) (*grants.DeviceAuthorization, error) {
+	auth, err := txn.First("device_authorization", index, args...)
+	if err != nil {
+		return nil, err
+	}
+	if auth == nil {
+		return nil, grants.ErrDeviceAuthorizationNotFound
+	}
+	found, ok := auth.(*grants.DeviceAuthorization)
+	if !ok || found == nil {
+		return nil, fmt.Errorf("unexpected result type %T", auth) //nolint:goerr113 // error for logging, not handling
+	}
+	if found.TenantID != grants.TenantFromContext(ctx) {
+		return nil, grants.ErrDeviceAuthorizationNotFound
+	}
+	return found, nil
+}
+
+// CreateDeviceAuthorization inserts `auth` into the Storer, returning an
+// ErrDeviceAuthorizationAlreadyExists error if a DeviceAuthorization with the
+// same ID, or the same UserCode in the same tenant, already exists.
+func (s *Storer) CreateDeviceAuthorization(ctx context.Context, auth grants.DeviceAuthorization) error {
+	tenantID, err := grants.ResolveTenantID(ctx, auth.TenantID)
+	if err != nil {
+		return err
+	}
+	auth.TenantID = tenantID
+
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	exists, err := txn.First("device_authorization", "id", auth.ID)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return grants.ErrDeviceAuthorizationAlreadyExists
+	}
+	exists, err = txn.First("device_authorization", "user_code", auth.TenantID, auth.UserCode)
+	if err != nil {
+		return err
+	}
+	if exists != nil {
+		return grants.ErrDeviceAuthorizationAlreadyExists
+	}
+	err = txn.Insert("device_authorization", &auth)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// GetDeviceAuthorization retrieves the DeviceAuthorization with the ID `id`
+// from the Storer, returning an ErrDeviceAuthorizationNotFound error if there
+// is none.
+func (s *Storer) GetDeviceAuthorization(ctx context.Context, id string) (grants.DeviceAuthorization, error) {
+	txn := s.db.Txn(false)
+	defer txn.Abort()
+
+	auth, err := getDeviceAuthorization(ctx, txn, "id", id)
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	return *auth, nil
+}
+
+// GetDeviceAuthorizationByUserCode retrieves the DeviceAuthorization with the
+// UserCode `userCode` from the Storer, returning an
+// ErrDeviceAuthorizationNotFound error if there is none.
+func (s *Storer) GetDeviceAuthorizationByUserCode(ctx context.Context, userCode string) (grants.DeviceAuthorization, error) {
+	txn := s.db.Txn(false)
+	defer txn.Abort()
+
+	auth, err := getDeviceAuthorization(ctx, txn, "user_code", grants.TenantFromContext(ctx), userCode)
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	return *auth, nil
+}
+
+// PollDeviceAuthorization records the device polling the DeviceAuthorization
+// with the ID `id` at `at`, as described by grants.DeviceAuthorization.Poll,
+// returning the updated DeviceAuthorization and the error the poll resulted
+// in.
+func (s *Storer) PollDeviceAuthorization(ctx context.Context, id string, at time.Time) (grants.DeviceAuthorization, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	found, err := getDeviceAuthorization(ctx, txn, "id", id)
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	polled, pollErr := found.Poll(at)
+	err = txn.Insert("device_authorization", &polled)
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	txn.Commit()
+
+	return polled, pollErr
+}
+
+// DecideDeviceAuthorization applies `decision` to the DeviceAuthorization
+// with the UserCode of `decision`, as described by
+// grants.DeviceAuthorization.Decide, returning the updated
+// DeviceAuthorization.
+func (s *Storer) DecideDeviceAuthorization(ctx context.Context, decision grants.DeviceDecision) (grants.DeviceAuthorization, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	found, err := getDeviceAuthorization(ctx, txn, "user_code", grants.TenantFromContext(ctx), decision.UserCode)
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	decided, err := found.Decide(decision)
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	err = txn.Insert("device_authorization", &decided)
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	txn.Commit()
+
+	return decided, nil
+}
+
+// DeleteExpiredDeviceAuthorizations deletes up to `limit`
+// DeviceAuthorizations that expired before `expiredBefore`, whatever their
+// status, returning the number deleted. DeviceAuthorizations of every tenant
+// are deleted, regardless of the tenant `ctx` is scoped to.
+func (s *Storer) DeleteExpiredDeviceAuthorizations(_ context.Context, expiredBefore time.Time, limit int) (int, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	iter, err := txn.Get("device_authorization", "id")
+	if err != nil {
+		return 0, err
+	}
+	// collect the matches before deleting anything, as modifying the
+	// table while iterating over it isn't safe
+	var matches []*grants.DeviceAuthorization
+	for auth := iter.Next(); auth != nil && len(matches) < limit; auth = iter.Next() {
+		found, ok := auth.(*grants.DeviceAuthorization)
+		if !ok || found == nil {
+			return 0, fmt.Errorf("unexpected result type %T", auth) //nolint:goerr113 // error for logging, not handling
+		}
+		if !found.ExpiresAt.Before(expiredBefore) {
+			continue
+		}
+		matches = append(matches, found)
+	}
+	for _, match := range matches {
+		err = txn.Delete("device_authorization", match)
+		if err != nil {
+			return 0, err
+		}
+	}
+	txn.Commit()
+	return len(matches), nil
+}
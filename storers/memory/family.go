@@ -0,0 +1,75 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"lockbox.dev/grants"
+)
+
+// RevokeGrantFamily revokes the Grant specified by `id`, its ancestors, and
+// every Grant descended from any of them, returning the IDs of the Grants it
+// revoked. Grants that can't be revoked are skipped. If no Grant has an ID
+// matching `id`, an ErrGrantNotFound error is returned.
+func (s *Storer) RevokeGrantFamily(ctx context.Context, id string) ([]string, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	found, err := getByID(ctx, txn, id)
+	if err != nil {
+		return nil, err
+	}
+	family := map[string]struct{}{found.ID: {}}
+	for _, ancestor := range found.AncestorIDs {
+		family[ancestor] = struct{}{}
+	}
+
+	iter, err := txn.Get("grant", "id")
+	if err != nil {
+		return nil, err
+	}
+	var revoke []grants.Grant
+	for grant := iter.Next(); grant != nil; grant = iter.Next() {
+		candidate, ok := grant.(*grants.Grant)
+		if !ok || candidate == nil {
+			return nil, fmt.Errorf("unexpected result type %T", grant) //nolint:goerr113 // error for logging, not handling
+		}
+		if candidate.TenantID != grants.TenantFromContext(ctx) || !inFamily(*candidate, family) {
+			continue
+		}
+		if !candidate.State.CanTransitionTo(grants.GrantStateRevoked) {
+			continue
+		}
+		revoke = append(revoke, *candidate)
+	}
+
+	var res []string
+	for _, grant := range revoke {
+		grant := grant
+		grant.State = grants.GrantStateRevoked
+		err = txn.Insert("grant", &grant)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, grant.ID)
+	}
+	txn.Commit()
+
+	sort.Strings(res)
+	return res, nil
+}
+
+// inFamily returns true if `grant` is in `family` or descends from any Grant
+// in it.
+func inFamily(grant grants.Grant, family map[string]struct{}) bool {
+	if _, ok := family[grant.ID]; ok {
+		return true
+	}
+	for _, ancestor := range grant.AncestorIDs {
+		if _, ok := family[ancestor]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -3,8 +3,11 @@ package memory
 import (
 	"context"
 	"fmt"
+	"sort"
+	"time"
 
 	memdb "github.com/hashicorp/go-memdb"
+	uuid "github.com/hashicorp/go-uuid"
 
 	"lockbox.dev/grants"
 )
@@ -27,6 +30,7 @@ var (
 						Unique: true,
 						Indexer: &memdb.CompoundIndex{
 							Indexes: []memdb.Indexer{
+								tenantIndex{},
 								&memdb.StringFieldIndex{
 									Field:     "SourceType",
 									Lowercase: true,
@@ -38,39 +42,171 @@ var (
 							},
 						},
 					},
+					"profile": &memdb.IndexSchema{
+						Name:         "profile",
+						AllowMissing: true,
+						Indexer: &memdb.StringFieldIndex{
+							Field: "ProfileID",
+						},
+					},
+				},
+			},
+			"device_authorization": &memdb.TableSchema{
+				Name: "device_authorization",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": &memdb.IndexSchema{
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.StringFieldIndex{
+							Field: "ID",
+						},
+					},
+					"user_code": &memdb.IndexSchema{
+						Name:   "user_code",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								tenantIndex{},
+								&memdb.StringFieldIndex{
+									Field: "UserCode",
+								},
+							},
+						},
+					},
 				},
 			},
 		},
 	}
 )
 
+// tenantIndex indexes the TenantID of a Grant or DeviceAuthorization. Unlike
+// memdb.StringFieldIndex, it treats the empty TenantID of the default tenant
+// as a value, not a missing field.
+type tenantIndex struct{}
+
+func (tenantIndex) FromObject(obj interface{}) (bool, []byte, error) {
+	switch value := obj.(type) {
+	case *grants.Grant:
+		if value != nil {
+			return true, []byte(value.TenantID + "\x00"), nil
+		}
+	case *grants.DeviceAuthorization:
+		if value != nil {
+			return true, []byte(value.TenantID + "\x00"), nil
+		}
+	}
+	return false, nil, fmt.Errorf("unexpected object type %T", obj) //nolint:goerr113 // error for logging, not handling
+}
+
+func (tenantIndex) FromArgs(args ...interface{}) ([]byte, error) {
+	if len(args) != 1 {
+		return nil, fmt.Errorf("must provide only a single argument, got %d", len(args)) //nolint:goerr113 // error for logging, not handling
+	}
+	tenantID, ok := args[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument must be a string, got %T", args[0]) //nolint:goerr113 // error for logging, not handling
+	}
+	return []byte(tenantID + "\x00"), nil
+}
+
+// anonymizationKeySize is the size, in bytes, of the key Storers generate to
+// derive pseudonyms from when they aren't given one.
+const anonymizationKeySize = 32
+
 // Storer is an in-memory implementation of the Storer
 // interface.
 type Storer struct {
-	db *memdb.MemDB
+	db               *memdb.MemDB
+	anonymizationKey []byte
+}
+
+// Option configures optional behavior of a Storer.
+type Option func(*Storer)
+
+// WithAnonymizationKey configures a Storer to derive the pseudonyms
+// AnonymizeProfile replaces personal data with from `key`, as described by
+// grants.Anonymizer. Without it, the Storer generates a random key that lasts
+// as long as the Storer, and its Grants, do.
+func WithAnonymizationKey(key []byte) Option {
+	return func(s *Storer) {
+		s.anonymizationKey = key
+	}
 }
 
 // NewStorer returns an in-memory Storer instance that is ready
 // to be used as a Storer.
-func NewStorer() (*Storer, error) {
+func NewStorer(opts ...Option) (*Storer, error) {
 	db, err := memdb.NewMemDB(schema)
 	if err != nil {
 		return nil, err
 	}
-	return &Storer{
-		db: db,
-	}, nil
+	key, err := uuid.GenerateRandomBytes(anonymizationKeySize)
+	if err != nil {
+		return nil, err
+	}
+	storer := &Storer{
+		db:               db,
+		anonymizationKey: key,
+	}
+	for _, opt := range opts {
+		opt(storer)
+	}
+	return storer, nil
+}
+
+// getByID returns the Grant with an ID of `id` belonging to the tenant
+// `ctx` is scoped to, or an ErrGrantNotFound error if there is none. Grants
+// belonging to other tenants are treated as if they don't exist.
+func getByID(ctx context.Context, txn *memdb.Txn, id string) (*grants.Grant, error) {
+	grant, err := txn.First("grant", "id", id)
+	if err != nil {
+		return nil, err
+	}
+	if grant == nil {
+		return nil, grants.ErrGrantNotFound
+	}
+	found, ok := grant.(*grants.Grant)
+	if !ok || found == nil {
+		return nil, fmt.Errorf("unexpected result type %T", grant) //nolint:goerr113 // error for logging, not handling
+	}
+	if found.TenantID != grants.TenantFromContext(ctx) {
+		return nil, grants.ErrGrantNotFound
+	}
+	return found, nil
 }
 
 // CreateGrant inserts the passed Grant into the Storer,
 // returning an ErrGrantAlreadyExists error if a Grant
 // with the same ID alreday exists in the Storer, or am
 // ErrGrantSourceAlreadyExists error if a Grant with the
-// same SourceType and SourceID already exists in the Storer.
-func (s *Storer) CreateGrant(_ context.Context, grant grants.Grant) error {
+// same SourceType and SourceID already exists in the Storer
+// for the same tenant.
+func (s *Storer) CreateGrant(ctx context.Context, grant grants.Grant) error {
+	grant, err := grants.ResolveTenant(ctx, grant)
+	if err != nil {
+		return err
+	}
+	grant, err = grants.ResolveState(grant)
+	if err != nil {
+		return err
+	}
+
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
+	err = createGrant(txn, grant)
+	if err != nil {
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// createGrant inserts `grant` using `txn`, after checking that its ID and
+// source haven't been used yet.
+func createGrant(txn *memdb.Txn, grant grants.Grant) error {
+	// IDs are unique across every tenant, so check for them without
+	// regard to the tenant
 	exists, err := txn.First("grant", "id", grant.ID)
 	if err != nil {
 		return err
@@ -78,19 +214,14 @@ func (s *Storer) CreateGrant(_ context.Context, grant grants.Grant) error {
 	if exists != nil {
 		return grants.ErrGrantAlreadyExists
 	}
-	exists, err = txn.First("grant", "source", grant.SourceType, grant.SourceID)
+	exists, err = txn.First("grant", "source", grant.TenantID, grant.SourceType, grant.SourceID)
 	if err != nil {
 		return err
 	}
 	if exists != nil {
 		return grants.ErrGrantSourceAlreadyUsed
 	}
-	err = txn.Insert("grant", &grant)
-	if err != nil {
-		return err
-	}
-	txn.Commit()
-	return nil
+	return txn.Insert("grant", &grant)
 }
 
 // ExchangeGrant applies the GrantUse to the Storer, marking
@@ -102,30 +233,54 @@ func (s *Storer) CreateGrant(_ context.Context, grant grants.Grant) error {
 // the Storer with an ID matching the Grant propery of the
 // GrantUse is already marked as used, an ErrGrantAlreadyUsed
 // error will be returned.
-func (s *Storer) ExchangeGrant(_ context.Context, use grants.GrantUse) (grants.Grant, error) {
+func (s *Storer) ExchangeGrant(ctx context.Context, use grants.GrantUse) (grants.Grant, error) {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
-	grant, err := txn.First("grant", "id", use.Grant)
+	grant, err := exchangeGrant(ctx, txn, use, grants.GrantStateActive)
 	if err != nil {
 		return grants.Grant{}, err
 	}
-	if grant == nil {
-		return grants.Grant{}, grants.ErrGrantNotFound
+	txn.Commit()
+
+	return grant, nil
+}
+
+// ExchangePendingGrant applies the GrantUse to the Storer like ExchangeGrant
+// does, but to a Grant that's still pending, moving it through
+// grants.GrantStateActive to grants.GrantStateUsed in a single transaction. If
+// the Grant isn't pending, the error returned by grants.Grant.ExchangePending
+// is returned.
+func (s *Storer) ExchangePendingGrant(ctx context.Context, use grants.GrantUse) (grants.Grant, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	grant, err := exchangeGrant(ctx, txn, use, grants.GrantStatePending)
+	if err != nil {
+		return grants.Grant{}, err
 	}
+	txn.Commit()
 
-	found, ok := grant.(*grants.Grant)
-	if !ok || found == nil {
-		return grants.Grant{}, fmt.Errorf("unexpected result type %T", grant) //nolint:goerr113 // error for logging, not handling
+	return grant, nil
+}
+
+// exchangeGrant applies `use` using `txn` to a Grant in the `from` state,
+// which must be grants.GrantStateActive or grants.GrantStatePending,
+// returning the exchanged Grant.
+func exchangeGrant(ctx context.Context, txn *memdb.Txn, use grants.GrantUse, from grants.GrantState) (grants.Grant, error) {
+	found, err := getByID(ctx, txn, use.Grant)
+	if err != nil {
+		return grants.Grant{}, err
 	}
-	newGrant := *found
-	if newGrant.Used {
-		return grants.Grant{}, grants.ErrGrantAlreadyUsed
+	var newGrant grants.Grant
+	if from == grants.GrantStatePending {
+		newGrant, err = found.ExchangePending()
+	} else {
+		newGrant, err = found.Transition(grants.GrantStateUsed)
 	}
-	if newGrant.Revoked {
-		return grants.Grant{}, grants.ErrGrantRevoked
+	if err != nil {
+		return grants.Grant{}, err
 	}
-	newGrant.Used = true
 	newGrant.UseIP = use.IP
 	newGrant.UsedAt = use.Time
 
@@ -133,39 +288,66 @@ func (s *Storer) ExchangeGrant(_ context.Context, use grants.GrantUse) (grants.G
 	if err != nil {
 		return grants.Grant{}, err
 	}
+	return newGrant, nil
+}
+
+// RotateGrant exchanges the Grant identified by `use` and creates `next` as
+// its child in a single transaction, returning the created Grant. The
+// AncestorIDs of `next` are set to the AncestorIDs of the exchanged Grant,
+// followed by its ID. If either step fails, neither takes effect.
+func (s *Storer) RotateGrant(ctx context.Context, use grants.GrantUse, next grants.Grant) (grants.Grant, error) {
+	next, err := grants.ResolveTenant(ctx, next)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	next, err = grants.ResolveState(next)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+
+	// the tenant may have been set on `next` instead of the context, and
+	// the parent has to be found in the same one
+	ctx = grants.WithTenant(ctx, next.TenantID)
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	exchanged, err := exchangeGrant(ctx, txn, use, grants.GrantStateActive)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	next, err = grants.ChildOf(exchanged, next)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	err = createGrant(txn, next)
+	if err != nil {
+		return grants.Grant{}, err
+	}
 	txn.Commit()
 
-	return newGrant, nil
+	return next, nil
 }
 
 // GetGrant retrieves the Grant specified by `id` from the
 // Storer. If no Grant has an ID matching the `id` parameter,
 // an ErrGrantNotFound error is returned.
-func (s *Storer) GetGrant(_ context.Context, id string) (grants.Grant, error) {
+func (s *Storer) GetGrant(ctx context.Context, id string) (grants.Grant, error) {
 	txn := s.db.Txn(false)
-	grant, err := txn.First("grant", "id", id)
+	res, err := getByID(ctx, txn, id)
 	if err != nil {
 		return grants.Grant{}, err
 	}
-	if grant == nil {
-		return grants.Grant{}, grants.ErrGrantNotFound
-	}
 	txn.Commit()
 
-	res, ok := grant.(*grants.Grant)
-	if !ok || res == nil {
-		return grants.Grant{}, fmt.Errorf("unexpected result type %T", grant) //nolint:goerr113 // error for logging, not handling
-	}
-
 	return *res, nil
 }
 
 // GetGrantBySource retrieves the Grant specified by `sourceType` and
 // `sourceID` from the Storer. If no Grant has a source type and source ID
 // matching these parameters, an ErrGrantNotFound error is returned.
-func (s *Storer) GetGrantBySource(_ context.Context, sourceType, sourceID string) (grants.Grant, error) {
+func (s *Storer) GetGrantBySource(ctx context.Context, sourceType, sourceID string) (grants.Grant, error) {
 	txn := s.db.Txn(false)
-	grant, err := txn.First("grant", "source", sourceType, sourceID)
+	grant, err := txn.First("grant", "source", grants.TenantFromContext(ctx), sourceType, sourceID)
 	if err != nil {
 		return grants.Grant{}, err
 	}
@@ -188,30 +370,26 @@ func (s *Storer) GetGrantBySource(_ context.Context, sourceType, sourceID string
 // marked as revoked in the Storer, an ErrGrantRevoked error is returned. If
 // the Grant matching the ID is already marked as used in the Storer, an
 // ErrGrantAlreadyUsed error is returned.
-func (s *Storer) RevokeGrant(_ context.Context, id string) (grants.Grant, error) {
+func (s *Storer) RevokeGrant(ctx context.Context, id string) (grants.Grant, error) {
+	return s.TransitionGrant(ctx, id, grants.GrantStateRevoked)
+}
+
+// TransitionGrant moves the Grant specified by `id` to `state`, returning the
+// updated Grant. If no Grant matches the specified ID, an ErrGrantNotFound
+// error is returned. If the Grant can't move to `state`, the error returned by
+// grants.Grant.Transition is returned.
+func (s *Storer) TransitionGrant(ctx context.Context, id string, state grants.GrantState) (grants.Grant, error) {
 	txn := s.db.Txn(true)
 	defer txn.Abort()
 
-	grant, err := txn.First("grant", "id", id)
+	found, err := getByID(ctx, txn, id)
 	if err != nil {
 		return grants.Grant{}, err
 	}
-	if grant == nil {
-		return grants.Grant{}, grants.ErrGrantNotFound
-	}
-
-	found, ok := grant.(*grants.Grant)
-	if !ok || found == nil {
-		return grants.Grant{}, fmt.Errorf("unexpected result type %T", grant) //nolint:goerr113 // error for logging, not handling
-	}
-	newGrant := *found
-	if newGrant.Used {
-		return grants.Grant{}, grants.ErrGrantAlreadyUsed
-	}
-	if newGrant.Revoked {
-		return grants.Grant{}, grants.ErrGrantRevoked
+	newGrant, err := found.Transition(state)
+	if err != nil {
+		return grants.Grant{}, err
 	}
-	newGrant.Revoked = true
 
 	err = txn.Insert("grant", &newGrant)
 	if err != nil {
@@ -221,3 +399,117 @@ func (s *Storer) RevokeGrant(_ context.Context, id string) (grants.Grant, error)
 
 	return newGrant, nil
 }
+
+// AnonymizeProfile scrubs the personal data from every Grant with a ProfileID
+// matching `profileID`, as described by grants.Anonymizer. Either every
+// matching Grant is anonymized, or none are.
+func (s *Storer) AnonymizeProfile(ctx context.Context, profileID string) error {
+	anonymizer, err := grants.NewAnonymizer(s.anonymizationKey)
+	if err != nil {
+		return err
+	}
+
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	iter, err := txn.Get("grant", "profile", profileID)
+	if err != nil {
+		return err
+	}
+	// collect the matches before modifying anything, as modifying the
+	// table while iterating over it isn't safe
+	var matches []grants.Grant
+	for grant := iter.Next(); grant != nil; grant = iter.Next() {
+		found, ok := grant.(*grants.Grant)
+		if !ok || found == nil {
+			return fmt.Errorf("unexpected result type %T", grant) //nolint:goerr113 // error for logging, not handling
+		}
+		if found.TenantID != grants.TenantFromContext(ctx) {
+			continue
+		}
+		matches = append(matches, *found)
+	}
+	for _, match := range matches {
+		anonymized := anonymizer.Anonymize(match)
+		err = txn.Insert("grant", &anonymized)
+		if err != nil {
+			return err
+		}
+	}
+	txn.Commit()
+	return nil
+}
+
+// ListGrantsByProfile returns up to `limit` Grants with a ProfileID matching
+// `profileID`, ordered by ID, starting after the Grant with the ID `after`.
+func (s *Storer) ListGrantsByProfile(ctx context.Context, profileID, after string, limit int) ([]grants.Grant, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+	txn := s.db.Txn(false)
+	defer txn.Abort()
+
+	iter, err := txn.Get("grant", "profile", profileID)
+	if err != nil {
+		return nil, err
+	}
+	var res []grants.Grant
+	for grant := iter.Next(); grant != nil; grant = iter.Next() {
+		found, ok := grant.(*grants.Grant)
+		if !ok || found == nil {
+			return nil, fmt.Errorf("unexpected result type %T", grant) //nolint:goerr113 // error for logging, not handling
+		}
+		if found.ID <= after || found.TenantID != grants.TenantFromContext(ctx) {
+			continue
+		}
+		res = append(res, *found)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	if len(res) > limit {
+		res = res[:limit]
+	}
+	return res, nil
+}
+
+// TruncateIPs applies `policy` to the CreateIP and UseIP of up to `limit`
+// Grants created before `createdBefore` whose IP addresses haven't been
+// truncated yet, returning the number of Grants updated. Retention applies to
+// the Grants of every tenant, regardless of the tenant `ctx` is scoped to.
+func (s *Storer) TruncateIPs(_ context.Context, createdBefore time.Time, policy grants.IPRetentionPolicy, limit int) (int, error) {
+	txn := s.db.Txn(true)
+	defer txn.Abort()
+
+	iter, err := txn.Get("grant", "id")
+	if err != nil {
+		return 0, err
+	}
+	// collect the matches before modifying anything, as modifying the
+	// table while iterating over it isn't safe
+	var matches []grants.Grant
+	for grant := iter.Next(); grant != nil && len(matches) < limit; grant = iter.Next() {
+		found, ok := grant.(*grants.Grant)
+		if !ok || found == nil {
+			return 0, fmt.Errorf("unexpected result type %T", grant) //nolint:goerr113 // error for logging, not handling
+		}
+		if !found.CreatedAt.Before(createdBefore) {
+			continue
+		}
+		// truncating is idempotent, so anything that truncating
+		// wouldn't change has already been truncated
+		if policy.Truncate(found.CreateIP) == found.CreateIP && policy.Truncate(found.UseIP) == found.UseIP {
+			continue
+		}
+		matches = append(matches, *found)
+	}
+	for pos := range matches {
+		match := &matches[pos]
+		match.CreateIP = policy.Truncate(match.CreateIP)
+		match.UseIP = policy.Truncate(match.UseIP)
+		err = txn.Insert("grant", match)
+		if err != nil {
+			return 0, err
+		}
+	}
+	txn.Commit()
+	return len(matches), nil
+}
@@ -6,7 +6,7 @@ import (
 	"lockbox.dev/grants"
 )
 
-// Factory implements the grants.Factory interface
+// Factory implements the storertest.Factory interface
 // for the Storer type; it offers a consistent
 // interface for setting up and tearing down Storers
 // for testing purposes.
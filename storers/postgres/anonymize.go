@@ -0,0 +1,102 @@
+package postgres
+
+import (
+	"context"
+
+	"darlinggo.co/pan"
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+func profileGrantsForUpdateSQL(tenantID, profileID string) *pan.Query {
+	var grant Grant
+	query := pan.New("SELECT " + pan.Columns(grant).String() + " FROM " + pan.Table(grant))
+	query.Where()
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	query.Comparison(grant, "ProfileID", "=", profileID)
+	query.Flush(" AND ")
+	query.Expression("FOR UPDATE")
+	return query.Flush(" ")
+}
+
+func anonymizeGrantSQL(grant Grant) *pan.Query {
+	query := pan.New("UPDATE " + pan.Table(grant) + " SET ")
+	query.Comparison(grant, "ProfileID", "=", grant.ProfileID)
+	query.Comparison(grant, "AccountID", "=", grant.AccountID)
+	query.Comparison(grant, "SourceID", "=", grant.SourceID)
+	query.Comparison(grant, "CreateIP", "=", grant.CreateIP)
+	query.Comparison(grant, "UseIP", "=", grant.UseIP)
+	query.Flush(", ").Where()
+	query.Comparison(grant, "ID", "=", grant.ID)
+	query.Comparison(grant, "TenantID", "=", grant.TenantID)
+	return query.Flush(" AND ")
+}
+
+// WithAnonymizationKey configures a Storer to derive the pseudonyms
+// AnonymizeProfile replaces personal data with from `key`, as described by
+// grants.Anonymizer. The key should be stored apart from the database.
+func WithAnonymizationKey(key []byte) Option {
+	return func(s *Storer) {
+		s.anonymizationKey = key
+	}
+}
+
+// AnonymizeProfile scrubs the personal data from every Grant with a ProfileID
+// matching `profileID`, as described by grants.Anonymizer. The Grants are
+// locked and updated in a single transaction, so either every matching Grant
+// is anonymized, or none are. If the Storer wasn't configured with
+// WithAnonymizationKey, a grants.ErrNoAnonymizationKey error is returned.
+func (s Storer) AnonymizeProfile(ctx context.Context, profileID string) error {
+	tenantID := grants.TenantFromContext(ctx)
+	log := yall.FromContext(ctx).WithField("profile_id", profileID).WithField("tenant", tenantID)
+	anonymizer, err := grants.NewAnonymizer(s.anonymizationKey)
+	if err != nil {
+		return err
+	}
+	query := profileGrantsForUpdateSQL(tenantID, profileID)
+	queryStr, err := query.PostgreSQLString()
+	if err != nil {
+		return err
+	}
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running select portion of anonymize profile query")
+	rows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	if err != nil {
+		return err
+	}
+	defer closeRows(ctx, rows)
+	var matches []Grant
+	for rows.Next() {
+		var grant Grant
+		err = pan.Unmarshal(rows, &grant)
+		if err != nil {
+			return err
+		}
+		matches = append(matches, grant)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	// the rows need to be closed before the connection can be used to
+	// run the updates
+	closeRows(ctx, rows)
+	for _, match := range matches {
+		anonymized := toPostgres(anonymizer.Anonymize(fromPostgres(match)))
+		query = anonymizeGrantSQL(anonymized)
+		queryStr, err = query.PostgreSQLString()
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, queryStr, query.Args()...)
+		if err != nil {
+			return err
+		}
+	}
+	log.WithField("grants", len(matches)).Debug("anonymized grants")
+	return tx.Commit()
+}
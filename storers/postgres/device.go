@@ -0,0 +1,304 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/lib/pq"
+
+	"darlinggo.co/pan"
+	"impractical.co/pqarrays"
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+// DeviceAuthorization is a representation of a DeviceAuthorization suitable
+// for storage in our Storer.
+type DeviceAuthorization struct {
+	ID           string
+	TenantID     string
+	UserCode     string
+	ClientID     string
+	Scopes       pqarrays.StringArray
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	PollInterval int64
+	LastPolledAt time.Time
+	Status       string
+	ProfileID    string
+	AccountID    string
+	GrantID      string
+}
+
+// GetSQLTableName allows us to use DeviceAuthorization with pan.
+func (DeviceAuthorization) GetSQLTableName() string {
+	return "device_authorizations"
+}
+
+func deviceAuthorizationFromPostgres(auth DeviceAuthorization) grants.DeviceAuthorization {
+	return grants.DeviceAuthorization{
+		ID:           auth.ID,
+		TenantID:     auth.TenantID,
+		UserCode:     auth.UserCode,
+		ClientID:     auth.ClientID,
+		Scopes:       []string(auth.Scopes),
+		CreatedAt:    auth.CreatedAt,
+		ExpiresAt:    auth.ExpiresAt,
+		Interval:     time.Duration(auth.PollInterval),
+		LastPolledAt: auth.LastPolledAt,
+		Status:       grants.DeviceStatus(auth.Status),
+		ProfileID:    auth.ProfileID,
+		AccountID:    auth.AccountID,
+		GrantID:      auth.GrantID,
+	}
+}
+
+func deviceAuthorizationToPostgres(auth grants.DeviceAuthorization) DeviceAuthorization {
+	return DeviceAuthorization{
+		ID:           auth.ID,
+		TenantID:     auth.TenantID,
+		UserCode:     auth.UserCode,
+		ClientID:     auth.ClientID,
+		Scopes:       pqarrays.StringArray(auth.Scopes),
+		CreatedAt:    auth.CreatedAt,
+		ExpiresAt:    auth.ExpiresAt,
+		PollInterval: int64(auth.Interval),
+		LastPolledAt: auth.LastPolledAt,
+		Status:       string(auth.Status),
+		ProfileID:    auth.ProfileID,
+		AccountID:    auth.AccountID,
+		GrantID:      auth.GrantID,
+	}
+}
+
+func getDeviceAuthorizationSQL(tenantID, column, value string, forUpdate bool) *pan.Query {
+	var auth DeviceAuthorization
+	query := pan.New("SELECT " + pan.Columns(auth).String() + " FROM " + pan.Table(auth))
+	query.Where()
+	query.Comparison(auth, "TenantID", "=", tenantID)
+	query.Comparison(auth, column, "=", value)
+	query.Flush(" AND ")
+	if forUpdate {
+		query.Expression("FOR UPDATE")
+	}
+	return query.Flush(" ")
+}
+
+func updateDeviceAuthorizationSQL(auth DeviceAuthorization) *pan.Query {
+	query := pan.New("UPDATE " + pan.Table(auth) + " SET ")
+	query.Comparison(auth, "PollInterval", "=", auth.PollInterval)
+	query.Comparison(auth, "LastPolledAt", "=", auth.LastPolledAt)
+	query.Comparison(auth, "Status", "=", auth.Status)
+	query.Comparison(auth, "ProfileID", "=", auth.ProfileID)
+	query.Comparison(auth, "AccountID", "=", auth.AccountID)
+	query.Comparison(auth, "GrantID", "=", auth.GrantID)
+	query.Flush(", ").Where()
+	query.Comparison(auth, "ID", "=", auth.ID)
+	query.Comparison(auth, "TenantID", "=", auth.TenantID)
+	return query.Flush(" AND ")
+}
+
+// CreateDeviceAuthorization inserts `auth` into the Storer, returning an
+// ErrDeviceAuthorizationAlreadyExists error if a DeviceAuthorization with the
+// same ID, or the same UserCode in the same tenant, already exists.
+func (s Storer) CreateDeviceAuthorization(ctx context.Context, auth grants.DeviceAuthorization) error {
+	tenantID, err := grants.ResolveTenantID(ctx, auth.TenantID)
+	if err != nil {
+		return err
+	}
+	auth.TenantID = tenantID
+	query := pan.Insert(deviceAuthorizationToPostgres(auth))
+	queryStr, err := query.PostgreSQLString()
+	if err != nil {
+		return err
+	}
+	// the tenant may have been set on `auth` instead of the context, and
+	// the row-level security policies need to know about it either way
+	tx, err := s.beginTx(grants.WithTenant(ctx, auth.TenantID))
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+	_, err = tx.ExecContext(ctx, queryStr, query.Args()...)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Constraint {
+		case "device_authorizations_pkey", "device_authorizations_tenant_id_user_code_key":
+			err = grants.ErrDeviceAuthorizationAlreadyExists
+		}
+	}
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// getDeviceAuthorization returns the DeviceAuthorization whose `column`
+// matches `value` using `tx`, locking it for the rest of the transaction if
+// `forUpdate` is true.
+func getDeviceAuthorization(ctx context.Context, tx *sql.Tx, column, value string, forUpdate bool) (DeviceAuthorization, error) {
+	log := yall.FromContext(ctx).WithField("tenant", grants.TenantFromContext(ctx))
+	query := getDeviceAuthorizationSQL(grants.TenantFromContext(ctx), column, value, forUpdate)
+	queryStr, err := query.PostgreSQLString()
+	if err != nil {
+		return DeviceAuthorization{}, err
+	}
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get device authorization query")
+	rows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	if err != nil {
+		return DeviceAuthorization{}, err
+	}
+	defer closeRows(ctx, rows)
+	var auth DeviceAuthorization
+	for rows.Next() {
+		err = pan.Unmarshal(rows, &auth)
+		if err != nil {
+			return DeviceAuthorization{}, err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return DeviceAuthorization{}, err
+	}
+	if auth.ID == "" {
+		return DeviceAuthorization{}, grants.ErrDeviceAuthorizationNotFound
+	}
+	return auth, nil
+}
+
+// GetDeviceAuthorization retrieves the DeviceAuthorization with the ID `id`
+// from the Storer, returning an ErrDeviceAuthorizationNotFound error if there
+// is none.
+func (s Storer) GetDeviceAuthorization(ctx context.Context, id string) (grants.DeviceAuthorization, error) {
+	return s.readDeviceAuthorization(ctx, "ID", id)
+}
+
+// GetDeviceAuthorizationByUserCode retrieves the DeviceAuthorization with the
+// UserCode `userCode` from the Storer, returning an
+// ErrDeviceAuthorizationNotFound error if there is none.
+func (s Storer) GetDeviceAuthorizationByUserCode(ctx context.Context, userCode string) (grants.DeviceAuthorization, error) {
+	return s.readDeviceAuthorization(ctx, "UserCode", userCode)
+}
+
+func (s Storer) readDeviceAuthorization(ctx context.Context, column, value string) (grants.DeviceAuthorization, error) {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	defer rollback(ctx, tx)
+	auth, err := getDeviceAuthorization(ctx, tx, column, value, false)
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	return deviceAuthorizationFromPostgres(auth), nil
+}
+
+// updateDeviceAuthorization locks the DeviceAuthorization whose `column`
+// matches `value`, applies `update` to it, and stores the result. The result
+// is stored even if `update` returns an error, unless it returns an empty
+// DeviceAuthorization; the error is returned once the result is committed.
+func (s Storer) updateDeviceAuthorization(ctx context.Context, column, value string, update func(grants.DeviceAuthorization) (grants.DeviceAuthorization, error)) (grants.DeviceAuthorization, error) {
+	log := yall.FromContext(ctx).WithField("tenant", grants.TenantFromContext(ctx))
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	defer rollback(ctx, tx)
+	found, err := getDeviceAuthorization(ctx, tx, column, value, true)
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	updated, updateErr := update(deviceAuthorizationFromPostgres(found))
+	if updated.ID == "" {
+		return grants.DeviceAuthorization{}, updateErr
+	}
+	query := updateDeviceAuthorizationSQL(deviceAuthorizationToPostgres(updated))
+	queryStr, err := query.PostgreSQLString()
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running update device authorization query")
+	_, err = tx.ExecContext(ctx, queryStr, query.Args()...)
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return grants.DeviceAuthorization{}, err
+	}
+	return updated, updateErr
+}
+
+// PollDeviceAuthorization records the device polling the DeviceAuthorization
+// with the ID `id` at `at`, as described by grants.DeviceAuthorization.Poll,
+// returning the updated DeviceAuthorization and the error the poll resulted
+// in.
+func (s Storer) PollDeviceAuthorization(ctx context.Context, id string, at time.Time) (grants.DeviceAuthorization, error) {
+	return s.updateDeviceAuthorization(ctx, "ID", id, func(auth grants.DeviceAuthorization) (grants.DeviceAuthorization, error) {
+		return auth.Poll(at)
+	})
+}
+
+// DecideDeviceAuthorization applies `decision` to the DeviceAuthorization
+// with the UserCode of `decision`, as described by
+// grants.DeviceAuthorization.Decide, returning the updated
+// DeviceAuthorization.
+func (s Storer) DecideDeviceAuthorization(ctx context.Context, decision grants.DeviceDecision) (grants.DeviceAuthorization, error) {
+	return s.updateDeviceAuthorization(ctx, "UserCode", decision.UserCode, func(auth grants.DeviceAuthorization) (grants.DeviceAuthorization, error) {
+		return auth.Decide(decision)
+	})
+}
+
+func deleteExpiredDeviceAuthorizationsSQL(expiredBefore time.Time, limit int) *pan.Query {
+	var auth DeviceAuthorization
+	id := pan.Column(auth, "ID")
+	// Postgres doesn't support LIMIT on DELETE, so pick the rows in a
+	// subquery, skipping the ones another run of the job has locked
+	query := pan.New("DELETE FROM " + pan.Table(auth) + " WHERE " + id + " IN (SELECT " + id + " FROM " + pan.Table(auth))
+	query.Where()
+	query.Comparison(auth, "ExpiresAt", "<", expiredBefore)
+	query.Flush(" AND ")
+	query.OrderBy(pan.Column(auth, "ExpiresAt"))
+	query.Limit(int64(limit))
+	query.Expression("FOR UPDATE SKIP LOCKED)")
+	return query.Flush(" ")
+}
+
+// DeleteExpiredDeviceAuthorizations deletes up to `limit`
+// DeviceAuthorizations that expired before `expiredBefore`, whatever their
+// status, returning the number deleted. DeviceAuthorizations of every tenant
+// are deleted, regardless of the tenant `ctx` is scoped to.
+func (s Storer) DeleteExpiredDeviceAuthorizations(ctx context.Context, expiredBefore time.Time, limit int) (int, error) {
+	log := yall.FromContext(ctx)
+	query := deleteExpiredDeviceAuthorizationsSQL(expiredBefore, limit)
+	queryStr, err := query.PostgreSQLString()
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.beginAllTenantsTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer rollback(ctx, tx)
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running delete expired device authorizations query")
+	result, err := tx.ExecContext(ctx, queryStr, query.Args()...)
+	if err != nil {
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.WithField("device_authorizations", count).Debug("deleted expired device authorizations")
+	return int(count), nil
+}
@@ -0,0 +1,300 @@
+package postgres
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	// encryptedIPPrefix is the prefix of every encrypted IP address. The
+	// full format is
+	// "enc:v1:<key ID>:<base64 wrapped data key>:<base64 nonce and ciphertext>".
+	encryptedIPPrefix = "enc:v1:"
+
+	encryptedIPParts = 5
+	dataKeySize      = 32
+)
+
+var (
+	// ErrUnknownKey is returned when a KeyProvider is asked to use a key
+	// it doesn't have.
+	ErrUnknownKey = errors.New("unknown encryption key")
+	// ErrNoKeyProvider is returned when an encrypted IP address is read by
+	// a Storer that wasn't configured with a KeyProvider.
+	ErrNoKeyProvider = errors.New("IP address is encrypted, but no key provider is configured")
+	// ErrMalformedEncryptedIP is returned when an encrypted IP address
+	// can't be parsed.
+	ErrMalformedEncryptedIP = errors.New("malformed encrypted IP address")
+)
+
+// KeyProvider supplies the key-encryption keys used to protect the data keys
+// that IP addresses are encrypted with. Implementations backed by a key
+// management service should perform the wrapping and unwrapping remotely, so
+// the key-encryption keys never leave the service.
+type KeyProvider interface {
+	// CurrentKeyID returns the ID of the key new data keys should be
+	// wrapped with.
+	CurrentKeyID(ctx context.Context) (string, error)
+
+	// WrapKey encrypts `dataKey` with the key identified by `keyID`.
+	WrapKey(ctx context.Context, keyID string, dataKey []byte) ([]byte, error)
+
+	// UnwrapKey decrypts `wrapped`, which was produced by WrapKey with
+	// the key identified by `keyID`.
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// WithIPEncryption configures a Storer to encrypt the CreateIP and UseIP of
+// every Grant at rest, using envelope encryption: each IP address is encrypted
+// with its own random data key, which is in turn encrypted with a key from
+// `provider`. IP addresses stored in plaintext before encryption was turned on
+// are still readable; ReencryptIPs will encrypt them.
+func WithIPEncryption(provider KeyProvider) Option {
+	return func(s *Storer) {
+		s.keys = provider
+	}
+}
+
+// FileKeyProvider is a KeyProvider that keeps its keys in a local JSON file.
+// The file has the form
+//
+//	{"current": "2023-01", "keys": {"2022-06": "<base64>", "2023-01": "<base64>"}}
+//
+// where each key is a base64-encoded 32 byte AES-256 key. To rotate keys, add a
+// new key to the file, make it current, reload the provider, then run
+// ReencryptIPs. Old keys can be removed from the file once ReencryptIPs has
+// finished.
+type FileKeyProvider struct {
+	path    string
+	current string
+	keys    map[string][]byte
+	lock    sync.RWMutex
+}
+
+type keyFile struct {
+	Current string            `json:"current"`
+	Keys    map[string]string `json:"keys"`
+}
+
+// NewFileKeyProvider returns a FileKeyProvider with the keys stored in the file
+// at `path`.
+func NewFileKeyProvider(path string) (*FileKeyProvider, error) {
+	provider := &FileKeyProvider{path: path}
+	err := provider.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return provider, nil
+}
+
+// Reload reads the keys from the FileKeyProvider's file again, picking up any
+// keys that have been added or rotated in since it was last read.
+func (f *FileKeyProvider) Reload() error {
+	contents, err := os.ReadFile(f.path)
+	if err != nil {
+		return err
+	}
+	var parsed keyFile
+	err = json.Unmarshal(contents, &parsed)
+	if err != nil {
+		return fmt.Errorf("error parsing key file %s: %w", f.path, err)
+	}
+	keys := make(map[string][]byte, len(parsed.Keys))
+	for id, encoded := range parsed.Keys {
+		if strings.Contains(id, ":") {
+			return fmt.Errorf("key ID %q can't contain colons", id) //nolint:goerr113 // error for display, not handling
+		}
+		key, decodeErr := base64.StdEncoding.DecodeString(encoded)
+		if decodeErr != nil {
+			return fmt.Errorf("error decoding key %q: %w", id, decodeErr)
+		}
+		if len(key) != dataKeySize {
+			return fmt.Errorf("key %q is %d bytes, must be %d", id, len(key), dataKeySize) //nolint:goerr113 // error for display, not handling
+		}
+		keys[id] = key
+	}
+	if _, ok := keys[parsed.Current]; !ok {
+		return fmt.Errorf("%w: current key %q not in key file", ErrUnknownKey, parsed.Current)
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.current = parsed.Current
+	f.keys = keys
+	return nil
+}
+
+// CurrentKeyID returns the ID of the key marked as current in the key file.
+func (f *FileKeyProvider) CurrentKeyID(_ context.Context) (string, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	return f.current, nil
+}
+
+// WrapKey encrypts `dataKey` with AES-256-GCM using the key identified by
+// `keyID`.
+func (f *FileKeyProvider) WrapKey(_ context.Context, keyID string, dataKey []byte) ([]byte, error) {
+	key, err := f.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return seal(key, dataKey, []byte(keyID))
+}
+
+// UnwrapKey decrypts `wrapped` with AES-256-GCM using the key identified by
+// `keyID`.
+func (f *FileKeyProvider) UnwrapKey(_ context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, err := f.key(keyID)
+	if err != nil {
+		return nil, err
+	}
+	return open(key, wrapped, []byte(keyID))
+}
+
+func (f *FileKeyProvider) key(id string) ([]byte, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+	key, ok := f.keys[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", ErrUnknownKey, id)
+	}
+	return key, nil
+}
+
+// seal encrypts `plaintext` with AES-GCM, returning the nonce followed by the
+// ciphertext.
+func seal(key, plaintext, additional []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	_, err = io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additional), nil
+}
+
+// open decrypts the output of seal.
+func open(key, sealed, additional []byte) ([]byte, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrMalformedEncryptedIP
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], additional)
+}
+
+// ipAAD binds an encrypted IP address to the grant and column it was written
+// to, so ciphertexts can't be swapped between rows or columns.
+func ipAAD(grantID, column string) []byte {
+	return []byte(grantID + ":" + column)
+}
+
+func isEncryptedIP(value string) bool {
+	return strings.HasPrefix(value, encryptedIPPrefix)
+}
+
+func (s Storer) encryptIP(ctx context.Context, grantID, column, ip string) (string, error) {
+	if s.keys == nil || ip == "" {
+		return ip, nil
+	}
+	keyID, err := s.keys.CurrentKeyID(ctx)
+	if err != nil {
+		return "", err
+	}
+	dataKey := make([]byte, dataKeySize)
+	_, err = io.ReadFull(rand.Reader, dataKey)
+	if err != nil {
+		return "", err
+	}
+	wrapped, err := s.keys.WrapKey(ctx, keyID, dataKey)
+	if err != nil {
+		return "", err
+	}
+	sealed, err := seal(dataKey, []byte(ip), ipAAD(grantID, column))
+	if err != nil {
+		return "", err
+	}
+	return encryptedIPPrefix + keyID + ":" + base64.RawStdEncoding.EncodeToString(wrapped) + ":" +
+		base64.RawStdEncoding.EncodeToString(sealed), nil
+}
+
+func (s Storer) decryptIP(ctx context.Context, grantID, column, value string) (string, error) {
+	if !isEncryptedIP(value) {
+		return value, nil
+	}
+	if s.keys == nil {
+		return "", ErrNoKeyProvider
+	}
+	parts := strings.SplitN(value, ":", encryptedIPParts)
+	if len(parts) != encryptedIPParts {
+		return "", ErrMalformedEncryptedIP
+	}
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrMalformedEncryptedIP, err.Error())
+	}
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return "", fmt.Errorf("%w: %s", ErrMalformedEncryptedIP, err.Error())
+	}
+	dataKey, err := s.keys.UnwrapKey(ctx, parts[2], wrapped)
+	if err != nil {
+		return "", err
+	}
+	ip, err := open(dataKey, sealed, ipAAD(grantID, column))
+	if err != nil {
+		return "", err
+	}
+	return string(ip), nil
+}
+
+// encryptIPs returns `grant` with its IP addresses encrypted, if the Storer is
+// configured to encrypt them.
+func (s Storer) encryptIPs(ctx context.Context, grant Grant) (Grant, error) {
+	var err error
+	grant.CreateIP, err = s.encryptIP(ctx, grant.ID, "create_ip", grant.CreateIP)
+	if err != nil {
+		return grant, err
+	}
+	grant.UseIP, err = s.encryptIP(ctx, grant.ID, "use_ip", grant.UseIP)
+	if err != nil {
+		return grant, err
+	}
+	return grant, nil
+}
+
+// decryptIPs returns `grant` with any encrypted IP addresses decrypted.
+func (s Storer) decryptIPs(ctx context.Context, grant Grant) (Grant, error) {
+	var err error
+	grant.CreateIP, err = s.decryptIP(ctx, grant.ID, "create_ip", grant.CreateIP)
+	if err != nil {
+		return grant, fmt.Errorf("error decrypting create IP of %s: %w", grant.ID, err)
+	}
+	grant.UseIP, err = s.decryptIP(ctx, grant.ID, "use_ip", grant.UseIP)
+	if err != nil {
+		return grant, fmt.Errorf("error decrypting use IP of %s: %w", grant.ID, err)
+	}
+	return grant, nil
+}
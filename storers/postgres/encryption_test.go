@@ -0,0 +1,195 @@
+package postgres_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	uuid "github.com/hashicorp/go-uuid"
+
+	"lockbox.dev/grants"
+	"lockbox.dev/grants/storers/postgres"
+)
+
+func newKeys(t *testing.T, ids ...string) map[string]string {
+	t.Helper()
+	keys := map[string]string{}
+	for _, id := range ids {
+		key := make([]byte, 32)
+		_, err := rand.Read(key)
+		if err != nil {
+			t.Fatalf("Error generating key: %s", err)
+		}
+		keys[id] = base64.StdEncoding.EncodeToString(key)
+	}
+	return keys
+}
+
+func writeKeyFile(t *testing.T, path, current string, keys map[string]string) {
+	t.Helper()
+	contents, err := json.Marshal(map[string]interface{}{"current": current, "keys": keys})
+	if err != nil {
+		t.Fatalf("Error encoding key file: %s", err)
+	}
+	err = os.WriteFile(path, contents, 0o600)
+	if err != nil {
+		t.Fatalf("Error writing key file: %s", err)
+	}
+}
+
+func TestFileKeyProviderWrapAndUnwrap(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeyFile(t, path, "one", newKeys(t, "one"))
+	provider, err := postgres.NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading keys: %s", err)
+	}
+
+	dataKey := []byte("0123456789abcdef0123456789abcdef")
+	current, err := provider.CurrentKeyID(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error getting current key: %s", err)
+	}
+	wrapped, err := provider.WrapKey(ctx, current, dataKey)
+	if err != nil {
+		t.Fatalf("Unexpected error wrapping key: %s", err)
+	}
+	if bytes.Contains(wrapped, dataKey) {
+		t.Errorf("Wrapped key contains the plaintext data key")
+	}
+
+	// reloading a file that drops the old key in favor of a new one
+	// makes the new key current, and data wrapped with the old key can
+	// no longer be unwrapped
+	writeKeyFile(t, path, "two", newKeys(t, "two"))
+	err = provider.Reload()
+	if err != nil {
+		t.Fatalf("Unexpected error reloading keys: %s", err)
+	}
+	_, err = provider.UnwrapKey(ctx, current, wrapped)
+	if !errors.Is(err, postgres.ErrUnknownKey) {
+		t.Errorf("Expected error %v after removing key, got %v", postgres.ErrUnknownKey, err)
+	}
+	current, err = provider.CurrentKeyID(ctx)
+	if err != nil {
+		t.Fatalf("Unexpected error getting current key: %s", err)
+	}
+	if current != "two" {
+		t.Errorf("Expected current key to be %q, got %q", "two", current)
+	}
+}
+
+func TestFileKeyProviderRejectsMissingCurrentKey(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	writeKeyFile(t, path, "missing", newKeys(t, "one"))
+	_, err := postgres.NewFileKeyProvider(path)
+	if !errors.Is(err, postgres.ErrUnknownKey) {
+		t.Errorf("Expected error %v, got %v", postgres.ErrUnknownKey, err)
+	}
+}
+
+func TestReencryptIPsRejectsInvalidBatchSize(t *testing.T) {
+	t.Parallel()
+
+	// the batch size is checked before the database is used
+	storer := postgres.NewStorer(context.Background(), nil)
+	for _, batchSize := range []int{0, -1} {
+		_, err := storer.ReencryptIPs(context.Background(), batchSize)
+		if !errors.Is(err, grants.ErrInvalidBatchSize) {
+			t.Errorf("Expected error %v for a batch size of %d, got %v", grants.ErrInvalidBatchSize, batchSize, err)
+		}
+	}
+}
+
+func TestEncryptedIPsRoundTripAndRotate(t *testing.T) {
+	t.Parallel()
+
+	if os.Getenv(postgres.TestConnStringEnvVar) == "" {
+		t.Skipf("%s not set, skipping", postgres.TestConnStringEnvVar)
+	}
+	ctx := context.Background()
+	conn, err := sql.Open("postgres", os.Getenv(postgres.TestConnStringEnvVar))
+	if err != nil {
+		t.Fatalf("Error connecting to database: %s", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "keys.json")
+	keys := newKeys(t, "one", "two")
+	writeKeyFile(t, path, "one", keys)
+	provider, err := postgres.NewFileKeyProvider(path)
+	if err != nil {
+		t.Fatalf("Unexpected error loading keys: %s", err)
+	}
+	factory := postgres.NewFactory(conn, postgres.WithIPEncryption(provider))
+	defer func() {
+		if err := factory.TeardownStorers(); err != nil {
+			t.Errorf("Error tearing down storers: %s", err)
+		}
+	}()
+	storer, err := factory.NewStorer(ctx)
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatalf("Error generating ID: %s", err)
+	}
+	err = storer.CreateGrant(ctx, grants.Grant{ID: id, SourceType: "manual", SourceID: id, CreateIP: "192.168.1.2"})
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant: %s", err)
+	}
+	used, err := storer.ExchangeGrant(ctx, grants.GrantUse{Grant: id, IP: "2001:db8::1", Time: time.Now()})
+	if err != nil {
+		t.Fatalf("Unexpected error exchanging grant: %s", err)
+	}
+	if used.CreateIP != "192.168.1.2" || used.UseIP != "2001:db8::1" {
+		t.Errorf("Expected decrypted IPs, got %q and %q", used.CreateIP, used.UseIP)
+	}
+
+	// nothing to do while everything is on the current key
+	pgStorer, ok := storer.(postgres.Storer)
+	if !ok {
+		t.Fatalf("Expected postgres.Storer, got %T", storer)
+	}
+	count, err := pgStorer.ReencryptIPs(ctx, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error re-encrypting: %s", err)
+	}
+	if count != 0 {
+		t.Errorf("Expected 0 grants re-encrypted, got %d", count)
+	}
+
+	writeKeyFile(t, path, "two", keys)
+	err = provider.Reload()
+	if err != nil {
+		t.Fatalf("Unexpected error reloading keys: %s", err)
+	}
+	count, err = pgStorer.ReencryptIPs(ctx, 10)
+	if err != nil {
+		t.Fatalf("Unexpected error re-encrypting: %s", err)
+	}
+	if count != 1 {
+		t.Errorf("Expected 1 grant re-encrypted, got %d", count)
+	}
+	got, err := storer.GetGrant(ctx, id)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant: %s", err)
+	}
+	if got.CreateIP != "192.168.1.2" || got.UseIP != "2001:db8::1" {
+		t.Errorf("Expected decrypted IPs after rotation, got %q and %q", got.CreateIP, got.UseIP)
+	}
+}
@@ -0,0 +1,140 @@
+package postgres
+
+import (
+	"context"
+	"sort"
+
+	"darlinggo.co/pan"
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+func revokeGrantFamilySQL(tenantID string, family []string) *pan.Query {
+	var grant Grant
+	var ancestor GrantAncestor
+	predecessors := grants.GrantStateRevoked.Predecessors()
+	from := make([]interface{}, 0, len(predecessors))
+	for _, predecessor := range predecessors {
+		from = append(from, string(predecessor))
+	}
+	ids := make([]interface{}, 0, len(family))
+	for _, id := range family {
+		ids = append(ids, id)
+	}
+	args := make([]interface{}, 0, len(ids)*2+1) //nolint:gomnd // the IDs twice, and the tenant
+	args = append(args, ids...)
+	args = append(args, tenantID)
+	args = append(args, ids...)
+
+	query := pan.New("UPDATE " + pan.Table(grant) + " SET ")
+	query.Comparison(grant, "State", "=", string(grants.GrantStateRevoked))
+	query.Flush(", ").Where()
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	query.In(grant, "State", from...)
+	// the family is the grants themselves, and anything descended from
+	// them
+	query.Expression("("+pan.Column(grant, "ID")+" IN ("+pan.Placeholders(len(ids))+") OR "+
+		pan.Column(grant, "ID")+" IN (SELECT "+pan.Column(ancestor, "GrantID")+" FROM "+pan.Table(ancestor)+
+		" WHERE "+pan.Column(ancestor, "TenantID")+" = ? AND "+pan.Column(ancestor, "AncestorID")+" IN ("+pan.Placeholders(len(ids))+")))", args...)
+	query.Flush(" AND ")
+	query.Expression("RETURNING " + pan.Column(grant, "ID"))
+	return query.Flush(" ")
+}
+
+// RevokeGrantFamily revokes the Grant specified by `id`, its ancestors, and
+// every Grant descended from any of them in a single transaction, returning
+// the IDs of the Grants it revoked. Grants that can't be revoked are skipped.
+// If no Grant has an ID matching `id`, an ErrGrantNotFound error is returned.
+func (s Storer) RevokeGrantFamily(ctx context.Context, id string) ([]string, error) {
+	tenantID := grants.TenantFromContext(ctx)
+	log := yall.FromContext(ctx).WithField("grant", id).WithField("tenant", tenantID)
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(ctx, tx)
+
+	// make sure the grant exists
+	query := getGrantSQL(tenantID, id)
+	queryStr, err := query.PostgreSQLString()
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get portion of revoke grant family query")
+	rows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(ctx, rows)
+	var grant Grant
+	for rows.Next() {
+		err = pan.Unmarshal(rows, &grant)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if grant.ID == "" {
+		return nil, grants.ErrGrantNotFound
+	}
+
+	// find its ancestors
+	query = getAncestorsSQL(tenantID, id)
+	queryStr, err = query.PostgreSQLString()
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get ancestors portion of revoke grant family query")
+	ancestorRows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(ctx, ancestorRows)
+	family := []string{id}
+	for ancestorRows.Next() {
+		var ancestor GrantAncestor
+		err = pan.Unmarshal(ancestorRows, &ancestor)
+		if err != nil {
+			return nil, err
+		}
+		family = append(family, ancestor.AncestorID)
+	}
+	if err = ancestorRows.Err(); err != nil {
+		return nil, err
+	}
+
+	// revoke the whole family
+	query = revokeGrantFamilySQL(tenantID, family)
+	queryStr, err = query.PostgreSQLString()
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running update portion of revoke grant family query")
+	revokedRows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(ctx, revokedRows)
+	var res []string
+	for revokedRows.Next() {
+		var revoked string
+		err = revokedRows.Scan(&revoked)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, revoked)
+	}
+	if err = revokedRows.Err(); err != nil {
+		return nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("revoked", len(res)).Debug("revoked grant family")
+	sort.Strings(res)
+	return res, nil
+}
@@ -11,20 +11,22 @@ import (
 // Grant is a representation of a Grant
 // suitable for storage in our Storer.
 type Grant struct {
-	ID         string
-	SourceType string
-	SourceID   string
-	Ancestors  []GrantAncestor `sql_column:"-"`
-	CreatedAt  time.Time
-	UsedAt     time.Time
-	Scopes     pqarrays.StringArray
-	AccountID  string
-	ProfileID  string
-	ClientID   string
-	CreateIP   string
-	UseIP      string
-	Used       bool
-	Revoked    bool
+	ID            string
+	TenantID      string
+	SourceType    string
+	SourceID      string
+	Ancestors     []GrantAncestor `sql_column:"-"`
+	CreatedAt     time.Time
+	UsedAt        time.Time
+	Scopes        pqarrays.StringArray
+	AccountID     string
+	ProfileID     string
+	ClientID      string
+	CreateIP      string
+	UseIP         string
+	KeyThumbprint string
+	State         string
+	IPsTruncated  bool `sql_column:"ips_truncated"`
 }
 
 func (g Grant) AncestorIDs() []string {
@@ -38,18 +40,20 @@ func (g Grant) AncestorIDs() []string {
 type GrantAncestor struct {
 	GrantID    string
 	AncestorID string
+	TenantID   string
 }
 
 func (GrantAncestor) GetSQLTableName() string {
 	return "grants_ancestors"
 }
 
-func ancestorsFromIDs(grantID string, ancestorIDs []string) []GrantAncestor {
+func ancestorsFromIDs(tenantID, grantID string, ancestorIDs []string) []GrantAncestor {
 	res := make([]GrantAncestor, 0, len(ancestorIDs))
 	for _, anc := range ancestorIDs {
 		res = append(res, GrantAncestor{
 			GrantID:    grantID,
 			AncestorID: anc,
+			TenantID:   tenantID,
 		})
 	}
 	return res
@@ -63,38 +67,40 @@ func (Grant) GetSQLTableName() string {
 
 func fromPostgres(grant Grant) grants.Grant {
 	return grants.Grant{
-		ID:          grant.ID,
-		SourceType:  grant.SourceType,
-		SourceID:    grant.SourceID,
-		AncestorIDs: grant.AncestorIDs(),
-		CreatedAt:   grant.CreatedAt,
-		UsedAt:      grant.UsedAt,
-		Scopes:      []string(grant.Scopes),
-		AccountID:   grant.AccountID,
-		ProfileID:   grant.ProfileID,
-		ClientID:    grant.ClientID,
-		CreateIP:    grant.CreateIP,
-		UseIP:       grant.UseIP,
-		Used:        grant.Used,
-		Revoked:     grant.Revoked,
+		ID:            grant.ID,
+		TenantID:      grant.TenantID,
+		SourceType:    grant.SourceType,
+		SourceID:      grant.SourceID,
+		AncestorIDs:   grant.AncestorIDs(),
+		CreatedAt:     grant.CreatedAt,
+		UsedAt:        grant.UsedAt,
+		Scopes:        []string(grant.Scopes),
+		AccountID:     grant.AccountID,
+		ProfileID:     grant.ProfileID,
+		ClientID:      grant.ClientID,
+		CreateIP:      grant.CreateIP,
+		UseIP:         grant.UseIP,
+		KeyThumbprint: grant.KeyThumbprint,
+		State:         grants.GrantState(grant.State),
 	}
 }
 
 func toPostgres(grant grants.Grant) Grant {
 	return Grant{
-		ID:         grant.ID,
-		SourceType: grant.SourceType,
-		SourceID:   grant.SourceID,
-		Ancestors:  ancestorsFromIDs(grant.ID, grant.AncestorIDs),
-		CreatedAt:  grant.CreatedAt,
-		UsedAt:     grant.UsedAt,
-		Scopes:     pqarrays.StringArray(grant.Scopes),
-		AccountID:  grant.AccountID,
-		ProfileID:  grant.ProfileID,
-		ClientID:   grant.ClientID,
-		CreateIP:   grant.CreateIP,
-		UseIP:      grant.UseIP,
-		Used:       grant.Used,
-		Revoked:    grant.Revoked,
+		ID:            grant.ID,
+		TenantID:      grant.TenantID,
+		SourceType:    grant.SourceType,
+		SourceID:      grant.SourceID,
+		Ancestors:     ancestorsFromIDs(grant.TenantID, grant.ID, grant.AncestorIDs),
+		CreatedAt:     grant.CreatedAt,
+		UsedAt:        grant.UsedAt,
+		Scopes:        pqarrays.StringArray(grant.Scopes),
+		AccountID:     grant.AccountID,
+		ProfileID:     grant.ProfileID,
+		ClientID:      grant.ClientID,
+		CreateIP:      grant.CreateIP,
+		UseIP:         grant.UseIP,
+		KeyThumbprint: grant.KeyThumbprint,
+		State:         string(grant.State),
 	}
 }
@@ -0,0 +1,176 @@
+package postgres
+
+import (
+	"context"
+
+	"darlinggo.co/pan"
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+func listGrantsByProfileSQL(tenantID, profileID, after string, limit int) *pan.Query {
+	var grant Grant
+	query := pan.New("SELECT " + pan.Columns(grant).String() + " FROM " + pan.Table(grant))
+	query.Where()
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	query.Comparison(grant, "ProfileID", "=", profileID)
+	query.Comparison(grant, "ID", ">", after)
+	query.Flush(" AND ")
+	query.OrderBy(pan.Column(grant, "ID"))
+	query.Limit(int64(limit))
+	return query.Flush(" ")
+}
+
+func listGrantsByClientSQL(tenantID, clientID, after string, limit int) *pan.Query {
+	var grant Grant
+	query := pan.New("SELECT " + pan.Columns(grant).String() + " FROM " + pan.Table(grant))
+	query.Where()
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	query.Comparison(grant, "ClientID", "=", clientID)
+	query.Comparison(grant, "ID", ">", after)
+	query.Flush(" AND ")
+	query.OrderBy(pan.Column(grant, "ID"))
+	query.Limit(int64(limit))
+	return query.Flush(" ")
+}
+
+func listGrantDescendantsSQL(tenantID, id, after string, limit int) *pan.Query {
+	var grant Grant
+	var ancestor GrantAncestor
+	query := pan.New("SELECT " + pan.Columns(grant).String() + " FROM " + pan.Table(grant))
+	query.Where()
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	query.Expression(pan.Column(grant, "ID")+" IN (SELECT "+pan.Column(ancestor, "GrantID")+" FROM "+pan.Table(ancestor)+
+		" WHERE "+pan.Column(ancestor, "TenantID")+" = ? AND "+pan.Column(ancestor, "AncestorID")+" = ?)", tenantID, id)
+	query.Comparison(grant, "ID", ">", after)
+	query.Flush(" AND ")
+	query.OrderBy(pan.Column(grant, "ID"))
+	query.Limit(int64(limit))
+	return query.Flush(" ")
+}
+
+func getAncestorsForGrantsSQL(tenantID string, ids []string) *pan.Query {
+	var ancestor GrantAncestor
+	args := make([]interface{}, 0, len(ids))
+	for _, id := range ids {
+		args = append(args, id)
+	}
+	query := pan.New("SELECT " + pan.Columns(ancestor).String() + " FROM " + pan.Table(ancestor))
+	query.Where()
+	query.Comparison(ancestor, "TenantID", "=", tenantID)
+	query.In(ancestor, "GrantID", args...)
+	return query.Flush(" AND ")
+}
+
+// ListGrantsByProfile returns up to `limit` Grants with a ProfileID matching
+// `profileID`, ordered by ID, starting after the Grant with the ID `after`.
+func (s Storer) ListGrantsByProfile(ctx context.Context, profileID, after string, limit int) ([]grants.Grant, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+	tenantID := grants.TenantFromContext(ctx)
+	log := yall.FromContext(ctx).WithField("profile_id", profileID).WithField("tenant", tenantID)
+	query := listGrantsByProfileSQL(tenantID, profileID, after, limit)
+	return s.listGrants(ctx, log, query)
+}
+
+// ListGrantsByClient returns up to `limit` Grants with a ClientID matching
+// `clientID`, ordered by ID, starting after the Grant with the ID `after`.
+func (s Storer) ListGrantsByClient(ctx context.Context, clientID, after string, limit int) ([]grants.Grant, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+	tenantID := grants.TenantFromContext(ctx)
+	log := yall.FromContext(ctx).WithField("client_id", clientID).WithField("tenant", tenantID)
+	query := listGrantsByClientSQL(tenantID, clientID, after, limit)
+	return s.listGrants(ctx, log, query)
+}
+
+// ListGrantDescendants returns up to `limit` Grants with `id` in their
+// AncestorIDs, ordered by ID, starting after the Grant with the ID `after`.
+func (s Storer) ListGrantDescendants(ctx context.Context, id, after string, limit int) ([]grants.Grant, error) {
+	if limit < 1 {
+		return nil, nil
+	}
+	tenantID := grants.TenantFromContext(ctx)
+	log := yall.FromContext(ctx).WithField("grant", id).WithField("tenant", tenantID)
+	query := listGrantDescendantsSQL(tenantID, id, after, limit)
+	return s.listGrants(ctx, log, query)
+}
+
+// listGrants runs `query`, which must select Grants belonging to the tenant
+// `ctx` is scoped to, and fills in the ancestors of every Grant it returns.
+func (s Storer) listGrants(ctx context.Context, log *yall.Logger, query *pan.Query) ([]grants.Grant, error) {
+	queryStr, err := query.PostgreSQLString()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(ctx, tx)
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running list grants query")
+	rows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(ctx, rows)
+	var found []Grant
+	var ids []string
+	for rows.Next() {
+		var grant Grant
+		err = pan.Unmarshal(rows, &grant)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, grant)
+		ids = append(ids, grant.ID)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(found) < 1 {
+		return nil, nil
+	}
+
+	query = getAncestorsForGrantsSQL(grants.TenantFromContext(ctx), ids)
+	queryStr, err = query.PostgreSQLString()
+	if err != nil {
+		return nil, err
+	}
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running ancestors portion of list grants query")
+	ancestorRows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(ctx, ancestorRows)
+	ancestors := map[string][]GrantAncestor{}
+	for ancestorRows.Next() {
+		var ancestor GrantAncestor
+		err = pan.Unmarshal(ancestorRows, &ancestor)
+		if err != nil {
+			return nil, err
+		}
+		ancestors[ancestor.GrantID] = append(ancestors[ancestor.GrantID], ancestor)
+	}
+	if err = ancestorRows.Err(); err != nil {
+		return nil, err
+	}
+
+	res := make([]grants.Grant, 0, len(found))
+	for _, grant := range found {
+		grant.Ancestors = ancestors[grant.ID]
+		grant, err = s.decryptIPs(ctx, grant)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, fromPostgres(grant))
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return res, nil
+}
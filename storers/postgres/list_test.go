@@ -0,0 +1,113 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uuid "github.com/hashicorp/go-uuid"
+
+	"lockbox.dev/grants"
+)
+
+func TestListGrantsByClientAndDescendants(t *testing.T) {
+	t.Parallel()
+
+	if os.Getenv(TestConnStringEnvVar) == "" {
+		t.Skipf("%s not set, skipping", TestConnStringEnvVar)
+	}
+	conn, err := sql.Open("postgres", os.Getenv(TestConnStringEnvVar))
+	if err != nil {
+		t.Fatalf("Error connecting to database: %s", err)
+	}
+	factory := NewFactory(conn)
+	t.Cleanup(func() {
+		if teardownErr := factory.TeardownStorers(); teardownErr != nil {
+			t.Errorf("Error cleaning up storers: %s", teardownErr)
+		}
+	})
+	ctx := context.Background()
+	created, err := factory.NewStorer(ctx)
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+	storer, ok := created.(Storer)
+	if !ok {
+		t.Fatalf("Expected a Storer, got %T", created)
+	}
+
+	// a chain of three grants for one client, and one for another
+	var chain []string
+	for i := 0; i < 3; i++ {
+		var id string
+		id, err = uuid.GenerateUUID()
+		if err != nil {
+			t.Fatalf("Error generating ID: %s", err)
+		}
+		ancestors := make([]string, 0, len(chain))
+		ancestors = append(ancestors, chain...)
+		err = storer.CreateGrant(ctx, grants.Grant{
+			ID:          id,
+			SourceType:  "manual",
+			SourceID:    "TestListGrantsByClientAndDescendants-" + id,
+			AncestorIDs: ancestors,
+			ProfileID:   "test",
+			ClientID:    "testrunner",
+		})
+		if err != nil {
+			t.Fatalf("Error creating grant: %s", err)
+		}
+		chain = append(chain, id)
+	}
+	other, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatalf("Error generating ID: %s", err)
+	}
+	err = storer.CreateGrant(ctx, grants.Grant{
+		ID:         other,
+		SourceType: "manual",
+		SourceID:   "TestListGrantsByClientAndDescendants-other",
+		ProfileID:  "test",
+		ClientID:   "other",
+	})
+	if err != nil {
+		t.Fatalf("Error creating grant: %s", err)
+	}
+
+	ids := func(list []grants.Grant) map[string]struct{} {
+		res := map[string]struct{}{}
+		for _, grant := range list {
+			res[grant.ID] = struct{}{}
+		}
+		return res
+	}
+
+	byClient, err := storer.ListGrantsByClient(ctx, "testrunner", "", 10)
+	if err != nil {
+		t.Fatalf("Error listing grants by client: %s", err)
+	}
+	expected := map[string]struct{}{chain[0]: {}, chain[1]: {}, chain[2]: {}}
+	if diff := cmp.Diff(expected, ids(byClient)); diff != "" {
+		t.Errorf("Unexpected grants by client (-wanted, +got): %s", diff)
+	}
+
+	descendants, err := storer.ListGrantDescendants(ctx, chain[0], "", 10)
+	if err != nil {
+		t.Fatalf("Error listing descendants: %s", err)
+	}
+	expected = map[string]struct{}{chain[1]: {}, chain[2]: {}}
+	if diff := cmp.Diff(expected, ids(descendants)); diff != "" {
+		t.Errorf("Unexpected descendants (-wanted, +got): %s", diff)
+	}
+
+	// other tenants see nothing
+	descendants, err = storer.ListGrantDescendants(grants.WithTenant(ctx, "other"), chain[0], "", 10)
+	if err != nil {
+		t.Fatalf("Error listing descendants: %s", err)
+	}
+	if len(descendants) != 0 {
+		t.Errorf("Expected no descendants from another tenant, got %+v", descendants)
+	}
+}
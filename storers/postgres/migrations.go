@@ -10,19 +10,76 @@ import (
 //go:embed sql/*
 var migrations embed.FS
 
+// contractMigrations are the migrations that remove something older releases
+// still depend on. They're held back by ApplyMigrations and only applied by
+// ApplyContractMigrations.
+//
+//nolint:gochecknoglobals // a constant set of migration IDs
+var contractMigrations = map[string]struct{}{
+	"grants_20261018_7_drop_used_revoked.sql": {},
+}
+
 // ApplyMigrations runs the necessary database migrations to make the database
 // match the expected schema against the passed connection.
+//
+// Contract migrations, which remove columns or tables older releases still
+// use, are skipped until they've been applied by ApplyContractMigrations;
+// every other migration, including the ones after them, is applied as usual.
+// Upgrading is a two step procedure: deploy the new release and call
+// ApplyMigrations, then once no older release is left running, call
+// ApplyContractMigrations.
 func ApplyMigrations(connection *sql.DB, direction migrate.MigrationDirection) error {
-	migrations := MigrationsSource()
-	_, err := migrate.Exec(connection, "postgres", migrations, direction)
+	migrations, err := ExpandMigrationsSource(connection)
+	if err != nil {
+		return err
+	}
+	_, err = migrate.Exec(connection, "postgres", migrations, direction)
+	return err
+}
+
+// ApplyContractMigrations applies every pending migration, including the
+// contract migrations ApplyMigrations holds back. It should only be called
+// once every binary using the database has been upgraded to a release that
+// includes those migrations.
+func ApplyContractMigrations(connection *sql.DB) error {
+	_, err := migrate.Exec(connection, "postgres", MigrationsSource(), migrate.Up)
 	return err
 }
 
 // MigrationsSource returns a migrate.MigrationSource to apply the migrations
-// for this storer.
+// for this storer. It includes the contract migrations.
 func MigrationsSource() *migrate.EmbedFileSystemMigrationSource {
 	return &migrate.EmbedFileSystemMigrationSource{
 		FileSystem: migrations,
 		Root:       "sql",
 	}
 }
+
+// ExpandMigrationsSource returns a migrate.MigrationSource with the
+// migrations ApplyMigrations uses: every migration for this storer, except
+// the contract migrations that haven't been applied to the database
+// `connection` is for yet.
+func ExpandMigrationsSource(connection *sql.DB) (*migrate.MemoryMigrationSource, error) {
+	all, err := MigrationsSource().FindMigrations()
+	if err != nil {
+		return nil, err
+	}
+	records, err := migrate.GetMigrationRecords(connection, "postgres")
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[string]struct{}, len(records))
+	for _, record := range records {
+		applied[record.Id] = struct{}{}
+	}
+	res := &migrate.MemoryMigrationSource{}
+	for _, migration := range all {
+		_, contract := contractMigrations[migration.Id]
+		_, ok := applied[migration.Id]
+		if contract && !ok {
+			continue
+		}
+		res.Migrations = append(res.Migrations, migration)
+	}
+	return res, nil
+}
@@ -18,18 +18,38 @@ const (
 	// TestConnStringEnvVar is the name of the environment variable
 	// to set to the connection string when running tests.
 	TestConnStringEnvVar = "PG_TEST_DB"
+
+	// sourceConstraint is the name of the constraint that keeps sources
+	// unique within a tenant.
+	sourceConstraint = "grants_tenant_id_source_type_source_id_key"
 )
 
 // Storer is a PostgreSQL implementation of the Storer
 // interface.
+//
+// Every query runs in a transaction scoped to the tenant of
+// the context it was made with, which the row-level security
+// policies on the grants tables use to hide other tenants'
+// rows as a second line of defense. The role the Storer
+// connects as must not be a superuser or have BYPASSRLS for
+// those policies to apply.
 type Storer struct {
-	db *sql.DB
+	db               *sql.DB
+	keys             KeyProvider
+	anonymizationKey []byte
 }
 
+// Option configures optional behavior of a Storer.
+type Option func(*Storer)
+
 // NewStorer returns a PostgreSQL Storer instance that is ready
 // to be used as a Storer.
-func NewStorer(_ context.Context, conn *sql.DB) Storer {
-	return Storer{db: conn}
+func NewStorer(_ context.Context, conn *sql.DB, opts ...Option) Storer {
+	storer := Storer{db: conn}
+	for _, opt := range opts {
+		opt(&storer)
+	}
+	return storer
 }
 
 func createGrantSQL(grant Grant) *pan.Query {
@@ -48,9 +68,40 @@ func createGrantAncestorsSQL(ancestors []GrantAncestor) *pan.Query {
 // returning an ErrGrantAlreadyExists error if a Grant
 // with the same ID alreday exists in the Storer, or am
 // ErrGrantSourceAlreadyExists error if a Grant with the
-// same SourceType and SourceID already exists in the Storer.
+// same SourceType and SourceID already exists in the Storer
+// for the same tenant.
 func (s Storer) CreateGrant(ctx context.Context, grant grants.Grant) error {
-	grantQuery := createGrantSQL(toPostgres(grant))
+	grant, err := grants.ResolveTenant(ctx, grant)
+	if err != nil {
+		return err
+	}
+	grant, err = grants.ResolveState(grant)
+	if err != nil {
+		return err
+	}
+	// the tenant may have been set on the grant instead of the context,
+	// and the row-level security policies need to know about it either
+	// way
+	tx, err := s.beginTx(grants.WithTenant(ctx, grant.TenantID))
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+	err = s.createGrant(ctx, tx, grant)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// createGrant inserts `grant` and its ancestors using `tx`, mapping
+// constraint violations to the matching grants errors.
+func (s Storer) createGrant(ctx context.Context, tx *sql.Tx, grant grants.Grant) error {
+	pgGrant, err := s.encryptIPs(ctx, toPostgres(grant))
+	if err != nil {
+		return err
+	}
+	grantQuery := createGrantSQL(pgGrant)
 	grantQueryStr, err := grantQuery.PostgreSQLString()
 	if err != nil {
 		return err
@@ -58,69 +109,67 @@ func (s Storer) CreateGrant(ctx context.Context, grant grants.Grant) error {
 	var ancestorQuery *pan.Query
 	var ancestorQueryStr string
 	if len(grant.AncestorIDs) > 0 {
-		ancestorQuery = createGrantAncestorsSQL(ancestorsFromIDs(grant.ID, grant.AncestorIDs))
+		ancestorQuery = createGrantAncestorsSQL(pgGrant.Ancestors)
 		ancestorQueryStr, err = ancestorQuery.PostgreSQLString()
 		if err != nil {
 			return err
 		}
 	}
-	tx, err := s.db.BeginTx(ctx, nil)
-	if err != nil {
-		return err
-	}
 	_, err = tx.ExecContext(ctx, grantQueryStr, grantQuery.Args()...)
 	var pqErr *pq.Error
 	if errors.As(err, &pqErr) {
 		switch pqErr.Constraint {
 		case "grants_pkey":
 			err = grants.ErrGrantAlreadyExists
-		case "grants_source_type_source_id_key":
+		case sourceConstraint:
 			err = grants.ErrGrantSourceAlreadyUsed
 		}
 	}
 	if err != nil {
-		tx.Rollback()
 		return err
 	}
 
 	if ancestorQuery != nil && ancestorQueryStr != "" {
 		_, err = tx.ExecContext(ctx, ancestorQueryStr, ancestorQuery.Args()...)
 		if err != nil {
-			tx.Rollback()
 			return err
 		}
 	}
-	err = tx.Commit()
-	return err
+	return nil
 }
 
-func exchangeGrantUpdateSQL(use grants.GrantUse) *pan.Query {
+func exchangeGrantUpdateSQL(tenantID string, use grants.GrantUse, from grants.GrantState) *pan.Query {
 	var grant Grant
 	query := pan.New("UPDATE " + pan.Table(grant) + " SET ")
-	query.Comparison(grant, "Used", "=", true)
+	query.Comparison(grant, "State", "=", string(grants.GrantStateUsed))
 	query.Comparison(grant, "UseIP", "=", use.IP)
 	query.Comparison(grant, "UsedAt", "=", use.Time)
+	// the UseIP is new, so it needs to be truncated by the next run of
+	// the retention job if the grant is old enough
+	query.Comparison(grant, "IPsTruncated", "=", false)
 	query.Flush(", ").Where()
 	query.Comparison(grant, "ID", "=", use.Grant)
-	query.Comparison(grant, "Used", "=", false)
-	query.Comparison(grant, "Revoked", "=", false)
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	query.Comparison(grant, "State", "=", string(from))
 	return query.Flush(" AND ")
 }
 
-func exchangeGrantGetSQL(id string) *pan.Query {
+func exchangeGrantGetSQL(tenantID, id string) *pan.Query {
 	var grant Grant
 	query := pan.New("SELECT " + pan.Columns(grant).String() + " FROM " + pan.Table(grant))
 	query.Where()
 	query.Comparison(grant, "ID", "=", id)
-	return query.Flush(" ")
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	return query.Flush(" AND ")
 }
 
-func getAncestorsSQL(id string) *pan.Query {
+func getAncestorsSQL(tenantID, id string) *pan.Query {
 	var ancestor GrantAncestor
 	query := pan.New("SELECT " + pan.Columns(ancestor).String() + " FROM " + pan.Table(ancestor))
 	query.Where()
 	query.Comparison(ancestor, "GrantID", "=", id)
-	return query.Flush(" ")
+	query.Comparison(ancestor, "TenantID", "=", tenantID)
+	return query.Flush(" AND ")
 }
 
 // ExchangeGrant applies the GrantUse to the Storer, marking
@@ -133,15 +182,65 @@ func getAncestorsSQL(id string) *pan.Query {
 // GrantUse is already marked as used, an ErrGrantAlreadyUsed
 // error will be returned.
 func (s Storer) ExchangeGrant(ctx context.Context, use grants.GrantUse) (grants.Grant, error) {
-	log := yall.FromContext(ctx).WithField("grant", use.Grant)
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	defer rollback(ctx, tx)
+	grant, err := s.exchangeGrant(ctx, tx, use, grants.GrantStateActive)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return grant, nil
+}
+
+// ExchangePendingGrant applies the GrantUse to the Storer like ExchangeGrant
+// does, but to a Grant that's still pending, moving it through
+// grants.GrantStateActive to grants.GrantStateUsed in a single transaction. If
+// the Grant isn't pending, the error returned by grants.Grant.ExchangePending
+// is returned.
+func (s Storer) ExchangePendingGrant(ctx context.Context, use grants.GrantUse) (grants.Grant, error) {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	defer rollback(ctx, tx)
+	grant, err := s.exchangeGrant(ctx, tx, use, grants.GrantStatePending)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return grant, nil
+}
+
+// exchangeGrant applies `use` using `tx` to a Grant in the `from` state,
+// which must be grants.GrantStateActive or grants.GrantStatePending,
+// returning the exchanged Grant. The caller is responsible for committing
+// `tx`.
+func (s Storer) exchangeGrant(ctx context.Context, tx *sql.Tx, use grants.GrantUse, from grants.GrantState) (grants.Grant, error) {
+	tenantID := grants.TenantFromContext(ctx)
+	log := yall.FromContext(ctx).WithField("grant", use.Grant).WithField("tenant", tenantID)
+	encryptedUse := use
+	var err error
+	encryptedUse.IP, err = s.encryptIP(ctx, use.Grant, "use_ip", use.IP)
+	if err != nil {
+		return grants.Grant{}, err
+	}
 	// exchange the grant
-	query := exchangeGrantUpdateSQL(use)
+	query := exchangeGrantUpdateSQL(tenantID, encryptedUse, from)
 	queryStr, err := query.PostgreSQLString()
 	if err != nil {
 		return grants.Grant{}, err
 	}
 	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running update portion of grant exchange query")
-	result, err := s.db.ExecContext(ctx, queryStr, query.Args()...)
+	result, err := tx.ExecContext(ctx, queryStr, query.Args()...)
 	if err != nil {
 		return grants.Grant{}, err
 	}
@@ -151,13 +250,13 @@ func (s Storer) ExchangeGrant(ctx context.Context, use grants.GrantUse) (grants.
 		return grants.Grant{}, err
 	}
 	log.WithField("rows_affected", count).Debug("successfully executed query")
-	query = exchangeGrantGetSQL(use.Grant)
+	query = exchangeGrantGetSQL(tenantID, use.Grant)
 	queryStr, err = query.PostgreSQLString()
 	if err != nil {
 		return grants.Grant{}, err
 	}
 	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get portion of grant exchange query")
-	rows, err := s.db.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	rows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
 	if err != nil {
 		return grants.Grant{}, err
 	}
@@ -172,13 +271,13 @@ func (s Storer) ExchangeGrant(ctx context.Context, use grants.GrantUse) (grants.
 	if err = rows.Err(); err != nil {
 		return fromPostgres(grant), err
 	}
-	query = getAncestorsSQL(use.Grant)
+	query = getAncestorsSQL(tenantID, use.Grant)
 	queryStr, err = query.PostgreSQLString()
 	if err != nil {
 		return grants.Grant{}, err
 	}
 	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get ancestors portion of grant exchange query")
-	ancestorRows, err := s.db.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	ancestorRows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
 	if err != nil {
 		return grants.Grant{}, err
 	}
@@ -197,6 +296,10 @@ func (s Storer) ExchangeGrant(ctx context.Context, use grants.GrantUse) (grants.
 	// if we affected one or more rows, the exchange was
 	// successful, return the grant and we're done
 	if count >= 1 {
+		grant, err = s.decryptIPs(ctx, grant)
+		if err != nil {
+			return grants.Grant{}, err
+		}
 		return fromPostgres(grant), nil
 	}
 	// if we affected fewer than one rows, the grant
@@ -207,34 +310,48 @@ func (s Storer) ExchangeGrant(ctx context.Context, use grants.GrantUse) (grants.
 	if grant.ID == "" {
 		return fromPostgres(grant), grants.ErrGrantNotFound
 	}
-	// if the Grant exists but we didn't update it, either it was already
-	// used or was revoked.
-	if grant.Used {
-		return grants.Grant{}, grants.ErrGrantAlreadyUsed
+	// if the Grant exists but we didn't update it, it wasn't in a state
+	// that can be exchanged
+	if from == grants.GrantStatePending {
+		_, err = fromPostgres(grant).ExchangePending()
+	} else {
+		_, err = fromPostgres(grant).Transition(grants.GrantStateUsed)
 	}
-	if grant.Revoked {
-		return grants.Grant{}, grants.ErrGrantRevoked
+	if err != nil {
+		return grants.Grant{}, err
 	}
-	return grants.Grant{}, fmt.Errorf("error exchanging %s: %w", use.Grant, errors.New("unexpected error, no grants updated, grant found, grant not used or revoked"))
+	return grants.Grant{}, fmt.Errorf("error exchanging %s: %w", use.Grant, errors.New("unexpected error, no grants updated, grant found, grant can be exchanged"))
 }
 
-func revokeGrantUpdateSQL(id string) *pan.Query {
+func transitionGrantUpdateSQL(tenantID, id string, state grants.GrantState) *pan.Query {
 	var grant Grant
+	predecessors := state.Predecessors()
+	from := make([]interface{}, 0, len(predecessors))
+	for _, predecessor := range predecessors {
+		from = append(from, string(predecessor))
+	}
 	query := pan.New("UPDATE " + pan.Table(grant) + " SET ")
-	query.Comparison(grant, "Revoked", "=", true)
+	query.Comparison(grant, "State", "=", string(state))
 	query.Flush(", ").Where()
 	query.Comparison(grant, "ID", "=", id)
-	query.Comparison(grant, "Used", "=", false)
-	query.Comparison(grant, "Revoked", "=", false)
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	if len(from) > 0 {
+		query.In(grant, "State", from...)
+	} else {
+		// nothing can move to `state`, so don't update anything and
+		// let the caller figure out why
+		query.Expression("false")
+	}
 	return query.Flush(" AND ")
 }
 
-func revokeGrantGetSQL(id string) *pan.Query {
+func transitionGrantGetSQL(tenantID, id string) *pan.Query {
 	var grant Grant
 	query := pan.New("SELECT " + pan.Columns(grant).String() + " FROM " + pan.Table(grant))
 	query.Where()
 	query.Comparison(grant, "ID", "=", id)
-	return query.Flush(" ")
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	return query.Flush(" AND ")
 }
 
 // RevokeGrant marks the Grant specified by id as revoked in the Storer, making
@@ -245,31 +362,48 @@ func revokeGrantGetSQL(id string) *pan.Query {
 // passed id is already marked as revoked, an ErrGrantRevoked error will be
 // returned.
 func (s Storer) RevokeGrant(ctx context.Context, id string) (grants.Grant, error) {
-	log := yall.FromContext(ctx).WithField("grant", id)
-	// revoke the grant
-	query := revokeGrantUpdateSQL(id)
+	return s.TransitionGrant(ctx, id, grants.GrantStateRevoked)
+}
+
+// TransitionGrant moves the Grant specified by id to `state`, returning the
+// updated Grant. If no Grant has an ID matching the passed id, an
+// ErrGrantNotFound error is returned. If the Grant can't move to `state`, the
+// error returned by grants.Grant.Transition is returned.
+func (s Storer) TransitionGrant(ctx context.Context, id string, state grants.GrantState) (grants.Grant, error) {
+	tenantID := grants.TenantFromContext(ctx)
+	log := yall.FromContext(ctx).WithField("grant", id).WithField("tenant", tenantID).WithField("state", state)
+	if !state.Valid() {
+		return grants.Grant{}, fmt.Errorf("%w: %q", grants.ErrInvalidGrantState, state)
+	}
+	// move the grant
+	query := transitionGrantUpdateSQL(tenantID, id, state)
 	queryStr, err := query.PostgreSQLString()
 	if err != nil {
 		return grants.Grant{}, err
 	}
-	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running update portion of grant revoke query")
-	result, err := s.db.ExecContext(ctx, queryStr, query.Args()...)
+	tx, err := s.beginTx(ctx)
 	if err != nil {
 		return grants.Grant{}, err
 	}
-	// figure out how many rows exchanging affected
+	defer rollback(ctx, tx)
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running update portion of grant transition query")
+	result, err := tx.ExecContext(ctx, queryStr, query.Args()...)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	// figure out how many rows the transition affected
 	count, err := result.RowsAffected()
 	if err != nil {
 		return grants.Grant{}, err
 	}
 	log.WithField("rows_affected", count).Debug("successfully executed query")
-	query = revokeGrantGetSQL(id)
+	query = transitionGrantGetSQL(tenantID, id)
 	queryStr, err = query.PostgreSQLString()
 	if err != nil {
 		return grants.Grant{}, err
 	}
-	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get portion of grant revoke query")
-	rows, err := s.db.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get portion of grant transition query")
+	rows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
 	if err != nil {
 		return grants.Grant{}, err
 	}
@@ -284,13 +418,13 @@ func (s Storer) RevokeGrant(ctx context.Context, id string) (grants.Grant, error
 	if err = rows.Err(); err != nil {
 		return fromPostgres(grant), err
 	}
-	query = getAncestorsSQL(id)
+	query = getAncestorsSQL(tenantID, id)
 	queryStr, err = query.PostgreSQLString()
 	if err != nil {
 		return grants.Grant{}, err
 	}
-	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get ancestors portion of grant revoke query")
-	ancestorRows, err := s.db.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get ancestors portion of grant transition query")
+	ancestorRows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
 	if err != nil {
 		return grants.Grant{}, err
 	}
@@ -306,9 +440,17 @@ func (s Storer) RevokeGrant(ctx context.Context, id string) (grants.Grant, error
 	if err = ancestorRows.Err(); err != nil {
 		return fromPostgres(grant), err
 	}
-	// if we affected one or more rows, the revoke was
+	// if we affected one or more rows, the transition was
 	// successful, return the grant and we're done
 	if count >= 1 {
+		grant, err = s.decryptIPs(ctx, grant)
+		if err != nil {
+			return grants.Grant{}, err
+		}
+		err = tx.Commit()
+		if err != nil {
+			return grants.Grant{}, err
+		}
 		return fromPostgres(grant), nil
 	}
 	// if we affected fewer than one rows, the grant
@@ -319,37 +461,42 @@ func (s Storer) RevokeGrant(ctx context.Context, id string) (grants.Grant, error
 	if grant.ID == "" {
 		return fromPostgres(grant), grants.ErrGrantNotFound
 	}
-	// if the Grant exists but we didn't update it, either it was already
-	// used or was revoked.
-	if grant.Used {
-		return grants.Grant{}, grants.ErrGrantAlreadyUsed
-	}
-	if grant.Revoked {
-		return grants.Grant{}, grants.ErrGrantRevoked
+	// if the Grant exists but we didn't update it, it wasn't in a state
+	// that can move to `state`
+	_, err = fromPostgres(grant).Transition(state)
+	if err != nil {
+		return grants.Grant{}, err
 	}
-	return grants.Grant{}, fmt.Errorf("error revoking %s: %w", id, errors.New("unexpected error, no grants updated, grant found, grant not used or revoked"))
+	return grants.Grant{}, fmt.Errorf("error moving %s to %s: %w", id, state, errors.New("unexpected error, no grants updated, grant found, grant can make the transition"))
 }
 
-func getGrantSQL(id string) *pan.Query {
+func getGrantSQL(tenantID, id string) *pan.Query {
 	var grant Grant
 	query := pan.New("SELECT " + pan.Columns(grant).String() + " FROM " + pan.Table(grant))
 	query.Where()
 	query.Comparison(grant, "ID", "=", id)
-	return query.Flush(" ")
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	return query.Flush(" AND ")
 }
 
 // GetGrant retrieves the Grant specified by `id` from the Storer,
 // returning an ErrGrantNotFound error if no Grant in the Storer
 // has an ID matching `id`.
 func (s Storer) GetGrant(ctx context.Context, id string) (grants.Grant, error) {
-	log := yall.FromContext(ctx).WithField("grant", id)
-	query := getGrantSQL(id)
+	tenantID := grants.TenantFromContext(ctx)
+	log := yall.FromContext(ctx).WithField("grant", id).WithField("tenant", tenantID)
+	query := getGrantSQL(tenantID, id)
 	queryStr, err := query.PostgreSQLString()
 	if err != nil {
 		return grants.Grant{}, err
 	}
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	defer rollback(ctx, tx)
 	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get grant query")
-	rows, err := s.db.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	rows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
 	if err != nil {
 		return grants.Grant{}, err
 	}
@@ -367,13 +514,13 @@ func (s Storer) GetGrant(ctx context.Context, id string) (grants.Grant, error) {
 	if grant.ID == "" {
 		return fromPostgres(grant), grants.ErrGrantNotFound
 	}
-	query = getAncestorsSQL(id)
+	query = getAncestorsSQL(tenantID, id)
 	queryStr, err = query.PostgreSQLString()
 	if err != nil {
 		return grants.Grant{}, err
 	}
 	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get ancestors portion of get grant query")
-	ancestorRows, err := s.db.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	ancestorRows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
 	if err != nil {
 		return grants.Grant{}, err
 	}
@@ -389,13 +536,22 @@ func (s Storer) GetGrant(ctx context.Context, id string) (grants.Grant, error) {
 	if err = ancestorRows.Err(); err != nil {
 		return fromPostgres(grant), err
 	}
+	grant, err = s.decryptIPs(ctx, grant)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return grants.Grant{}, err
+	}
 	return fromPostgres(grant), nil
 }
 
-func getGrantBySourceSQL(sourceType, sourceID string) *pan.Query {
+func getGrantBySourceSQL(tenantID, sourceType, sourceID string) *pan.Query {
 	var grant Grant
 	query := pan.New("SELECT " + pan.Columns(grant).String() + " FROM " + pan.Table(grant))
 	query.Where()
+	query.Comparison(grant, "TenantID", "=", tenantID)
 	query.Comparison(grant, "SourceType", "=", sourceType)
 	query.Comparison(grant, "SourceID", "=", sourceID)
 	return query.Flush(" AND ")
@@ -405,15 +561,21 @@ func getGrantBySourceSQL(sourceType, sourceID string) *pan.Query {
 // `sourceID` from the Storer, returning an ErrGrantNotFound error if no Grant
 // in the Storer has a SourceType and SourceID matching those parameters.
 func (s Storer) GetGrantBySource(ctx context.Context, sourceType, sourceID string) (grants.Grant, error) {
+	tenantID := grants.TenantFromContext(ctx)
 	log := yall.FromContext(ctx).WithField("source_type", sourceType)
-	log = log.WithField("source_id", sourceID)
-	query := getGrantBySourceSQL(sourceType, sourceID)
+	log = log.WithField("source_id", sourceID).WithField("tenant", tenantID)
+	query := getGrantBySourceSQL(tenantID, sourceType, sourceID)
 	queryStr, err := query.PostgreSQLString()
 	if err != nil {
 		return grants.Grant{}, err
 	}
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	defer rollback(ctx, tx)
 	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get grant by source query")
-	rows, err := s.db.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	rows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
 	if err != nil {
 		return grants.Grant{}, err
 	}
@@ -431,13 +593,13 @@ func (s Storer) GetGrantBySource(ctx context.Context, sourceType, sourceID strin
 	if grant.ID == "" {
 		return fromPostgres(grant), grants.ErrGrantNotFound
 	}
-	query = getAncestorsSQL(grant.ID)
+	query = getAncestorsSQL(tenantID, grant.ID)
 	queryStr, err = query.PostgreSQLString()
 	if err != nil {
 		return grants.Grant{}, err
 	}
 	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running ancestors portion of get grant by source query")
-	ancestorRows, err := s.db.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	ancestorRows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
 	if err != nil {
 		return grants.Grant{}, err
 	}
@@ -453,6 +615,14 @@ func (s Storer) GetGrantBySource(ctx context.Context, sourceType, sourceID strin
 	if err = ancestorRows.Err(); err != nil {
 		return fromPostgres(grant), err
 	}
+	grant, err = s.decryptIPs(ctx, grant)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return grants.Grant{}, err
+	}
 	return fromPostgres(grant), nil
 }
 
@@ -0,0 +1,154 @@
+package postgres
+
+import (
+	"context"
+
+	"darlinggo.co/pan"
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+func staleIPsSQL(keyID, after string, limit int) *pan.Query {
+	var grant Grant
+	current := encryptedIPPrefix + keyID + ":%"
+	createIP := pan.Column(grant, "CreateIP")
+	useIP := pan.Column(grant, "UseIP")
+	query := pan.New("SELECT " + pan.Columns(grant).String() + " FROM " + pan.Table(grant))
+	query.Where()
+	query.Comparison(grant, "ID", ">", after)
+	query.Expression("(("+createIP+" <> '' AND "+createIP+" NOT LIKE ?) OR ("+useIP+" <> '' AND "+useIP+" NOT LIKE ?))", current, current)
+	query.Flush(" AND ")
+	query.OrderBy(pan.Column(grant, "ID"))
+	query.Limit(int64(limit))
+	return query.Flush(" ")
+}
+
+func replaceIPsSQL(old, updated Grant) *pan.Query {
+	query := pan.New("UPDATE " + pan.Table(old) + " SET ")
+	query.Comparison(old, "CreateIP", "=", updated.CreateIP)
+	query.Comparison(old, "UseIP", "=", updated.UseIP)
+	query.Flush(", ").Where()
+	query.Comparison(old, "ID", "=", old.ID)
+	query.Comparison(old, "CreateIP", "=", old.CreateIP)
+	query.Comparison(old, "UseIP", "=", old.UseIP)
+	return query.Flush(" AND ")
+}
+
+// ReencryptIPs encrypts the IP addresses of every Grant that is stored in
+// plaintext or encrypted with a key other than the KeyProvider's current key,
+// `batchSize` rows at a time, returning the number of Grants updated. The
+// Grants of every tenant are updated, regardless of the tenant `ctx` is
+// scoped to.
+//
+// It can be run while the Storer is in use. Each row is only updated if its
+// IP addresses haven't changed since they were read, so a Grant exchanged
+// while it is being re-encrypted keeps its new UseIP; it will be picked up
+// the next time ReencryptIPs is run.
+//
+// If `batchSize` is less than 1, a grants.ErrInvalidBatchSize error is
+// returned.
+func (s Storer) ReencryptIPs(ctx context.Context, batchSize int) (int, error) {
+	if batchSize < 1 {
+		return 0, grants.ErrInvalidBatchSize
+	}
+	if s.keys == nil {
+		return 0, ErrNoKeyProvider
+	}
+	keyID, err := s.keys.CurrentKeyID(ctx)
+	if err != nil {
+		return 0, err
+	}
+	var updated int
+	var after string
+	for {
+		var batch []Grant
+		batch, err = s.staleIPs(ctx, keyID, after, batchSize)
+		if err != nil {
+			return updated, err
+		}
+		if len(batch) < 1 {
+			return updated, nil
+		}
+		for _, grant := range batch {
+			after = grant.ID
+			var ok bool
+			ok, err = s.reencryptIPs(ctx, grant)
+			if err != nil {
+				return updated, err
+			}
+			if ok {
+				updated++
+			}
+		}
+	}
+}
+
+func (s Storer) reencryptIPs(ctx context.Context, grant Grant) (bool, error) {
+	decrypted, err := s.decryptIPs(ctx, grant)
+	if err != nil {
+		return false, err
+	}
+	encrypted, err := s.encryptIPs(ctx, decrypted)
+	if err != nil {
+		return false, err
+	}
+	query := replaceIPsSQL(grant, encrypted)
+	queryStr, err := query.PostgreSQLString()
+	if err != nil {
+		return false, err
+	}
+	tx, err := s.beginAllTenantsTx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer rollback(ctx, tx)
+	result, err := tx.ExecContext(ctx, queryStr, query.Args()...)
+	if err != nil {
+		return false, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+	if count < 1 {
+		yall.FromContext(ctx).WithField("grant", grant.ID).Debug("grant IPs changed while re-encrypting, skipping")
+		return false, nil
+	}
+	return true, nil
+}
+
+func (s Storer) staleIPs(ctx context.Context, keyID, after string, limit int) ([]Grant, error) {
+	query := staleIPsSQL(keyID, after, limit)
+	queryStr, err := query.PostgreSQLString()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.beginAllTenantsTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(ctx, tx)
+	rows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(ctx, rows)
+	var res []Grant
+	for rows.Next() {
+		var grant Grant
+		err = pan.Unmarshal(rows, &grant)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, grant)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+const (
+	// tenantSetting is the name of the setting the row-level security
+	// policies read the current tenant from.
+	tenantSetting = "lockbox.tenant_id"
+
+	// allTenantsSetting is the name of the setting that lets the
+	// row-level security policies expose the rows of every tenant. It is
+	// only set by maintenance jobs, like ReencryptIPs, that are meant to
+	// cover every tenant.
+	allTenantsSetting = "lockbox.all_tenants"
+)
+
+// beginTx starts a transaction scoped to the tenant `ctx` is scoped to. The
+// tenant is set for the duration of the transaction only, so connections
+// returned to the pool never carry a tenant over to the next transaction.
+//
+// The row-level security policies on the grants and grants_ancestors tables
+// only expose the rows of that tenant, so even a query that forgets to filter
+// by tenant can't read or modify another tenant's Grants.
+func (s Storer) beginTx(ctx context.Context) (*sql.Tx, error) {
+	return s.begin(ctx, tenantSetting, grants.TenantFromContext(ctx))
+}
+
+// beginAllTenantsTx starts a transaction that can see the rows of every
+// tenant, for maintenance jobs that aren't scoped to a single tenant.
+func (s Storer) beginAllTenantsTx(ctx context.Context) (*sql.Tx, error) {
+	return s.begin(ctx, allTenantsSetting, "on")
+}
+
+func (s Storer) begin(ctx context.Context, setting, value string) (*sql.Tx, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	// passing true as the last argument makes the setting local to the
+	// transaction, like SET LOCAL, but unlike SET LOCAL it accepts a
+	// parameter for the value
+	_, err = tx.ExecContext(ctx, "SELECT set_config($1, $2, true)", setting, value)
+	if err != nil {
+		rollback(ctx, tx)
+		return nil, err
+	}
+	return tx, nil
+}
+
+// rollback rolls back `tx`, logging any error other than the transaction
+// already being committed or rolled back. It's safe to defer right after a
+// transaction is started.
+func rollback(ctx context.Context, tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		yall.FromContext(ctx).WithError(err).Error("error rolling back transaction")
+	}
+}
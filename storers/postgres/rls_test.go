@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"os"
+	"sort"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	uuid "github.com/hashicorp/go-uuid"
+
+	"lockbox.dev/grants"
+)
+
+func TestRowLevelSecurity(t *testing.T) {
+	t.Parallel()
+
+	if os.Getenv(TestConnStringEnvVar) == "" {
+		t.Skipf("%s not set, skipping", TestConnStringEnvVar)
+	}
+	conn, err := sql.Open("postgres", os.Getenv(TestConnStringEnvVar))
+	if err != nil {
+		t.Fatalf("Error connecting to database: %s", err)
+	}
+	factory := NewFactory(conn)
+	t.Cleanup(func() {
+		if teardownErr := factory.TeardownStorers(); teardownErr != nil {
+			t.Errorf("Error cleaning up storers: %s", teardownErr)
+		}
+	})
+	ctx := context.Background()
+	created, err := factory.NewStorer(ctx)
+	if err != nil {
+		t.Fatalf("Error creating storer: %s", err)
+	}
+	storer, ok := created.(Storer)
+	if !ok {
+		t.Fatalf("Expected a Storer, got %T", created)
+	}
+
+	expected := map[string][]string{}
+	for _, tenant := range []string{"acme", "globex", ""} {
+		var id, ancestor string
+		id, err = uuid.GenerateUUID()
+		if err != nil {
+			t.Fatalf("Error generating ID: %s", err)
+		}
+		ancestor, err = uuid.GenerateUUID()
+		if err != nil {
+			t.Fatalf("Error generating ID: %s", err)
+		}
+		err = storer.CreateGrant(grants.WithTenant(ctx, tenant), grants.Grant{
+			ID:          id,
+			SourceType:  "manual",
+			SourceID:    "TestRowLevelSecurity",
+			AncestorIDs: []string{ancestor},
+			ProfileID:   "test",
+			ClientID:    "testrunner",
+		})
+		if err != nil {
+			t.Fatalf("Error creating grant for tenant %q: %s", tenant, err)
+		}
+		expected[tenant] = []string{id}
+	}
+
+	// superusers and table owners with BYPASSRLS skip row-level security
+	// entirely, so run the queries as a role that can't
+	suffix, err := uuid.GenerateRandomBytes(6) //nolint:gomnd // number is arbitrary, not magic
+	if err != nil {
+		t.Fatalf("Error generating role name: %s", err)
+	}
+	role := "grants_rls_test_" + hex.EncodeToString(suffix)
+	_, err = storer.db.ExecContext(ctx, "CREATE ROLE "+role+" NOLOGIN NOBYPASSRLS")
+	if err != nil {
+		t.Skipf("Can't create a role to test row-level security with: %s", err)
+	}
+	t.Cleanup(func() {
+		_, dropErr := storer.db.ExecContext(ctx, "DROP OWNED BY "+role)
+		if dropErr != nil {
+			t.Errorf("Error dropping privileges of %s: %s", role, dropErr)
+		}
+		_, dropErr = storer.db.ExecContext(ctx, "DROP ROLE "+role)
+		if dropErr != nil {
+			t.Errorf("Error dropping %s: %s", role, dropErr)
+		}
+	})
+	_, err = storer.db.ExecContext(ctx, "GRANT SELECT ON grants, grants_ancestors TO "+role)
+	if err != nil {
+		t.Fatalf("Error granting access to %s: %s", role, err)
+	}
+
+	for tenant, ids := range expected {
+		// deliberately don't filter by tenant, like a buggy query would
+		grantIDs := unfilteredIDs(ctx, t, storer, role, tenant, "SELECT id FROM grants")
+		if diff := cmp.Diff(ids, grantIDs); diff != "" {
+			t.Errorf("Unexpected grants visible to tenant %q (-wanted, +got): %s", tenant, diff)
+		}
+		ancestorIDs := unfilteredIDs(ctx, t, storer, role, tenant, "SELECT grant_id FROM grants_ancestors")
+		if diff := cmp.Diff(ids, ancestorIDs); diff != "" {
+			t.Errorf("Unexpected ancestors visible to tenant %q (-wanted, +got): %s", tenant, diff)
+		}
+	}
+}
+
+func unfilteredIDs(ctx context.Context, t *testing.T, storer Storer, role, tenant, query string) []string {
+	t.Helper()
+	tx, err := storer.beginTx(grants.WithTenant(ctx, tenant))
+	if err != nil {
+		t.Fatalf("Error starting transaction: %s", err)
+	}
+	defer rollback(ctx, tx)
+	_, err = tx.ExecContext(ctx, "SET LOCAL ROLE "+role)
+	if err != nil {
+		t.Fatalf("Error switching to %s: %s", role, err)
+	}
+	rows, err := tx.QueryContext(ctx, query)
+	if err != nil {
+		t.Fatalf("Error running %q: %s", query, err)
+	}
+	defer closeRows(ctx, rows)
+	var res []string
+	for rows.Next() {
+		var id string
+		err = rows.Scan(&id)
+		if err != nil {
+			t.Fatalf("Error scanning ID: %s", err)
+		}
+		res = append(res, id)
+	}
+	if err = rows.Err(); err != nil {
+		t.Fatalf("Error iterating over rows: %s", err)
+	}
+	sort.Strings(res)
+	return res
+}
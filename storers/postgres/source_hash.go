@@ -0,0 +1,137 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+
+	"github.com/lib/pq"
+
+	"darlinggo.co/pan"
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+func unhashedSourcesSQL(after string, limit int) *pan.Query {
+	var grant Grant
+	query := pan.New("SELECT " + pan.Columns(grant).String() + " FROM " + pan.Table(grant))
+	query.Where()
+	query.Comparison(grant, "ID", ">", after)
+	query.Expression(pan.Column(grant, "SourceID")+" !~ ?", grants.HashedSourceIDPattern)
+	query.Flush(" AND ")
+	query.OrderBy(pan.Column(grant, "ID"))
+	query.Limit(int64(limit))
+	return query.Flush(" ")
+}
+
+func hashSourceSQL(id, plaintext, hashed string) *pan.Query {
+	var grant Grant
+	query := pan.New("UPDATE " + pan.Table(grant) + " SET ")
+	query.Comparison(grant, "SourceID", "=", hashed)
+	query.Flush(", ").Where()
+	query.Comparison(grant, "ID", "=", id)
+	query.Comparison(grant, "SourceID", "=", plaintext)
+	return query.Flush(" AND ")
+}
+
+// HashSourceIDs replaces the SourceID of every Grant that is still stored in
+// plaintext with its hash under the current key of `hasher`, `batchSize` rows
+// at a time. It is the migration path for turning on grants.WithHashedSources
+// for an existing database, and can be run while the Storer is in use: the
+// wrapped Storer finds Grants by both their plaintext and hashed SourceIDs
+// until the migration is complete. The Grants of every tenant are hashed,
+// regardless of the tenant `ctx` is scoped to.
+//
+// Grants whose hashed SourceID would collide with a Grant that already exists
+// under that hash are left in plaintext and logged, as they represent a source
+// that was used twice. The number of Grants hashed is returned. If `batchSize`
+// is less than 1, a grants.ErrInvalidBatchSize error is returned.
+func (s Storer) HashSourceIDs(ctx context.Context, hasher grants.SourceHasher, batchSize int) (int, error) {
+	if batchSize < 1 {
+		return 0, grants.ErrInvalidBatchSize
+	}
+	var hashed int
+	var after string
+	for {
+		batch, err := s.unhashedSources(ctx, after, batchSize)
+		if err != nil {
+			return hashed, err
+		}
+		if len(batch) < 1 {
+			return hashed, nil
+		}
+		for _, grant := range batch {
+			after = grant.ID
+			var ok bool
+			ok, err = s.hashSource(ctx, hasher, grant)
+			if err != nil {
+				return hashed, err
+			}
+			if ok {
+				hashed++
+			}
+		}
+	}
+}
+
+func (s Storer) hashSource(ctx context.Context, hasher grants.SourceHasher, grant Grant) (bool, error) {
+	sourceID, err := hasher.Hash(grant.SourceType, grant.SourceID)
+	if err != nil {
+		return false, err
+	}
+	query := hashSourceSQL(grant.ID, grant.SourceID, sourceID)
+	queryStr, err := query.PostgreSQLString()
+	if err != nil {
+		return false, err
+	}
+	tx, err := s.beginAllTenantsTx(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer rollback(ctx, tx)
+	_, err = tx.ExecContext(ctx, queryStr, query.Args()...)
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Constraint == sourceConstraint {
+		yall.FromContext(ctx).WithField("grant", grant.ID).Warn("grant source already used under its hash, leaving in plaintext")
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (s Storer) unhashedSources(ctx context.Context, after string, limit int) ([]Grant, error) {
+	query := unhashedSourcesSQL(after, limit)
+	queryStr, err := query.PostgreSQLString()
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.beginAllTenantsTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer rollback(ctx, tx)
+	rows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	if err != nil {
+		return nil, err
+	}
+	defer closeRows(ctx, rows)
+	var res []Grant
+	for rows.Next() {
+		var grant Grant
+		err = pan.Unmarshal(rows, &grant)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, grant)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
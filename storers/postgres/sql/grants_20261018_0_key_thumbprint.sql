@@ -0,0 +1,5 @@
+-- +migrate Up
+ALTER TABLE grants ADD COLUMN key_thumbprint TEXT NOT NULL DEFAULT '';
+
+-- +migrate Down
+ALTER TABLE grants DROP COLUMN IF EXISTS key_thumbprint;
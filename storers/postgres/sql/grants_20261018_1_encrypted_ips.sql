@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE grants ALTER COLUMN create_ip TYPE TEXT,
+		   ALTER COLUMN use_ip TYPE TEXT;
+
+-- +migrate Down
+-- rolling back leaves the columns as TEXT: encrypted IPs don't fit in the
+-- VARCHAR(36) they used to be, and narrowing them would fail for every Grant
+-- with one. Older releases can read and write TEXT columns, but not encrypted
+-- IPs, so decrypt them before rolling back to a release without encryption.
@@ -0,0 +1,9 @@
+-- +migrate Up
+ALTER TABLE grants ADD COLUMN ips_truncated BOOLEAN NOT NULL DEFAULT false;
+
+CREATE INDEX grants_ip_retention_idx ON grants (created_at) WHERE NOT ips_truncated;
+
+-- +migrate Down
+DROP INDEX IF EXISTS grants_ip_retention_idx;
+
+ALTER TABLE grants DROP COLUMN IF EXISTS ips_truncated;
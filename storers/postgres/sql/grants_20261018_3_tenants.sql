@@ -0,0 +1,18 @@
+-- +migrate Up
+ALTER TABLE grants ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '',
+		   DROP CONSTRAINT IF EXISTS grants_source_type_source_id_key,
+		   ADD CONSTRAINT grants_tenant_id_source_type_source_id_key UNIQUE (tenant_id, source_type, source_id);
+
+CREATE INDEX grants_tenant_id_profile_id_idx ON grants (tenant_id, profile_id);
+
+ALTER TABLE grants_ancestors ADD COLUMN tenant_id TEXT NOT NULL DEFAULT '';
+
+-- +migrate Down
+ALTER TABLE grants_ancestors DROP COLUMN IF EXISTS tenant_id;
+
+DROP INDEX IF EXISTS grants_tenant_id_profile_id_idx;
+
+-- this will fail if the same source has been used by more than one tenant
+ALTER TABLE grants DROP CONSTRAINT IF EXISTS grants_tenant_id_source_type_source_id_key,
+		   ADD CONSTRAINT grants_source_type_source_id_key UNIQUE (source_type, source_id),
+		   DROP COLUMN IF EXISTS tenant_id;
@@ -0,0 +1,40 @@
+-- +migrate Up
+ALTER TABLE grants ENABLE ROW LEVEL SECURITY;
+ALTER TABLE grants FORCE ROW LEVEL SECURITY;
+
+-- current_setting returns NULL for settings that were never set, and an
+-- empty string for settings that were set in an earlier transaction, so
+-- both are treated as the default tenant
+CREATE POLICY grants_tenant_isolation ON grants
+	USING (
+		tenant_id = coalesce(current_setting('lockbox.tenant_id', true), '')
+		OR coalesce(current_setting('lockbox.all_tenants', true), '') = 'on'
+	)
+	WITH CHECK (
+		tenant_id = coalesce(current_setting('lockbox.tenant_id', true), '')
+		OR coalesce(current_setting('lockbox.all_tenants', true), '') = 'on'
+	);
+
+ALTER TABLE grants_ancestors ENABLE ROW LEVEL SECURITY;
+ALTER TABLE grants_ancestors FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY grants_ancestors_tenant_isolation ON grants_ancestors
+	USING (
+		tenant_id = coalesce(current_setting('lockbox.tenant_id', true), '')
+		OR coalesce(current_setting('lockbox.all_tenants', true), '') = 'on'
+	)
+	WITH CHECK (
+		tenant_id = coalesce(current_setting('lockbox.tenant_id', true), '')
+		OR coalesce(current_setting('lockbox.all_tenants', true), '') = 'on'
+	);
+
+-- +migrate Down
+DROP POLICY IF EXISTS grants_ancestors_tenant_isolation ON grants_ancestors;
+
+ALTER TABLE grants_ancestors NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE grants_ancestors DISABLE ROW LEVEL SECURITY;
+
+DROP POLICY IF EXISTS grants_tenant_isolation ON grants;
+
+ALTER TABLE grants NO FORCE ROW LEVEL SECURITY;
+ALTER TABLE grants DISABLE ROW LEVEL SECURITY;
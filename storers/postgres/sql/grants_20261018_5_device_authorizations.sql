@@ -0,0 +1,35 @@
+-- +migrate Up
+CREATE TABLE device_authorizations (
+	id TEXT PRIMARY KEY,
+	tenant_id TEXT NOT NULL DEFAULT '',
+	user_code TEXT NOT NULL,
+	client_id TEXT NOT NULL DEFAULT '',
+	scopes VARCHAR[] NOT NULL DEFAULT array[]::varchar[],
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	expires_at TIMESTAMPTZ NOT NULL,
+	-- in nanoseconds, like a Go time.Duration
+	poll_interval BIGINT NOT NULL DEFAULT 0,
+	last_polled_at TIMESTAMPTZ NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	profile_id TEXT NOT NULL DEFAULT '',
+	account_id TEXT NOT NULL DEFAULT '',
+	grant_id TEXT NOT NULL DEFAULT '',
+
+	UNIQUE(tenant_id, user_code)
+);
+
+ALTER TABLE device_authorizations ENABLE ROW LEVEL SECURITY;
+ALTER TABLE device_authorizations FORCE ROW LEVEL SECURITY;
+
+CREATE POLICY device_authorizations_tenant_isolation ON device_authorizations
+	USING (
+		tenant_id = coalesce(current_setting('lockbox.tenant_id', true), '')
+		OR coalesce(current_setting('lockbox.all_tenants', true), '') = 'on'
+	)
+	WITH CHECK (
+		tenant_id = coalesce(current_setting('lockbox.tenant_id', true), '')
+		OR coalesce(current_setting('lockbox.all_tenants', true), '') = 'on'
+	);
+
+-- +migrate Down
+DROP TABLE device_authorizations;
@@ -0,0 +1,57 @@
+-- +migrate Up
+-- the grants table has row-level security forced on it, so the backfill
+-- needs to be allowed to see every tenant's grants
+SELECT set_config('lockbox.all_tenants', 'on', true);
+
+-- the used and revoked columns are left in place so binaries that predate
+-- the state column keep working during a rolling deploy; they're dropped by
+-- a later migration, once nothing reads them anymore
+ALTER TABLE grants ADD COLUMN state TEXT NOT NULL DEFAULT 'active',
+		   ADD CONSTRAINT grants_state_check CHECK (state IN ('pending', 'active', 'used', 'revoked', 'expired', 'denied'));
+
+UPDATE grants SET state = CASE
+	WHEN used THEN 'used'
+	WHEN revoked THEN 'revoked'
+	ELSE 'active'
+END;
+
+-- until then, old binaries only write the booleans and new binaries only
+-- write state, so keep each in sync with the other. The booleans can't
+-- represent pending, expired, or denied grants, none of which can be
+-- exchanged, so old binaries see them as revoked.
+-- +migrate StatementBegin
+CREATE FUNCTION grants_sync_state() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		IF NEW.used THEN
+			NEW.state := 'used';
+		ELSIF NEW.revoked THEN
+			NEW.state := 'revoked';
+		END IF;
+	ELSIF NEW.used IS DISTINCT FROM OLD.used OR NEW.revoked IS DISTINCT FROM OLD.revoked THEN
+		NEW.state := CASE
+			WHEN NEW.used THEN 'used'
+			WHEN NEW.revoked THEN 'revoked'
+			ELSE 'active'
+		END;
+	END IF;
+	NEW.used := NEW.state = 'used';
+	NEW.revoked := NEW.state IN ('revoked', 'pending', 'expired', 'denied');
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+CREATE TRIGGER grants_sync_state BEFORE INSERT OR UPDATE ON grants
+	FOR EACH ROW EXECUTE FUNCTION grants_sync_state();
+
+-- +migrate Down
+-- rolling back loses the distinction between revoked, pending, expired, and
+-- denied grants: the booleans the trigger kept up to date record all of them
+-- as revoked
+DROP TRIGGER IF EXISTS grants_sync_state ON grants;
+
+DROP FUNCTION IF EXISTS grants_sync_state();
+
+ALTER TABLE grants DROP CONSTRAINT IF EXISTS grants_state_check,
+		   DROP COLUMN IF EXISTS state;
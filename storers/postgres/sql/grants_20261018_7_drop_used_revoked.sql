@@ -0,0 +1,47 @@
+-- +migrate Up
+-- only apply this once every binary using the grants table has been upgraded
+-- to use the state column, as older binaries still read and write the used
+-- and revoked columns it drops
+DROP TRIGGER IF EXISTS grants_sync_state ON grants;
+
+DROP FUNCTION IF EXISTS grants_sync_state();
+
+ALTER TABLE grants DROP COLUMN IF EXISTS used,
+		   DROP COLUMN IF EXISTS revoked;
+
+-- +migrate Down
+SELECT set_config('lockbox.all_tenants', 'on', true);
+
+ALTER TABLE grants ADD COLUMN used BOOLEAN NOT NULL DEFAULT false,
+		   ADD COLUMN revoked BOOLEAN NOT NULL DEFAULT false;
+
+-- the booleans can't represent pending, expired, or denied grants, none of
+-- which can be exchanged, so they're treated as revoked
+UPDATE grants SET used = (state = 'used'),
+		  revoked = (state IN ('revoked', 'pending', 'expired', 'denied'));
+
+-- +migrate StatementBegin
+CREATE FUNCTION grants_sync_state() RETURNS trigger AS $$
+BEGIN
+	IF TG_OP = 'INSERT' THEN
+		IF NEW.used THEN
+			NEW.state := 'used';
+		ELSIF NEW.revoked THEN
+			NEW.state := 'revoked';
+		END IF;
+	ELSIF NEW.used IS DISTINCT FROM OLD.used OR NEW.revoked IS DISTINCT FROM OLD.revoked THEN
+		NEW.state := CASE
+			WHEN NEW.used THEN 'used'
+			WHEN NEW.revoked THEN 'revoked'
+			ELSE 'active'
+		END;
+	END IF;
+	NEW.used := NEW.state = 'used';
+	NEW.revoked := NEW.state IN ('revoked', 'pending', 'expired', 'denied');
+	RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +migrate StatementEnd
+
+CREATE TRIGGER grants_sync_state BEFORE INSERT OR UPDATE ON grants
+	FOR EACH ROW EXECUTE FUNCTION grants_sync_state();
@@ -0,0 +1,5 @@
+-- +migrate Up
+CREATE INDEX device_authorizations_expires_at_idx ON device_authorizations (expires_at);
+
+-- +migrate Down
+DROP INDEX IF EXISTS device_authorizations_expires_at_idx;
@@ -16,24 +16,34 @@ import (
 	migrate "github.com/rubenv/sql-migrate"
 )
 
-// Factory implements the grants.Factory interface
+// testAnonymizationKey is the key Storers created by a Factory derive
+// pseudonyms from, unless the Factory is given a different one.
+//
+//nolint:gochecknoglobals // never modified
+var testAnonymizationKey = []byte("storertest anonymization key")
+
+// Factory implements the storertest.Factory interface
 // for the Storer type; it offers a consistent
 // interface for setting up and tearing down Storers
 // for testing purposes.
 type Factory struct {
 	db        *sql.DB
 	databases map[string]*sql.DB
+	opts      []Option
 	lock      sync.Mutex
 }
 
 // NewFactory returns a Factory, ready to be used.
 // NewFactory must be called to obtain a usable Factory,
 // because Factory types have internal state that must
-// be initialized.
-func NewFactory(db *sql.DB) *Factory {
+// be initialized. Any `opts` passed will be used to
+// configure every Storer the Factory creates, after
+// WithAnonymizationKey is used to set a test key.
+func NewFactory(db *sql.DB, opts ...Option) *Factory {
 	return &Factory{
 		db:        db,
 		databases: map[string]*sql.DB{},
+		opts:      opts,
 	}
 }
 
@@ -79,8 +89,13 @@ func (f *Factory) NewStorer(ctx context.Context) (grants.Storer, error) { //noli
 	if err != nil {
 		return nil, err
 	}
+	err = ApplyContractMigrations(newConn)
+	if err != nil {
+		return nil, err
+	}
 
-	storer := NewStorer(ctx, newConn)
+	opts := append([]Option{WithAnonymizationKey(testAnonymizationKey)}, f.opts...)
+	storer := NewStorer(ctx, newConn, opts...)
 	return storer, nil
 }
 
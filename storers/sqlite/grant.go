@@ -0,0 +1,153 @@
+package sqlite
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"lockbox.dev/grants"
+)
+
+// Grant is a representation of a Grant
+// suitable for storage in our Storer.
+type Grant struct {
+	ID            string
+	TenantID      string
+	SourceType    string
+	SourceID      string
+	Ancestors     []GrantAncestor `sql_column:"-"`
+	CreatedAt     time.Time
+	UsedAt        time.Time
+	Scopes        StringArray
+	AccountID     string
+	ProfileID     string
+	ClientID      string
+	CreateIP      string
+	UseIP         string
+	KeyThumbprint string
+	State         string
+	IPsTruncated  bool `sql_column:"ips_truncated"`
+}
+
+func (g Grant) AncestorIDs() []string {
+	res := make([]string, 0, len(g.Ancestors))
+	for _, anc := range g.Ancestors {
+		res = append(res, anc.AncestorID)
+	}
+	return res
+}
+
+type GrantAncestor struct {
+	GrantID    string
+	AncestorID string
+	TenantID   string
+}
+
+func (GrantAncestor) GetSQLTableName() string {
+	return "grants_ancestors"
+}
+
+func ancestorsFromIDs(tenantID, grantID string, ancestorIDs []string) []GrantAncestor {
+	res := make([]GrantAncestor, 0, len(ancestorIDs))
+	for _, anc := range ancestorIDs {
+		res = append(res, GrantAncestor{
+			GrantID:    grantID,
+			AncestorID: anc,
+			TenantID:   tenantID,
+		})
+	}
+	return res
+}
+
+// GetSQLTableName allows us to use Grant with
+// pan.
+func (Grant) GetSQLTableName() string {
+	return "grants"
+}
+
+// StringArray is a []string that's stored as a JSON array, as SQLite has no
+// array type.
+type StringArray []string
+
+// Value encodes `s` as a JSON array. A nil StringArray is stored as an empty
+// array.
+func (s StringArray) Value() (driver.Value, error) {
+	if s == nil {
+		s = StringArray{}
+	}
+	res, err := json.Marshal([]string(s))
+	if err != nil {
+		return nil, err
+	}
+	return string(res), nil
+}
+
+// Scan decodes the JSON array in `src` into `s`. An empty array is decoded as
+// a nil StringArray.
+func (s *StringArray) Scan(src interface{}) error {
+	var raw []byte
+	switch val := src.(type) {
+	case string:
+		raw = []byte(val)
+	case []byte:
+		raw = val
+	case nil:
+		*s = nil
+		return nil
+	default:
+		return fmt.Errorf("can't scan %T into a StringArray", src) //nolint:goerr113 // error isn't handled, only for display
+	}
+	var res []string
+	err := json.Unmarshal(raw, &res)
+	if err != nil {
+		return err
+	}
+	if len(res) < 1 {
+		res = nil
+	}
+	*s = res
+	return nil
+}
+
+func fromSQLite(grant Grant) grants.Grant {
+	return grants.Grant{
+		ID:            grant.ID,
+		TenantID:      grant.TenantID,
+		SourceType:    grant.SourceType,
+		SourceID:      grant.SourceID,
+		AncestorIDs:   grant.AncestorIDs(),
+		CreatedAt:     grant.CreatedAt,
+		UsedAt:        grant.UsedAt,
+		Scopes:        []string(grant.Scopes),
+		AccountID:     grant.AccountID,
+		ProfileID:     grant.ProfileID,
+		ClientID:      grant.ClientID,
+		CreateIP:      grant.CreateIP,
+		UseIP:         grant.UseIP,
+		KeyThumbprint: grant.KeyThumbprint,
+		State:         grants.GrantState(grant.State),
+	}
+}
+
+// toSQLite converts `grant` into a Grant. Times are stored as text, so
+// they're converted to UTC to keep them comparable.
+func toSQLite(grant grants.Grant) Grant {
+	return Grant{
+		ID:            grant.ID,
+		TenantID:      grant.TenantID,
+		SourceType:    grant.SourceType,
+		SourceID:      grant.SourceID,
+		Ancestors:     ancestorsFromIDs(grant.TenantID, grant.ID, grant.AncestorIDs),
+		CreatedAt:     grant.CreatedAt.UTC(),
+		UsedAt:        grant.UsedAt.UTC(),
+		Scopes:        StringArray(grant.Scopes),
+		AccountID:     grant.AccountID,
+		ProfileID:     grant.ProfileID,
+		ClientID:      grant.ClientID,
+		CreateIP:      grant.CreateIP,
+		UseIP:         grant.UseIP,
+		KeyThumbprint: grant.KeyThumbprint,
+		State:         string(grant.State),
+	}
+}
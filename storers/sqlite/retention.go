@@ -0,0 +1,90 @@
+package sqlite
+
+import (
+	"context"
+	"time"
+
+	"darlinggo.co/pan"
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+func untruncatedIPsSQL(createdBefore time.Time, limit int) *pan.Query {
+	var grant Grant
+	query := pan.New("SELECT " + pan.Columns(grant).String() + " FROM " + pan.Table(grant))
+	query.Where()
+	query.Comparison(grant, "CreatedAt", "<", createdBefore.UTC())
+	query.Comparison(grant, "IPsTruncated", "=", false)
+	query.Flush(" AND ")
+	query.OrderBy(pan.Column(grant, "CreatedAt"))
+	query.Limit(int64(limit))
+	return query.Flush(" ")
+}
+
+func truncateIPsSQL(grant Grant) *pan.Query {
+	query := pan.New("UPDATE " + pan.Table(grant) + " SET ")
+	query.Comparison(grant, "CreateIP", "=", grant.CreateIP)
+	query.Comparison(grant, "UseIP", "=", grant.UseIP)
+	query.Comparison(grant, "IPsTruncated", "=", true)
+	query.Flush(", ").Where()
+	query.Comparison(grant, "ID", "=", grant.ID)
+	return query.Flush(" AND ")
+}
+
+// TruncateIPs applies `policy` to the CreateIP and UseIP of up to `limit`
+// Grants created before `createdBefore` whose IP addresses haven't been
+// truncated yet, returning the number of Grants updated. Each batch is
+// updated in a single transaction. Retention applies to the Grants of every
+// tenant, regardless of the tenant `ctx` is scoped to.
+func (s Storer) TruncateIPs(ctx context.Context, createdBefore time.Time, policy grants.IPRetentionPolicy, limit int) (int, error) {
+	log := yall.FromContext(ctx)
+	query := untruncatedIPsSQL(createdBefore, limit)
+	queryStr, err := query.MySQLString()
+	if err != nil {
+		return 0, err
+	}
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer rollback(ctx, tx)
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running select portion of truncate IPs query")
+	rows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	if err != nil {
+		return 0, err
+	}
+	defer closeRows(ctx, rows)
+	var matches []Grant
+	for rows.Next() {
+		var grant Grant
+		err = pan.Unmarshal(rows, &grant)
+		if err != nil {
+			return 0, err
+		}
+		matches = append(matches, grant)
+	}
+	if err = rows.Err(); err != nil {
+		return 0, err
+	}
+	closeRows(ctx, rows)
+	for _, match := range matches {
+		match.CreateIP = policy.Truncate(match.CreateIP)
+		match.UseIP = policy.Truncate(match.UseIP)
+		query = truncateIPsSQL(match)
+		queryStr, err = query.MySQLString()
+		if err != nil {
+			return 0, err
+		}
+		_, err = tx.ExecContext(ctx, queryStr, query.Args()...)
+		if err != nil {
+			return 0, err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+	log.WithField("grants", len(matches)).Debug("truncated grant IPs")
+	return len(matches), nil
+}
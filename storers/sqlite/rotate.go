@@ -0,0 +1,52 @@
+package sqlite
+
+import (
+	"context"
+
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+// RotateGrant exchanges the Grant identified by `use` and creates `next` as
+// its child in a single transaction, returning the created Grant. The
+// AncestorIDs of `next` are set to the AncestorIDs of the exchanged Grant,
+// followed by its ID. If the exchange fails, `next` isn't created; if `next`
+// can't be created, the exchange is rolled back.
+func (s Storer) RotateGrant(ctx context.Context, use grants.GrantUse, next grants.Grant) (grants.Grant, error) {
+	log := yall.FromContext(ctx).WithField("grant", use.Grant).WithField("next_grant", next.ID)
+	next, err := grants.ResolveTenant(ctx, next)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	next, err = grants.ResolveState(next)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	// the tenant may have been set on `next` instead of the context, and
+	// the parent has to be found in the same one
+	ctx = grants.WithTenant(ctx, next.TenantID)
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	defer rollback(ctx, tx)
+	exchanged, err := s.exchangeGrant(ctx, tx, use, grants.GrantStateActive)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	next, err = grants.ChildOf(exchanged, next)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	err = s.createGrant(ctx, tx, next)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	log.Debug("rotated grant")
+	return next, nil
+}
@@ -0,0 +1,46 @@
+-- +migrate Up
+CREATE TABLE grants (
+	id TEXT PRIMARY KEY,
+	tenant_id TEXT NOT NULL DEFAULT '',
+	source_type TEXT NOT NULL DEFAULT '',
+	source_id TEXT NOT NULL DEFAULT '',
+	created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	used_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+	-- a JSON array of strings
+	scopes TEXT NOT NULL DEFAULT '[]',
+	account_id TEXT NOT NULL DEFAULT '',
+	profile_id TEXT NOT NULL DEFAULT '',
+	client_id TEXT NOT NULL DEFAULT '',
+	create_ip TEXT NOT NULL DEFAULT '',
+	use_ip TEXT NOT NULL DEFAULT '',
+	key_thumbprint TEXT NOT NULL DEFAULT '',
+	state TEXT NOT NULL DEFAULT 'active' CHECK (state IN ('pending', 'active', 'used', 'revoked', 'expired', 'denied')),
+	ips_truncated BOOLEAN NOT NULL DEFAULT false,
+
+	UNIQUE(tenant_id, source_type, source_id)
+);
+
+CREATE INDEX grants_tenant_id_profile_id_idx ON grants (tenant_id, profile_id);
+
+CREATE INDEX grants_ip_retention_idx ON grants (created_at) WHERE NOT ips_truncated;
+
+CREATE TABLE grants_ancestors (
+	grant_id TEXT NOT NULL,
+	ancestor_id TEXT NOT NULL,
+	tenant_id TEXT NOT NULL DEFAULT '',
+
+	UNIQUE(grant_id, ancestor_id)
+);
+
+CREATE INDEX grants_ancestors_tenant_id_ancestor_id_idx ON grants_ancestors (tenant_id, ancestor_id);
+
+-- +migrate Down
+DROP INDEX IF EXISTS grants_ancestors_tenant_id_ancestor_id_idx;
+
+DROP TABLE grants_ancestors;
+
+DROP INDEX IF EXISTS grants_ip_retention_idx;
+
+DROP INDEX IF EXISTS grants_tenant_id_profile_id_idx;
+
+DROP TABLE grants;
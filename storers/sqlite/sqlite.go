@@ -0,0 +1,495 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/mattn/go-sqlite3"
+
+	"darlinggo.co/pan"
+	yall "yall.in"
+
+	"lockbox.dev/grants"
+)
+
+const (
+	// busyTimeout is how long, in milliseconds, a transaction waits
+	// for another to release the database's write lock before giving
+	// up.
+	busyTimeout = 5000
+
+	// sourceConstraint is the list of columns SQLite reports when the
+	// constraint that keeps sources unique within a tenant is violated.
+	// SQLite doesn't report constraint names, only the columns they
+	// cover.
+	sourceConstraint = "grants.tenant_id, grants.source_type, grants.source_id"
+)
+
+// Storer is a SQLite implementation of the Storer interface, for
+// single-node deployments and local development.
+//
+// Storers should use a database opened with Open; other connections
+// need to start their transactions with BEGIN IMMEDIATE and wait for
+// the database to be unlocked to be safe for concurrent use.
+type Storer struct {
+	db               *sql.DB
+	anonymizationKey []byte
+}
+
+// Option configures optional behavior of a Storer.
+type Option func(*Storer)
+
+// NewStorer returns a SQLite Storer instance that is ready
+// to be used as a Storer.
+func NewStorer(_ context.Context, conn *sql.DB, opts ...Option) Storer {
+	storer := Storer{db: conn}
+	for _, opt := range opts {
+		opt(&storer)
+	}
+	return storer
+}
+
+// Open opens the SQLite database at `path`, creating it if it doesn't exist,
+// configured the way Storer expects. Every transaction takes the database's
+// write lock when it starts, waiting for it if another transaction holds it,
+// so concurrent exchanges and revocations of the same Grant are serialized
+// instead of failing with SQLITE_BUSY.
+func Open(path string) (*sql.DB, error) {
+	params := url.Values{}
+	params.Set("_txlock", "immediate")
+	params.Set("_busy_timeout", fmt.Sprint(busyTimeout))
+	params.Set("_journal_mode", "WAL")
+	return sql.Open("sqlite3", "file:"+path+"?"+params.Encode())
+}
+
+func createGrantSQL(grant Grant) *pan.Query {
+	return pan.Insert(grant)
+}
+
+func createGrantAncestorsSQL(ancestors []GrantAncestor) *pan.Query {
+	namer := make([]pan.SQLTableNamer, 0, len(ancestors))
+	for _, anc := range ancestors {
+		namer = append(namer, anc)
+	}
+	return pan.Insert(namer...)
+}
+
+// CreateGrant inserts the passed Grant into the Storer,
+// returning an ErrGrantAlreadyExists error if a Grant
+// with the same ID already exists in the Storer, or an
+// ErrGrantSourceAlreadyUsed error if a Grant with the
+// same SourceType and SourceID already exists in the Storer
+// for the same tenant.
+func (s Storer) CreateGrant(ctx context.Context, grant grants.Grant) error {
+	grant, err := grants.ResolveTenant(ctx, grant)
+	if err != nil {
+		return err
+	}
+	grant, err = grants.ResolveState(grant)
+	if err != nil {
+		return err
+	}
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer rollback(ctx, tx)
+	err = s.createGrant(ctx, tx, grant)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// createGrant inserts `grant` and its ancestors using `tx`, mapping
+// constraint violations to the matching grants errors.
+func (Storer) createGrant(ctx context.Context, tx *sql.Tx, grant grants.Grant) error {
+	sqliteGrant := toSQLite(grant)
+	grantQuery := createGrantSQL(sqliteGrant)
+	grantQueryStr, err := grantQuery.MySQLString()
+	if err != nil {
+		return err
+	}
+	var ancestorQuery *pan.Query
+	var ancestorQueryStr string
+	if len(grant.AncestorIDs) > 0 {
+		ancestorQuery = createGrantAncestorsSQL(sqliteGrant.Ancestors)
+		ancestorQueryStr, err = ancestorQuery.MySQLString()
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.ExecContext(ctx, grantQueryStr, grantQuery.Args()...)
+	var sqliteErr sqlite3.Error
+	if errors.As(err, &sqliteErr) {
+		switch {
+		case sqliteErr.ExtendedCode == sqlite3.ErrConstraintPrimaryKey:
+			err = grants.ErrGrantAlreadyExists
+		case sqliteErr.ExtendedCode == sqlite3.ErrConstraintUnique && strings.HasSuffix(sqliteErr.Error(), sourceConstraint):
+			err = grants.ErrGrantSourceAlreadyUsed
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	if ancestorQuery != nil && ancestorQueryStr != "" {
+		_, err = tx.ExecContext(ctx, ancestorQueryStr, ancestorQuery.Args()...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func exchangeGrantUpdateSQL(tenantID string, use grants.GrantUse, from grants.GrantState) *pan.Query {
+	var grant Grant
+	query := pan.New("UPDATE " + pan.Table(grant) + " SET ")
+	query.Comparison(grant, "State", "=", string(grants.GrantStateUsed))
+	query.Comparison(grant, "UseIP", "=", use.IP)
+	query.Comparison(grant, "UsedAt", "=", use.Time.UTC())
+	// the UseIP is new, so it needs to be truncated by the next run of
+	// the retention job if the grant is old enough
+	query.Comparison(grant, "IPsTruncated", "=", false)
+	query.Flush(", ").Where()
+	query.Comparison(grant, "ID", "=", use.Grant)
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	query.Comparison(grant, "State", "=", string(from))
+	return query.Flush(" AND ")
+}
+
+func getAncestorsSQL(tenantID, id string) *pan.Query {
+	var ancestor GrantAncestor
+	query := pan.New("SELECT " + pan.Columns(ancestor).String() + " FROM " + pan.Table(ancestor))
+	query.Where()
+	query.Comparison(ancestor, "GrantID", "=", id)
+	query.Comparison(ancestor, "TenantID", "=", tenantID)
+	query.Flush(" AND ")
+	// ancestors are inserted oldest first, and need to come back in the
+	// same order
+	query.OrderBy("rowid")
+	return query.Flush(" ")
+}
+
+// getGrant runs `query` using `tx` and returns the Grant it selects, with its
+// ancestors filled in. If `query` doesn't select a Grant, the Grant returned
+// has an empty ID.
+func (Storer) getGrant(ctx context.Context, tx *sql.Tx, log *yall.Logger, tenantID string, query *pan.Query) (Grant, error) {
+	queryStr, err := query.MySQLString()
+	if err != nil {
+		return Grant{}, err
+	}
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get grant query")
+	rows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	if err != nil {
+		return Grant{}, err
+	}
+	defer closeRows(ctx, rows)
+	var grant Grant
+	for rows.Next() {
+		err = pan.Unmarshal(rows, &grant)
+		if err != nil {
+			return Grant{}, err
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return Grant{}, err
+	}
+	if grant.ID == "" {
+		return grant, nil
+	}
+	query = getAncestorsSQL(tenantID, grant.ID)
+	queryStr, err = query.MySQLString()
+	if err != nil {
+		return Grant{}, err
+	}
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running get ancestors query")
+	ancestorRows, err := tx.QueryContext(ctx, queryStr, query.Args()...) //nolint:sqlclosecheck // the closeRows helper isn't picked up
+	if err != nil {
+		return Grant{}, err
+	}
+	defer closeRows(ctx, ancestorRows)
+	for ancestorRows.Next() {
+		var ancestor GrantAncestor
+		err = pan.Unmarshal(ancestorRows, &ancestor)
+		if err != nil {
+			return Grant{}, err
+		}
+		grant.Ancestors = append(grant.Ancestors, ancestor)
+	}
+	if err = ancestorRows.Err(); err != nil {
+		return Grant{}, err
+	}
+	return grant, nil
+}
+
+// ExchangeGrant applies the GrantUse to the Storer, marking
+// the Grant in the Storer with an ID matching the Grant
+// property of the GrantUse as used and recording metadata
+// about the IP and time the Grant was used. If no Grant
+// has an ID matching the Grant property of the GrantUse,
+// an ErrGrantNotFound error is returned. If the Grant in
+// the Storer with an ID matching the Grant property of the
+// GrantUse is already marked as used, an ErrGrantAlreadyUsed
+// error will be returned.
+func (s Storer) ExchangeGrant(ctx context.Context, use grants.GrantUse) (grants.Grant, error) {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	defer rollback(ctx, tx)
+	grant, err := s.exchangeGrant(ctx, tx, use, grants.GrantStateActive)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return grant, nil
+}
+
+// ExchangePendingGrant applies the GrantUse to the Storer like ExchangeGrant
+// does, but to a Grant that's still pending, moving it through
+// grants.GrantStateActive to grants.GrantStateUsed in a single transaction. If
+// the Grant isn't pending, the error returned by grants.Grant.ExchangePending
+// is returned.
+func (s Storer) ExchangePendingGrant(ctx context.Context, use grants.GrantUse) (grants.Grant, error) {
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	defer rollback(ctx, tx)
+	grant, err := s.exchangeGrant(ctx, tx, use, grants.GrantStatePending)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return grant, nil
+}
+
+// exchangeGrant applies `use` using `tx` to a Grant in the `from` state,
+// which must be grants.GrantStateActive or grants.GrantStatePending,
+// returning the exchanged Grant. The caller is responsible for committing
+// `tx`.
+func (s Storer) exchangeGrant(ctx context.Context, tx *sql.Tx, use grants.GrantUse, from grants.GrantState) (grants.Grant, error) {
+	tenantID := grants.TenantFromContext(ctx)
+	log := yall.FromContext(ctx).WithField("grant", use.Grant).WithField("tenant", tenantID)
+	// exchange the grant
+	query := exchangeGrantUpdateSQL(tenantID, use, from)
+	queryStr, err := query.MySQLString()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running update portion of grant exchange query")
+	result, err := tx.ExecContext(ctx, queryStr, query.Args()...)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	// figure out how many rows exchanging affected
+	count, err := result.RowsAffected()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	log.WithField("rows_affected", count).Debug("successfully executed query")
+	grant, err := s.getGrant(ctx, tx, log, tenantID, getGrantSQL(tenantID, use.Grant))
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	// if we affected one or more rows, the exchange was
+	// successful, return the grant and we're done
+	if count >= 1 {
+		return fromSQLite(grant), nil
+	}
+	// if we affected fewer than one rows, the grant
+	// wasn't successful.
+
+	// if the grant doesn't exist in the Storer, that's an
+	// ErrGrantNotFound error
+	if grant.ID == "" {
+		return grants.Grant{}, grants.ErrGrantNotFound
+	}
+	// if the Grant exists but we didn't update it, it wasn't in a state
+	// that can be exchanged
+	if from == grants.GrantStatePending {
+		_, err = fromSQLite(grant).ExchangePending()
+	} else {
+		_, err = fromSQLite(grant).Transition(grants.GrantStateUsed)
+	}
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return grants.Grant{}, fmt.Errorf("error exchanging %s: %w", use.Grant, errors.New("unexpected error, no grants updated, grant found, grant can be exchanged"))
+}
+
+func transitionGrantUpdateSQL(tenantID, id string, state grants.GrantState) *pan.Query {
+	var grant Grant
+	predecessors := state.Predecessors()
+	from := make([]interface{}, 0, len(predecessors))
+	for _, predecessor := range predecessors {
+		from = append(from, string(predecessor))
+	}
+	query := pan.New("UPDATE " + pan.Table(grant) + " SET ")
+	query.Comparison(grant, "State", "=", string(state))
+	query.Flush(", ").Where()
+	query.Comparison(grant, "ID", "=", id)
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	if len(from) > 0 {
+		query.In(grant, "State", from...)
+	} else {
+		// nothing can move to `state`, so don't update anything and
+		// let the caller figure out why
+		query.Expression("false")
+	}
+	return query.Flush(" AND ")
+}
+
+// RevokeGrant marks the Grant specified by id as revoked in the Storer, making
+// in unable to be exchanged. If no Grant has an ID matching the passed id, an
+// ErrGrantNotFound error is returned. If the Grant in the Storer with an ID
+// matching the passed id is already marked as used, an ErrGrantAlreadyUsed
+// error will be returned. If the Grant in the Storer with an ID matching the
+// passed id is already marked as revoked, an ErrGrantRevoked error will be
+// returned.
+func (s Storer) RevokeGrant(ctx context.Context, id string) (grants.Grant, error) {
+	return s.TransitionGrant(ctx, id, grants.GrantStateRevoked)
+}
+
+// TransitionGrant moves the Grant specified by id to `state`, returning the
+// updated Grant. If no Grant has an ID matching the passed id, an
+// ErrGrantNotFound error is returned. If the Grant can't move to `state`, the
+// error returned by grants.Grant.Transition is returned.
+func (s Storer) TransitionGrant(ctx context.Context, id string, state grants.GrantState) (grants.Grant, error) {
+	tenantID := grants.TenantFromContext(ctx)
+	log := yall.FromContext(ctx).WithField("grant", id).WithField("tenant", tenantID).WithField("state", state)
+	if !state.Valid() {
+		return grants.Grant{}, fmt.Errorf("%w: %q", grants.ErrInvalidGrantState, state)
+	}
+	// move the grant
+	query := transitionGrantUpdateSQL(tenantID, id, state)
+	queryStr, err := query.MySQLString()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	defer rollback(ctx, tx)
+	log.WithField("query", queryStr).WithField("query_args", query.Args()).Debug("running update portion of grant transition query")
+	result, err := tx.ExecContext(ctx, queryStr, query.Args()...)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	// figure out how many rows the transition affected
+	count, err := result.RowsAffected()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	log.WithField("rows_affected", count).Debug("successfully executed query")
+	grant, err := s.getGrant(ctx, tx, log, tenantID, getGrantSQL(tenantID, id))
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	// if we affected one or more rows, the transition was
+	// successful, return the grant and we're done
+	if count >= 1 {
+		err = tx.Commit()
+		if err != nil {
+			return grants.Grant{}, err
+		}
+		return fromSQLite(grant), nil
+	}
+	// if we affected fewer than one rows, the grant
+	// wasn't successful.
+
+	// if the grant doesn't exist in the Storer, that's an
+	// ErrGrantNotFound error
+	if grant.ID == "" {
+		return grants.Grant{}, grants.ErrGrantNotFound
+	}
+	// if the Grant exists but we didn't update it, it wasn't in a state
+	// that can move to `state`
+	_, err = fromSQLite(grant).Transition(state)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return grants.Grant{}, fmt.Errorf("error moving %s to %s: %w", id, state, errors.New("unexpected error, no grants updated, grant found, grant can make the transition"))
+}
+
+func getGrantSQL(tenantID, id string) *pan.Query {
+	var grant Grant
+	query := pan.New("SELECT " + pan.Columns(grant).String() + " FROM " + pan.Table(grant))
+	query.Where()
+	query.Comparison(grant, "ID", "=", id)
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	return query.Flush(" AND ")
+}
+
+// GetGrant retrieves the Grant specified by `id` from the Storer,
+// returning an ErrGrantNotFound error if no Grant in the Storer
+// has an ID matching `id`.
+func (s Storer) GetGrant(ctx context.Context, id string) (grants.Grant, error) {
+	tenantID := grants.TenantFromContext(ctx)
+	log := yall.FromContext(ctx).WithField("grant", id).WithField("tenant", tenantID)
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	defer rollback(ctx, tx)
+	grant, err := s.getGrant(ctx, tx, log, tenantID, getGrantSQL(tenantID, id))
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	if grant.ID == "" {
+		return grants.Grant{}, grants.ErrGrantNotFound
+	}
+	err = tx.Commit()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return fromSQLite(grant), nil
+}
+
+func getGrantBySourceSQL(tenantID, sourceType, sourceID string) *pan.Query {
+	var grant Grant
+	query := pan.New("SELECT " + pan.Columns(grant).String() + " FROM " + pan.Table(grant))
+	query.Where()
+	query.Comparison(grant, "TenantID", "=", tenantID)
+	query.Comparison(grant, "SourceType", "=", sourceType)
+	query.Comparison(grant, "SourceID", "=", sourceID)
+	return query.Flush(" AND ")
+}
+
+// GetGrantBySource retrieves the Grant specified by `sourceType` and
+// `sourceID` from the Storer, returning an ErrGrantNotFound error if no Grant
+// in the Storer has a SourceType and SourceID matching those parameters.
+func (s Storer) GetGrantBySource(ctx context.Context, sourceType, sourceID string) (grants.Grant, error) {
+	tenantID := grants.TenantFromContext(ctx)
+	log := yall.FromContext(ctx).WithField("source_type", sourceType)
+	log = log.WithField("source_id", sourceID).WithField("tenant", tenantID)
+	tx, err := s.beginTx(ctx)
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	defer rollback(ctx, tx)
+	grant, err := s.getGrant(ctx, tx, log, tenantID, getGrantBySourceSQL(tenantID, sourceType, sourceID))
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	if grant.ID == "" {
+		return grants.Grant{}, grants.ErrGrantNotFound
+	}
+	err = tx.Commit()
+	if err != nil {
+		return grants.Grant{}, err
+	}
+	return fromSQLite(grant), nil
+}
@@ -0,0 +1,92 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/hex"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"lockbox.dev/grants"
+
+	uuid "github.com/hashicorp/go-uuid"
+	migrate "github.com/rubenv/sql-migrate"
+)
+
+// testAnonymizationKey is the key Storers created by a Factory derive
+// pseudonyms from.
+//
+//nolint:gochecknoglobals // never modified
+var testAnonymizationKey = []byte("storertest anonymization key")
+
+// Factory implements the storertest.Factory interface
+// for the Storer type; it offers a consistent
+// interface for setting up and tearing down Storers
+// for testing purposes.
+type Factory struct {
+	dir       string
+	databases []*sql.DB
+	lock      sync.Mutex
+}
+
+// NewFactory returns a Factory, ready to be used.
+// NewFactory must be called to obtain a usable Factory,
+// because Factory types have internal state that must
+// be initialized.
+func NewFactory() *Factory {
+	return &Factory{}
+}
+
+// NewStorer creates a new Storer, backed by a new database
+// file in a temporary directory, and returns it.
+func (f *Factory) NewStorer(ctx context.Context) (grants.Storer, error) { //nolint:ireturn // interface requires returning an interface
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.dir == "" {
+		dir, err := os.MkdirTemp("", "grants_test_")
+		if err != nil {
+			log.Printf("Error creating temporary directory: %+v\n", err)
+			return nil, err
+		}
+		f.dir = dir
+	}
+
+	suffix, err := uuid.GenerateRandomBytes(6) //nolint:gomnd // number is arbitrary, not magic
+	if err != nil {
+		log.Printf("Error generating UUID: %+v\n", err)
+		return nil, err
+	}
+	conn, err := Open(filepath.Join(f.dir, "grants_test_"+hex.EncodeToString(suffix)+".db"))
+	if err != nil {
+		return nil, err
+	}
+	f.databases = append(f.databases, conn)
+
+	err = ApplyMigrations(conn, migrate.Up)
+	if err != nil {
+		return nil, err
+	}
+
+	storer := NewStorer(ctx, conn, WithAnonymizationKey(testAnonymizationKey))
+	return storer, nil
+}
+
+// TeardownStorers closes all the databases created by
+// NewStorer and removes their files, cleaning up after
+// the Factory.
+func (f *Factory) TeardownStorers() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, conn := range f.databases {
+		err := conn.Close()
+		if err != nil {
+			return err
+		}
+	}
+	if f.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(f.dir)
+}
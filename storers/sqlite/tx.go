@@ -0,0 +1,31 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	yall "yall.in"
+)
+
+// beginTx starts a transaction. Connections opened with Open take the
+// database's write lock as soon as the transaction starts, so transactions
+// that read a Grant and then update it can't interleave with each other.
+func (s Storer) beginTx(ctx context.Context) (*sql.Tx, error) {
+	return s.db.BeginTx(ctx, nil)
+}
+
+// rollback rolls back `tx`, logging any error other than the transaction
+// already being committed or rolled back. It's safe to defer right after a
+// transaction is started.
+func rollback(ctx context.Context, tx *sql.Tx) {
+	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+		yall.FromContext(ctx).WithError(err).Error("error rolling back transaction")
+	}
+}
+
+func closeRows(ctx context.Context, rows *sql.Rows) {
+	if err := rows.Close(); err != nil {
+		yall.FromContext(ctx).WithError(err).Error("failed to close rows")
+	}
+}
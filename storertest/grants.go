@@ -0,0 +1,1384 @@
+package storertest
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+
+	"lockbox.dev/grants"
+)
+
+func testCreateAndExchangeGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	grant := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestCreateAndExchangeGrant",
+		AncestorIDs: []string{uuidOrFail(t), uuidOrFail(t)},
+		UsedAt:      time.Now().Add(time.Hour).Round(time.Millisecond),
+		Scopes:      []string{"https://scopes.impractical.co/test", "https://scopes.impractical.co/other/test"},
+		ProfileID:   "tester",
+		AccountID:   "test123",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Errorf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	use := grants.GrantUse{Grant: grant.ID, IP: "8.8.8.8", Time: time.Now().Round(time.Millisecond)}
+	resp, err := storer.ExchangeGrant(ctx, use)
+	if err != nil {
+		t.Errorf("Unexpected error exchanging grant in %T: %+v\n", storer, err)
+	}
+	expectation := grant
+	expectation.State = grants.GrantStateUsed
+	expectation.UseIP = "8.8.8.8"
+	expectation.UsedAt = use.Time
+	if diff := cmp.Diff(expectation, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func testCreateAndGetGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	grant := grants.Grant{
+		ID:            uuidOrFail(t),
+		SourceType:    "manual",
+		SourceID:      "TestCreateAndExchangeGrant",
+		AncestorIDs:   []string{uuidOrFail(t), uuidOrFail(t)},
+		UsedAt:        time.Now().Add(time.Hour).Round(time.Millisecond),
+		Scopes:        []string{"https://scopes.impractical.co/test", "https://scopes.impractical.co/other/test"},
+		ProfileID:     "tester",
+		AccountID:     "test123",
+		ClientID:      "testrunner",
+		State:         grants.GrantStateActive,
+		CreateIP:      "192.168.1.2",
+		KeyThumbprint: "NzbLsXh8uDCcd-6MNwXF4W_7noWXFZAfHkxZsRGC9Xs",
+	}
+	err := storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Errorf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	resp, err := storer.GetGrant(ctx, grant.ID)
+	if err != nil {
+		t.Errorf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	expectation := grant
+	if diff := cmp.Diff(expectation, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func testCreateAndGetRootGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	grant := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestCreateAndExchangeGrant",
+		AncestorIDs: []string{},
+		UsedAt:      time.Now().Add(time.Hour).Round(time.Millisecond),
+		Scopes:      []string{"https://scopes.impractical.co/test", "https://scopes.impractical.co/other/test"},
+		ProfileID:   "tester",
+		AccountID:   "test123",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Errorf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	resp, err := storer.GetGrant(ctx, grant.ID)
+	if err != nil {
+		t.Errorf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	expectation := grant
+	if diff := cmp.Diff(expectation, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func testCreateAndGetGrantBySource(ctx context.Context, t *testing.T, storer grants.Storer) {
+	grant := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestCreateAndGetGrantBySource",
+		AncestorIDs: []string{uuidOrFail(t), uuidOrFail(t)},
+		UsedAt:      time.Now().Add(time.Hour).Round(time.Millisecond),
+		Scopes:      []string{"https://scopes.impractical.co/test", "https://scopes.impractical.co/other/test"},
+		ProfileID:   "tester",
+		AccountID:   "test123",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Errorf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	resp, err := storer.GetGrantBySource(ctx, grant.SourceType, grant.SourceID)
+	if err != nil {
+		t.Errorf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	expectation := grant
+	if diff := cmp.Diff(expectation, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func testCreateAndExchangeUsedGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	grant := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestCreateAndExchangeUsedGrant",
+		AncestorIDs: []string{uuidOrFail(t), uuidOrFail(t)},
+		UsedAt:      time.Now().Add(time.Hour).Round(time.Millisecond),
+		Scopes:      []string{"https://scopes.impractical.co/test", "https://scopes.impractical.co/other/test"},
+		ProfileID:   "tester",
+		AccountID:   "test123",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Errorf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	_, err = storer.ExchangeGrant(ctx, grants.GrantUse{Grant: grant.ID, IP: "1.2.3.4", Time: time.Now().Round(time.Millisecond)})
+	if err != nil {
+		t.Errorf("Unexpected error exchanging grant in %T: %+v\n", storer, err)
+	}
+
+	_, err = storer.ExchangeGrant(ctx, grants.GrantUse{Grant: grant.ID, IP: "5.6.7.8", Time: time.Now().Round(time.Millisecond)})
+	if !errors.Is(err, grants.ErrGrantAlreadyUsed) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantAlreadyUsed, storer, err)
+	}
+}
+
+func testCreateAndExchangeRevokedGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	grant := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestCreateAndExchangeRevokedGrant",
+		AncestorIDs: []string{uuidOrFail(t), uuidOrFail(t)},
+		UsedAt:      time.Now().Add(time.Hour).Round(time.Millisecond),
+		Scopes:      []string{"https://scopes.impractical.co/test", "https://scopes.impractical.co/other/test"},
+		ProfileID:   "tester",
+		AccountID:   "test123",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Errorf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	_, err = storer.RevokeGrant(ctx, grant.ID)
+	if err != nil {
+		t.Errorf("Unexpected error exchanging grant in %T: %+v\n", storer, err)
+	}
+
+	_, err = storer.ExchangeGrant(ctx, grants.GrantUse{Grant: grant.ID, IP: "5.6.7.8", Time: time.Now().Round(time.Millisecond)})
+	if !errors.Is(err, grants.ErrGrantRevoked) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantAlreadyUsed, storer, err)
+	}
+}
+
+func testExchangeNonExistentGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	_, err := storer.ExchangeGrant(ctx, grants.GrantUse{
+		Grant: uuidOrFail(t),
+		IP:    "8.8.8.8",
+		Time:  time.Now().Round(time.Millisecond),
+	})
+	if !errors.Is(err, grants.ErrGrantNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+	}
+}
+
+func testGetNonExistentGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	_, err := storer.GetGrant(ctx, uuidOrFail(t))
+	if !errors.Is(err, grants.ErrGrantNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+	}
+}
+
+func testGetNonExistentGrantBySource(ctx context.Context, t *testing.T, storer grants.Storer) {
+	_, err := storer.GetGrantBySource(ctx, "test", "non-existent-grant")
+	if !errors.Is(err, grants.ErrGrantNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+	}
+}
+
+func testCreateDuplicateGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	grant := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestCreateDuplicateGrant",
+		AncestorIDs: []string{uuidOrFail(t), uuidOrFail(t)},
+		UsedAt:      time.Now().Add(time.Hour).Round(time.Millisecond),
+		Scopes:      []string{"https://scopes.impractical.co/test", "https://scopes.impractical.co/other/test"},
+		ProfileID:   "tester",
+		AccountID:   "test123",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Errorf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	grant.SourceID += "!"
+
+	err = storer.CreateGrant(ctx, grant)
+	if !errors.Is(err, grants.ErrGrantAlreadyExists) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantAlreadyExists, storer, err)
+	}
+}
+
+func testCreateDuplicateSourceGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	grant := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestCreateDuplicateSourceGrant",
+		AncestorIDs: []string{uuidOrFail(t), uuidOrFail(t)},
+		UsedAt:      time.Now().Add(time.Hour).Round(time.Millisecond),
+		Scopes:      []string{"https://scopes.impractical.co/test", "https://scopes.impractical.co/other/test"},
+		ProfileID:   "tester",
+		AccountID:   "test123",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Errorf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	grant.ID = uuidOrFail(t)
+
+	err = storer.CreateGrant(ctx, grant)
+	if !errors.Is(err, grants.ErrGrantSourceAlreadyUsed) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantSourceAlreadyUsed, storer, err)
+	}
+}
+
+func testCreateAndRevokeGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	grant := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestCreateAndRevokeGrant",
+		AncestorIDs: []string{uuidOrFail(t), uuidOrFail(t)},
+		UsedAt:      time.Now().Add(time.Hour).Round(time.Millisecond),
+		Scopes:      []string{"https://scopes.impractical.co/test", "https://scopes.impractical.co/other/test"},
+		ProfileID:   "tester",
+		AccountID:   "test123",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Errorf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	resp, err := storer.RevokeGrant(ctx, grant.ID)
+	if err != nil {
+		t.Errorf("Unexpected error exchanging grant in %T: %+v\n", storer, err)
+	}
+	expectation := grant
+	expectation.State = grants.GrantStateRevoked
+	if diff := cmp.Diff(expectation, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func testCreateAndRevokeUsedGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	grant := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestCreateAndRevokeUsedGrant",
+		AncestorIDs: []string{uuidOrFail(t), uuidOrFail(t)},
+		UsedAt:      time.Now().Add(time.Hour).Round(time.Millisecond),
+		Scopes:      []string{"https://scopes.impractical.co/test", "https://scopes.impractical.co/other/test"},
+		ProfileID:   "tester",
+		AccountID:   "test123",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Errorf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	_, err = storer.ExchangeGrant(ctx, grants.GrantUse{Grant: grant.ID, IP: "1.2.3.4", Time: time.Now().Round(time.Millisecond)})
+	if err != nil {
+		t.Errorf("Unexpected error exchanging grant in %T: %+v\n", storer, err)
+	}
+
+	_, err = storer.RevokeGrant(ctx, grant.ID)
+	if !errors.Is(err, grants.ErrGrantAlreadyUsed) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantAlreadyUsed, storer, err)
+	}
+}
+
+func testRevokeNonExistentGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	_, err := storer.RevokeGrant(ctx, uuidOrFail(t))
+	if !errors.Is(err, grants.ErrGrantNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+	}
+}
+
+func testHashedSources(ctx context.Context, t *testing.T, storer grants.Storer) {
+	oldKey := grants.SourceHashKey{ID: "old", Secret: []byte("old secret")}
+	newKey := grants.SourceHashKey{ID: "new", Secret: []byte("new secret")}
+	oldHasher, err := grants.NewSourceHasher(oldKey)
+	if err != nil {
+		t.Fatalf("Unexpected error creating hasher: %+v\n", err)
+	}
+	rotatedHasher, err := grants.NewSourceHasher(newKey, oldKey)
+	if err != nil {
+		t.Fatalf("Unexpected error creating hasher: %+v\n", err)
+	}
+
+	// a grant created before hashing was turned on
+	legacy := grants.Grant{
+		ID:         uuidOrFail(t),
+		SourceType: "manual",
+		SourceID:   "TestHashedSources-legacy",
+		ProfileID:  "tester",
+		ClientID:   "testrunner",
+		State:      grants.GrantStateActive,
+	}
+	err = storer.CreateGrant(ctx, legacy)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	// a grant created with the old key
+	hashed := grants.Grant{
+		ID:         uuidOrFail(t),
+		SourceType: "manual",
+		SourceID:   "TestHashedSources-hashed",
+		ProfileID:  "tester",
+		ClientID:   "testrunner",
+		State:      grants.GrantStateActive,
+	}
+	err = grants.WithHashedSources(storer, oldHasher).CreateGrant(ctx, hashed)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+	stored, err := storer.GetGrant(ctx, hashed.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	if !grants.IsHashedSourceID(stored.SourceID) || stored.SourceID == hashed.SourceID {
+		t.Errorf("Expected SourceID to be hashed at rest, got %q", stored.SourceID)
+	}
+
+	// after rotating keys, both are still found and can't be reused
+	wrapped := grants.WithHashedSources(storer, rotatedHasher)
+	for _, grant := range []grants.Grant{legacy, hashed} {
+		var resp grants.Grant
+		resp, err = wrapped.GetGrantBySource(ctx, grant.SourceType, grant.SourceID)
+		if err != nil {
+			t.Errorf("Unexpected error retrieving grant %s from %T: %+v\n", grant.ID, storer, err)
+		}
+		if resp.ID != grant.ID {
+			t.Errorf("Expected grant %s, got %s", grant.ID, resp.ID)
+		}
+		grant.ID = uuidOrFail(t)
+		err = wrapped.CreateGrant(ctx, grant)
+		if !errors.Is(err, grants.ErrGrantSourceAlreadyUsed) {
+			t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantSourceAlreadyUsed, storer, err)
+		}
+	}
+
+	_, err = wrapped.GetGrantBySource(ctx, "manual", "TestHashedSources-missing")
+	if !errors.Is(err, grants.ErrGrantNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+	}
+}
+
+func testAnonymizeProfile(ctx context.Context, t *testing.T, storer grants.Storer) {
+	profileID := uuidOrFail(t)
+	var scrubbed []grants.Grant
+	for i := 0; i < 2; i++ {
+		grant := grants.Grant{
+			ID:          uuidOrFail(t),
+			SourceType:  "manual",
+			SourceID:    "TestAnonymizeProfile-" + uuidOrFail(t),
+			AncestorIDs: []string{uuidOrFail(t)},
+			Scopes:      []string{"https://scopes.impractical.co/test"},
+			ProfileID:   profileID,
+			AccountID:   "test123",
+			ClientID:    "testrunner",
+			State:       grants.GrantStateActive,
+			CreateIP:    "192.168.1.2",
+		}
+		err := storer.CreateGrant(ctx, grant)
+		if err != nil {
+			t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+		}
+		scrubbed = append(scrubbed, grant)
+	}
+	_, err := storer.ExchangeGrant(ctx, grants.GrantUse{Grant: scrubbed[0].ID, IP: "8.8.8.8", Time: time.Now().Round(time.Millisecond)})
+	if err != nil {
+		t.Fatalf("Unexpected error exchanging grant in %T: %+v\n", storer, err)
+	}
+	untouched := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestAnonymizeProfile-untouched",
+		ProfileID:   uuidOrFail(t),
+		AccountID:   "test123",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.3",
+		AncestorIDs: []string{},
+	}
+	err = storer.CreateGrant(ctx, untouched)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	err = storer.AnonymizeProfile(ctx, profileID)
+	if err != nil {
+		t.Fatalf("Unexpected error anonymizing profile in %T: %+v\n", storer, err)
+	}
+
+	var pseudonym string
+	for _, grant := range scrubbed {
+		resp, err := storer.GetGrant(ctx, grant.ID)
+		if err != nil {
+			t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+		}
+		if resp.CreateIP != "" || resp.UseIP != "" {
+			t.Errorf("Expected IPs to be blanked, got %q and %q", resp.CreateIP, resp.UseIP)
+		}
+		if resp.ProfileID == profileID || resp.AccountID == grant.AccountID || resp.SourceID == grant.SourceID {
+			t.Errorf("Expected personal data to be replaced, got %+v", resp)
+		}
+		if pseudonym == "" {
+			pseudonym = resp.ProfileID
+		} else if resp.ProfileID != pseudonym {
+			t.Errorf("Expected stable pseudonym %q, got %q", pseudonym, resp.ProfileID)
+		}
+		// the audit trail is kept
+		if diff := cmp.Diff(grant.AncestorIDs, resp.AncestorIDs); diff != "" {
+			t.Errorf("Unexpected ancestors diff (-wanted, +got): %s", diff)
+		}
+		if diff := cmp.Diff(grant.Scopes, resp.Scopes); diff != "" {
+			t.Errorf("Unexpected scopes diff (-wanted, +got): %s", diff)
+		}
+		_, err = storer.GetGrantBySource(ctx, grant.SourceType, grant.SourceID)
+		if !errors.Is(err, grants.ErrGrantNotFound) {
+			t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+		}
+	}
+
+	resp, err := storer.GetGrant(ctx, untouched.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	if diff := cmp.Diff(untouched, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	// grants anonymized later get the same pseudonym, so they can still be
+	// connected to the ones anonymized before
+	later := scrubbed[1]
+	later.ID = uuidOrFail(t)
+	later.SourceID = "TestAnonymizeProfile-later"
+	err = storer.CreateGrant(ctx, later)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+	err = storer.AnonymizeProfile(ctx, profileID)
+	if err != nil {
+		t.Fatalf("Unexpected error anonymizing profile in %T: %+v\n", storer, err)
+	}
+	resp, err = storer.GetGrant(ctx, later.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	if resp.ProfileID != pseudonym {
+		t.Errorf("Expected pseudonym %q to be stable across calls, got %q", pseudonym, resp.ProfileID)
+	}
+}
+
+func testListGrantsByProfile(ctx context.Context, t *testing.T, storer grants.Storer) {
+	profileID := uuidOrFail(t)
+	var expected []grants.Grant
+	for i := 0; i < 5; i++ {
+		grant := grants.Grant{
+			ID:          uuidOrFail(t),
+			SourceType:  "manual",
+			SourceID:    "TestListGrantsByProfile-" + uuidOrFail(t),
+			AncestorIDs: []string{uuidOrFail(t)},
+			UsedAt:      time.Now().Add(time.Hour).Round(time.Millisecond),
+			Scopes:      []string{"https://scopes.impractical.co/test"},
+			ProfileID:   profileID,
+			AccountID:   "test123",
+			ClientID:    "testrunner",
+			State:       grants.GrantStateActive,
+			CreateIP:    "192.168.1.2",
+		}
+		err := storer.CreateGrant(ctx, grant)
+		if err != nil {
+			t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+		}
+		expected = append(expected, grant)
+	}
+	sort.Slice(expected, func(i, j int) bool { return expected[i].ID < expected[j].ID })
+
+	var got []grants.Grant
+	var after string
+	for {
+		page, err := storer.ListGrantsByProfile(ctx, profileID, after, 2)
+		if err != nil {
+			t.Fatalf("Unexpected error listing grants in %T: %+v\n", storer, err)
+		}
+		if len(page) > 2 {
+			t.Fatalf("Expected at most 2 grants, got %d", len(page))
+		}
+		if len(page) < 1 {
+			break
+		}
+		got = append(got, page...)
+		after = page[len(page)-1].ID
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	for _, limit := range []int{0, -1} {
+		page, err := storer.ListGrantsByProfile(ctx, profileID, "", limit)
+		if err != nil {
+			t.Fatalf("Unexpected error listing grants with a limit of %d in %T: %+v\n", limit, storer, err)
+		}
+		if len(page) != 0 {
+			t.Errorf("Expected no grants with a limit of %d in %T, got %d", limit, storer, len(page))
+		}
+	}
+}
+
+func testApplyIPRetention(ctx context.Context, t *testing.T, storer grants.Storer) {
+	retainer, ok := storer.(grants.IPRetainer)
+	if !ok {
+		t.Skipf("%T doesn't implement grants.IPRetainer", storer)
+	}
+	old := time.Now().Add(-2 * grants.DefaultIPRetentionPolicy.MaxAge).Round(time.Millisecond)
+	var expired []grants.Grant
+	for i := 0; i < 3; i++ {
+		grant := grants.Grant{
+			ID:          uuidOrFail(t),
+			SourceType:  "manual",
+			SourceID:    "TestApplyIPRetention-" + uuidOrFail(t),
+			AncestorIDs: []string{},
+			CreatedAt:   old,
+			Scopes:      []string{"https://scopes.impractical.co/test"},
+			ProfileID:   uuidOrFail(t),
+			AccountID:   "test123",
+			ClientID:    "testrunner",
+			State:       grants.GrantStateActive,
+			CreateIP:    "192.168.1.2",
+		}
+		err := storer.CreateGrant(ctx, grant)
+		if err != nil {
+			t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+		}
+		expired = append(expired, grant)
+	}
+	_, err := storer.ExchangeGrant(ctx, grants.GrantUse{Grant: expired[0].ID, IP: "2001:db8:85a3::8a2e:370:7334", Time: old})
+	if err != nil {
+		t.Fatalf("Unexpected error exchanging grant in %T: %+v\n", storer, err)
+	}
+	recent := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestApplyIPRetention-recent",
+		AncestorIDs: []string{},
+		CreatedAt:   time.Now().Round(time.Millisecond),
+		ProfileID:   uuidOrFail(t),
+		AccountID:   "test123",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.3",
+	}
+	err = storer.CreateGrant(ctx, recent)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	count, err := grants.ApplyIPRetention(ctx, retainer, grants.DefaultIPRetentionPolicy, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error applying IP retention in %T: %+v\n", storer, err)
+	}
+	if count != len(expired) {
+		t.Errorf("Expected %d grants to be updated, got %d", len(expired), count)
+	}
+	for pos, grant := range expired {
+		resp, err := storer.GetGrant(ctx, grant.ID)
+		if err != nil {
+			t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+		}
+		if resp.CreateIP != "192.168.1.0" {
+			t.Errorf("Expected create IP of grant %d to be truncated to %q, got %q", pos, "192.168.1.0", resp.CreateIP)
+		}
+		if pos == 0 && resp.UseIP != "2001:db8:85a3::" {
+			t.Errorf("Expected use IP of grant %d to be truncated to %q, got %q", pos, "2001:db8:85a3::", resp.UseIP)
+		}
+		if resp.ProfileID != grant.ProfileID || resp.SourceID != grant.SourceID {
+			t.Errorf("Expected only IPs to be changed, got %+v", resp)
+		}
+	}
+	resp, err := storer.GetGrant(ctx, recent.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	if diff := cmp.Diff(recent, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	// running the job again has nothing left to do
+	count, err = grants.ApplyIPRetention(ctx, retainer, grants.DefaultIPRetentionPolicy, 2)
+	if err != nil {
+		t.Fatalf("Unexpected error applying IP retention in %T: %+v\n", storer, err)
+	}
+	if count != 0 {
+		t.Errorf("Expected no grants to be updated, got %d", count)
+	}
+}
+
+func testTenantIsolation(ctx context.Context, t *testing.T, storer grants.Storer) {
+	acme := grants.WithTenant(ctx, "acme")
+	globex := grants.WithTenant(ctx, "globex")
+
+	grant := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestTenantIsolation",
+		AncestorIDs: []string{uuidOrFail(t)},
+		CreatedAt:   time.Now().Round(time.Millisecond),
+		UsedAt:      time.Now().Add(time.Hour).Round(time.Millisecond),
+		Scopes:      []string{"https://scopes.impractical.co/test"},
+		ProfileID:   uuidOrFail(t),
+		AccountID:   "test123",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(acme, grant)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+	grant.TenantID = "acme"
+
+	resp, err := storer.GetGrant(acme, grant.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	if diff := cmp.Diff(grant, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	// every other tenant, including the default one, acts as if the
+	// grant doesn't exist
+	for name, other := range map[string]context.Context{"globex": globex, "default": ctx} {
+		_, err = storer.GetGrant(other, grant.ID)
+		if !errors.Is(err, grants.ErrGrantNotFound) {
+			t.Errorf("Expected error to be %v getting grant as %s, %T returned %v\n", grants.ErrGrantNotFound, name, storer, err)
+		}
+		_, err = storer.GetGrantBySource(other, grant.SourceType, grant.SourceID)
+		if !errors.Is(err, grants.ErrGrantNotFound) {
+			t.Errorf("Expected error to be %v getting grant by source as %s, %T returned %v\n", grants.ErrGrantNotFound, name, storer, err)
+		}
+		_, err = storer.ExchangeGrant(other, grants.GrantUse{Grant: grant.ID, IP: "8.8.8.8", Time: time.Now()})
+		if !errors.Is(err, grants.ErrGrantNotFound) {
+			t.Errorf("Expected error to be %v exchanging grant as %s, %T returned %v\n", grants.ErrGrantNotFound, name, storer, err)
+		}
+		_, err = storer.RevokeGrant(other, grant.ID)
+		if !errors.Is(err, grants.ErrGrantNotFound) {
+			t.Errorf("Expected error to be %v revoking grant as %s, %T returned %v\n", grants.ErrGrantNotFound, name, storer, err)
+		}
+		var list []grants.Grant
+		list, err = storer.ListGrantsByProfile(other, grant.ProfileID, "", 10)
+		if err != nil {
+			t.Fatalf("Unexpected error listing grants in %T: %+v\n", storer, err)
+		}
+		if len(list) != 0 {
+			t.Errorf("Expected no grants listing as %s, got %+v", name, list)
+		}
+		err = storer.AnonymizeProfile(other, grant.ProfileID)
+		if err != nil {
+			t.Fatalf("Unexpected error anonymizing profile in %T: %+v\n", storer, err)
+		}
+	}
+
+	// the grant wasn't modified by any of that
+	resp, err = storer.GetGrant(acme, grant.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	if diff := cmp.Diff(grant, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	// sources are only unique within a tenant
+	other := grant
+	other.ID = uuidOrFail(t)
+	other.TenantID = ""
+	err = storer.CreateGrant(globex, other)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant with the same source in another tenant in %T: %+v\n", storer, err)
+	}
+	dupe := grant
+	dupe.ID = uuidOrFail(t)
+	err = storer.CreateGrant(acme, dupe)
+	if !errors.Is(err, grants.ErrGrantSourceAlreadyUsed) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantSourceAlreadyUsed, storer, err)
+	}
+
+	// a grant can't be created for a tenant other than the one in
+	// the context
+	mismatched := grant
+	mismatched.ID = uuidOrFail(t)
+	mismatched.SourceID = "TestTenantIsolation-mismatched"
+	err = storer.CreateGrant(globex, mismatched)
+	if !errors.Is(err, grants.ErrTenantMismatch) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrTenantMismatch, storer, err)
+	}
+}
+
+func testExplicitTenant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	// the context isn't scoped to a tenant, so the tenant set on the
+	// grant is used
+	grant := grants.Grant{
+		ID:          uuidOrFail(t),
+		TenantID:    "acme",
+		SourceType:  "manual",
+		SourceID:    "TestExplicitTenant",
+		AncestorIDs: []string{uuidOrFail(t)},
+		CreatedAt:   time.Now().Round(time.Millisecond),
+		Scopes:      []string{"https://scopes.impractical.co/test"},
+		ProfileID:   uuidOrFail(t),
+		AccountID:   "test123",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	acme := grants.WithTenant(ctx, "acme")
+	resp, err := storer.GetGrant(acme, grant.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	if diff := cmp.Diff(grant, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+	resp, err = storer.GetGrantBySource(acme, grant.SourceType, grant.SourceID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant by source from %T: %+v\n", storer, err)
+	}
+	if diff := cmp.Diff(grant, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	// and it's not in the default tenant
+	_, err = storer.GetGrant(ctx, grant.ID)
+	if !errors.Is(err, grants.ErrGrantNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+	}
+}
+
+func testRotateGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	parent := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestRotateGrant",
+		AncestorIDs: []string{uuidOrFail(t), uuidOrFail(t)},
+		Scopes:      []string{"https://scopes.impractical.co/test"},
+		ProfileID:   "tester",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreatedAt:   time.Now().Round(time.Millisecond),
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(ctx, parent)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	child := parent
+	child.ID = uuidOrFail(t)
+	child.SourceType = "refresh_token"
+	child.SourceID = "TestRotateGrant-child"
+	child.AncestorIDs = nil
+	use := grants.GrantUse{Grant: parent.ID, IP: "8.8.8.8", Time: time.Now().Round(time.Millisecond)}
+	resp, err := storer.RotateGrant(ctx, use, child)
+	if err != nil {
+		t.Fatalf("Unexpected error rotating grant in %T: %+v\n", storer, err)
+	}
+	expectation := child
+	expectation.AncestorIDs = []string{parent.AncestorIDs[0], parent.AncestorIDs[1], parent.ID}
+	if diff := cmp.Diff(expectation, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	stored, err := storer.GetGrant(ctx, child.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving child grant from %T: %+v\n", storer, err)
+	}
+	if diff := cmp.Diff(expectation, stored); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	used, err := storer.GetGrant(ctx, parent.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving parent grant from %T: %+v\n", storer, err)
+	}
+	if !used.Used() || used.UseIP != use.IP || !used.UsedAt.Equal(use.Time) {
+		t.Errorf("Expected parent grant to be used by %+v, got %+v\n", use, used)
+	}
+
+	// a used grant can't be rotated again, and no child is created
+	again := child
+	again.ID = uuidOrFail(t)
+	again.SourceID = "TestRotateGrant-again"
+	_, err = storer.RotateGrant(ctx, grants.GrantUse{Grant: parent.ID, IP: "5.6.7.8", Time: time.Now().Round(time.Millisecond)}, again)
+	if !errors.Is(err, grants.ErrGrantAlreadyUsed) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantAlreadyUsed, storer, err)
+	}
+	_, err = storer.GetGrant(ctx, again.ID)
+	if !errors.Is(err, grants.ErrGrantNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+	}
+}
+
+func testRotateGrantExplicitTenant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	acme := grants.WithTenant(ctx, "acme")
+	parent := grants.Grant{
+		ID:         uuidOrFail(t),
+		TenantID:   "acme",
+		SourceType: "manual",
+		SourceID:   "TestRotateGrantExplicitTenant",
+		Scopes:     []string{"https://scopes.impractical.co/test"},
+		ProfileID:  "tester",
+		ClientID:   "testrunner",
+		State:      grants.GrantStateActive,
+		CreatedAt:  time.Now().Round(time.Millisecond),
+	}
+	err := storer.CreateGrant(acme, parent)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	// a default tenant child can't be created from a parent in another
+	// tenant, because the parent isn't visible from the default tenant
+	child := parent
+	child.ID = uuidOrFail(t)
+	child.TenantID = ""
+	child.SourceType = "refresh_token"
+	child.SourceID = "TestRotateGrantExplicitTenant-child"
+	use := grants.GrantUse{Grant: parent.ID, IP: "8.8.8.8", Time: time.Now().Round(time.Millisecond)}
+	_, err = storer.RotateGrant(ctx, use, child)
+	if !errors.Is(err, grants.ErrGrantNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+	}
+
+	// setting the tenant on the child instead of the context keeps the
+	// whole family in that tenant
+	child.TenantID = "acme"
+	resp, err := storer.RotateGrant(ctx, use, child)
+	if err != nil {
+		t.Fatalf("Unexpected error rotating grant in %T: %+v\n", storer, err)
+	}
+	expectation := child
+	expectation.AncestorIDs = []string{parent.ID}
+	if diff := cmp.Diff(expectation, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+	stored, err := storer.GetGrant(acme, child.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving child grant from %T: %+v\n", storer, err)
+	}
+	if diff := cmp.Diff(expectation, stored); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+	used, err := storer.GetGrant(acme, parent.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving parent grant from %T: %+v\n", storer, err)
+	}
+	if !used.Used() {
+		t.Errorf("Expected parent grant to be used, got %+v\n", used)
+	}
+}
+
+func testRotateGrantFailedCreate(ctx context.Context, t *testing.T, storer grants.Storer) {
+	parent := grants.Grant{
+		ID:         uuidOrFail(t),
+		SourceType: "manual",
+		SourceID:   "TestRotateGrantFailedCreate",
+		Scopes:     []string{"https://scopes.impractical.co/test"},
+		ProfileID:  "tester",
+		ClientID:   "testrunner",
+		State:      grants.GrantStateActive,
+		CreatedAt:  time.Now().Round(time.Millisecond),
+	}
+	err := storer.CreateGrant(ctx, parent)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+
+	// reusing the parent's source means the child can't be created
+	child := parent
+	child.ID = uuidOrFail(t)
+	_, err = storer.RotateGrant(ctx, grants.GrantUse{Grant: parent.ID, IP: "8.8.8.8", Time: time.Now().Round(time.Millisecond)}, child)
+	if !errors.Is(err, grants.ErrGrantSourceAlreadyUsed) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantSourceAlreadyUsed, storer, err)
+	}
+
+	// which means the parent must not have been used either
+	resp, err := storer.GetGrant(ctx, parent.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	if resp.Used() {
+		t.Errorf("Expected parent grant to be unused after a failed rotation in %T, got %+v\n", storer, resp)
+	}
+	_, err = storer.GetGrant(ctx, child.ID)
+	if !errors.Is(err, grants.ErrGrantNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+	}
+}
+
+func testRevokeGrantFamily(ctx context.Context, t *testing.T, storer grants.Storer) {
+	families, ok := storer.(grants.FamilyRevoker)
+	if !ok {
+		t.Skipf("%T doesn't implement grants.FamilyRevoker", storer)
+	}
+	newGrant := func(sourceID string, ancestors ...string) grants.Grant {
+		return grants.Grant{
+			ID:          uuidOrFail(t),
+			SourceType:  "manual",
+			SourceID:    "TestRevokeGrantFamily-" + sourceID,
+			AncestorIDs: ancestors,
+			ProfileID:   "tester",
+			ClientID:    "testrunner",
+			State:       grants.GrantStateActive,
+			CreatedAt:   time.Now().Round(time.Millisecond),
+		}
+	}
+	root := newGrant("root")
+	err := storer.CreateGrant(ctx, root)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+	first, err := storer.RotateGrant(ctx, grants.GrantUse{Grant: root.ID, Time: time.Now()}, newGrant("first"))
+	if err != nil {
+		t.Fatalf("Unexpected error rotating grant in %T: %+v\n", storer, err)
+	}
+	second, err := storer.RotateGrant(ctx, grants.GrantUse{Grant: first.ID, Time: time.Now()}, newGrant("second"))
+	if err != nil {
+		t.Fatalf("Unexpected error rotating grant in %T: %+v\n", storer, err)
+	}
+	// a sibling branch of the family, descended from the root
+	sibling := newGrant("sibling", root.ID)
+	unrelated := newGrant("unrelated")
+	for _, grant := range []grants.Grant{sibling, unrelated} {
+		err = storer.CreateGrant(ctx, grant)
+		if err != nil {
+			t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+		}
+	}
+
+	// revoking through a used member of the family revokes every
+	// member that can still be revoked
+	revoked, err := families.RevokeGrantFamily(ctx, first.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error revoking grant family in %T: %+v\n", storer, err)
+	}
+	expected := []string{second.ID, sibling.ID}
+	sort.Strings(expected)
+	if diff := cmp.Diff(expected, revoked); diff != "" {
+		t.Errorf("Unexpected diff in revoked grants (-wanted, +got): %s", diff)
+	}
+
+	states := map[string]grants.GrantState{
+		root.ID:      grants.GrantStateUsed,
+		first.ID:     grants.GrantStateUsed,
+		second.ID:    grants.GrantStateRevoked,
+		sibling.ID:   grants.GrantStateRevoked,
+		unrelated.ID: grants.GrantStateActive,
+	}
+	for id, state := range states {
+		grant, err := storer.GetGrant(ctx, id)
+		if err != nil {
+			t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+		}
+		if grant.State != state {
+			t.Errorf("Expected grant %s to be %q in %T, got %q\n", id, state, storer, grant.State)
+		}
+	}
+
+	// revoking again has nothing left to revoke
+	revoked, err = families.RevokeGrantFamily(ctx, second.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error revoking grant family in %T: %+v\n", storer, err)
+	}
+	if len(revoked) != 0 {
+		t.Errorf("Expected no grants to be revoked in %T, got %v\n", storer, revoked)
+	}
+
+	_, err = families.RevokeGrantFamily(ctx, uuidOrFail(t))
+	if !errors.Is(err, grants.ErrGrantNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+	}
+}
+
+func testExchangePendingGrant(ctx context.Context, t *testing.T, storer grants.Storer) {
+	pending, ok := storer.(grants.PendingExchanger)
+	if !ok {
+		t.Skipf("%T doesn't implement grants.PendingExchanger", storer)
+	}
+	grant := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    "TestExchangePendingGrant",
+		AncestorIDs: []string{uuidOrFail(t)},
+		ProfileID:   "tester",
+		ClientID:    "testrunner",
+		State:       grants.GrantStatePending,
+		CreateIP:    "192.168.1.2",
+		CreatedAt:   time.Now().Round(time.Millisecond),
+	}
+	active := grant
+	active.ID = uuidOrFail(t)
+	active.SourceID = "TestExchangePendingGrant-active"
+	active.State = grants.GrantStateActive
+	for _, g := range []grants.Grant{grant, active} {
+		err := storer.CreateGrant(ctx, g)
+		if err != nil {
+			t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+		}
+	}
+
+	use := grants.GrantUse{Grant: grant.ID, IP: "8.8.8.8", Time: time.Now().Round(time.Millisecond)}
+	resp, err := pending.ExchangePendingGrant(ctx, use)
+	if err != nil {
+		t.Fatalf("Unexpected error exchanging pending grant in %T: %+v\n", storer, err)
+	}
+	expectation := grant
+	expectation.State = grants.GrantStateUsed
+	expectation.UseIP = "8.8.8.8"
+	expectation.UsedAt = use.Time
+	if diff := cmp.Diff(expectation, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	_, err = pending.ExchangePendingGrant(ctx, use)
+	if !errors.Is(err, grants.ErrGrantAlreadyUsed) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantAlreadyUsed, storer, err)
+	}
+
+	// active grants aren't pending, and are left as they are
+	_, err = pending.ExchangePendingGrant(ctx, grants.GrantUse{Grant: active.ID, IP: "8.8.8.8", Time: time.Now()})
+	if !errors.Is(err, grants.ErrInvalidGrantTransition) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrInvalidGrantTransition, storer, err)
+	}
+	got, err := storer.GetGrant(ctx, active.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	if got.State != grants.GrantStateActive {
+		t.Errorf("Expected grant to still be %q in %T, got %q\n", grants.GrantStateActive, storer, got.State)
+	}
+
+	_, err = pending.ExchangePendingGrant(ctx, grants.GrantUse{Grant: uuidOrFail(t), Time: time.Now()})
+	if !errors.Is(err, grants.ErrGrantNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+	}
+}
+
+func testPurgeDeviceAuthorizations(ctx context.Context, t *testing.T, storer grants.Storer) {
+	devices, ok := storer.(grants.DeviceStorer)
+	if !ok {
+		t.Skipf("%T doesn't implement grants.DeviceStorer", storer)
+	}
+	purger, ok := storer.(grants.DeviceAuthorizationPurger)
+	if !ok {
+		t.Skipf("%T doesn't implement grants.DeviceAuthorizationPurger", storer)
+	}
+	now := time.Now().Round(time.Millisecond)
+	newAuth := func(userCode string, expiresAt time.Time, status grants.DeviceStatus) grants.DeviceAuthorization {
+		return grants.DeviceAuthorization{
+			ID:        uuidOrFail(t),
+			UserCode:  userCode,
+			ClientID:  "testrunner",
+			Scopes:    []string{"https://scopes.impractical.co/test"},
+			CreatedAt: expiresAt.Add(-10 * time.Minute),
+			ExpiresAt: expiresAt,
+			Interval:  5 * time.Second,
+			Status:    status,
+		}
+	}
+	expired := newAuth("BCDF-GHJK", now.Add(-time.Hour), grants.DeviceStatusPending)
+	denied := newAuth("LMNP-QRST", now.Add(-time.Hour), grants.DeviceStatusDenied)
+	current := newAuth("VWXZ-BCDF", now.Add(time.Hour), grants.DeviceStatusPending)
+	err := devices.CreateDeviceAuthorization(ctx, expired)
+	if err != nil {
+		t.Fatalf("Unexpected error creating device authorization in %T: %+v\n", storer, err)
+	}
+	// expired authorizations are purged from every tenant
+	err = devices.CreateDeviceAuthorization(grants.WithTenant(ctx, "globex"), denied)
+	if err != nil {
+		t.Fatalf("Unexpected error creating device authorization in %T: %+v\n", storer, err)
+	}
+	err = devices.CreateDeviceAuthorization(ctx, current)
+	if err != nil {
+		t.Fatalf("Unexpected error creating device authorization in %T: %+v\n", storer, err)
+	}
+
+	// a batch size of 1 makes sure every batch is worked through
+	purged, err := grants.PurgeDeviceAuthorizations(ctx, purger, time.Minute, 1)
+	if err != nil {
+		t.Fatalf("Unexpected error purging device authorizations in %T: %+v\n", storer, err)
+	}
+	if purged != 2 {
+		t.Errorf("Expected 2 device authorizations to be purged from %T, got %d", storer, purged)
+	}
+	_, err = devices.GetDeviceAuthorization(ctx, expired.ID)
+	if !errors.Is(err, grants.ErrDeviceAuthorizationNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrDeviceAuthorizationNotFound, storer, err)
+	}
+	_, err = devices.GetDeviceAuthorization(grants.WithTenant(ctx, "globex"), denied.ID)
+	if !errors.Is(err, grants.ErrDeviceAuthorizationNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrDeviceAuthorizationNotFound, storer, err)
+	}
+	resp, err := devices.GetDeviceAuthorization(ctx, current.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving device authorization from %T: %+v\n", storer, err)
+	}
+	if diff := cmp.Diff(current, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	// the user code of a purged authorization can be used again
+	err = devices.CreateDeviceAuthorization(ctx, newAuth(expired.UserCode, now.Add(time.Hour), grants.DeviceStatusPending))
+	if err != nil {
+		t.Errorf("Unexpected error reusing a purged user code in %T: %+v\n", storer, err)
+	}
+}
+
+func testDeviceAuthorizations(ctx context.Context, t *testing.T, storer grants.Storer) {
+	devices, ok := storer.(grants.DeviceStorer)
+	if !ok {
+		t.Skipf("%T doesn't implement grants.DeviceStorer", storer)
+	}
+	created := time.Now().Round(time.Millisecond)
+	auth := grants.DeviceAuthorization{
+		ID:        uuidOrFail(t),
+		UserCode:  "BCDF-GHJK",
+		ClientID:  "testrunner",
+		Scopes:    []string{"https://scopes.impractical.co/test"},
+		CreatedAt: created,
+		ExpiresAt: created.Add(10 * time.Minute),
+		Interval:  5 * time.Second,
+		Status:    grants.DeviceStatusPending,
+	}
+	err := devices.CreateDeviceAuthorization(ctx, auth)
+	if err != nil {
+		t.Fatalf("Unexpected error creating device authorization in %T: %+v\n", storer, err)
+	}
+
+	dupe := auth
+	dupe.ID = uuidOrFail(t)
+	err = devices.CreateDeviceAuthorization(ctx, dupe)
+	if !errors.Is(err, grants.ErrDeviceAuthorizationAlreadyExists) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrDeviceAuthorizationAlreadyExists, storer, err)
+	}
+
+	// user codes are only unique within a tenant
+	err = devices.CreateDeviceAuthorization(grants.WithTenant(ctx, "globex"), dupe)
+	if err != nil {
+		t.Errorf("Unexpected error creating device authorization in another tenant in %T: %+v\n", storer, err)
+	}
+
+	resp, err := devices.GetDeviceAuthorizationByUserCode(ctx, auth.UserCode)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving device authorization from %T: %+v\n", storer, err)
+	}
+	if diff := cmp.Diff(auth, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	polled, err := devices.PollDeviceAuthorization(ctx, auth.ID, created.Add(time.Second))
+	if !errors.Is(err, grants.ErrDeviceAuthorizationPending) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrDeviceAuthorizationPending, storer, err)
+	}
+	_, err = devices.PollDeviceAuthorization(ctx, auth.ID, created.Add(2*time.Second))
+	if !errors.Is(err, grants.ErrDeviceSlowDown) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrDeviceSlowDown, storer, err)
+	}
+	resp, err = devices.GetDeviceAuthorization(ctx, auth.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving device authorization from %T: %+v\n", storer, err)
+	}
+	expectation := polled
+	expectation.LastPolledAt = created.Add(2 * time.Second)
+	expectation.Interval += grants.DeviceSlowDownIncrement
+	if diff := cmp.Diff(expectation, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+
+	decided, err := devices.DecideDeviceAuthorization(ctx, grants.DeviceDecision{
+		UserCode:  auth.UserCode,
+		Status:    grants.DeviceStatusApproved,
+		ProfileID: "tester",
+		GrantID:   "grant",
+		Time:      created.Add(time.Minute),
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error deciding device authorization in %T: %+v\n", storer, err)
+	}
+	expectation.Status = grants.DeviceStatusApproved
+	expectation.ProfileID = "tester"
+	expectation.GrantID = "grant"
+	if diff := cmp.Diff(expectation, decided); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+	_, err = devices.DecideDeviceAuthorization(ctx, grants.DeviceDecision{
+		UserCode: auth.UserCode,
+		Status:   grants.DeviceStatusDenied,
+		Time:     created.Add(time.Minute),
+	})
+	if !errors.Is(err, grants.ErrDeviceAuthorizationNotPending) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrDeviceAuthorizationNotPending, storer, err)
+	}
+
+	// other tenants can't see the authorization
+	_, err = devices.GetDeviceAuthorization(grants.WithTenant(ctx, "globex"), auth.ID)
+	if !errors.Is(err, grants.ErrDeviceAuthorizationNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrDeviceAuthorizationNotFound, storer, err)
+	}
+
+	// the tenant can be set on the authorization instead of the context
+	explicit := auth
+	explicit.ID = uuidOrFail(t)
+	explicit.TenantID = "acme"
+	err = devices.CreateDeviceAuthorization(ctx, explicit)
+	if err != nil {
+		t.Fatalf("Unexpected error creating device authorization in %T: %+v\n", storer, err)
+	}
+	resp, err = devices.GetDeviceAuthorization(grants.WithTenant(ctx, "acme"), explicit.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving device authorization from %T: %+v\n", storer, err)
+	}
+	if diff := cmp.Diff(explicit, resp); diff != "" {
+		t.Errorf("Unexpected diff (-wanted, +got): %s", diff)
+	}
+}
+
+func testGrantStates(ctx context.Context, t *testing.T, storer grants.Storer) {
+	// grants are active unless created in another state
+	active := grants.Grant{
+		ID:         uuidOrFail(t),
+		SourceType: "manual",
+		SourceID:   "TestGrantStates-active",
+		ProfileID:  "tester",
+		ClientID:   "testrunner",
+	}
+	err := storer.CreateGrant(ctx, active)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+	resp, err := storer.GetGrant(ctx, active.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	if resp.State != grants.GrantStateActive {
+		t.Errorf("Expected grant to be %q in %T, got %q\n", grants.GrantStateActive, storer, resp.State)
+	}
+
+	// grants can't be created in a final state
+	used := active
+	used.ID = uuidOrFail(t)
+	used.SourceID = "TestGrantStates-used"
+	used.State = grants.GrantStateUsed
+	err = storer.CreateGrant(ctx, used)
+	if !errors.Is(err, grants.ErrInvalidGrantState) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrInvalidGrantState, storer, err)
+	}
+
+	// pending grants can't be exchanged until they're active
+	pending := active
+	pending.ID = uuidOrFail(t)
+	pending.SourceID = "TestGrantStates-pending"
+	pending.State = grants.GrantStatePending
+	err = storer.CreateGrant(ctx, pending)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+	use := grants.GrantUse{Grant: pending.ID, IP: "8.8.8.8", Time: time.Now().Round(time.Millisecond)}
+	_, err = storer.ExchangeGrant(ctx, use)
+	if !errors.Is(err, grants.ErrGrantPending) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantPending, storer, err)
+	}
+	resp, err = storer.TransitionGrant(ctx, pending.ID, grants.GrantStateActive)
+	if err != nil {
+		t.Fatalf("Unexpected error activating grant in %T: %+v\n", storer, err)
+	}
+	if resp.State != grants.GrantStateActive {
+		t.Errorf("Expected grant to be %q in %T, got %q\n", grants.GrantStateActive, storer, resp.State)
+	}
+	resp, err = storer.ExchangeGrant(ctx, use)
+	if err != nil {
+		t.Fatalf("Unexpected error exchanging grant in %T: %+v\n", storer, err)
+	}
+	if !resp.Used() || resp.State != grants.GrantStateUsed {
+		t.Errorf("Expected grant to be %q in %T, got %q\n", grants.GrantStateUsed, storer, resp.State)
+	}
+
+	// final states can't be left
+	_, err = storer.TransitionGrant(ctx, pending.ID, grants.GrantStateExpired)
+	if !errors.Is(err, grants.ErrGrantAlreadyUsed) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantAlreadyUsed, storer, err)
+	}
+	_, err = storer.TransitionGrant(ctx, active.ID, grants.GrantStateExpired)
+	if err != nil {
+		t.Fatalf("Unexpected error expiring grant in %T: %+v\n", storer, err)
+	}
+	_, err = storer.ExchangeGrant(ctx, grants.GrantUse{Grant: active.ID, IP: "8.8.8.8", Time: time.Now().Round(time.Millisecond)})
+	if !errors.Is(err, grants.ErrGrantExpired) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantExpired, storer, err)
+	}
+	_, err = storer.RevokeGrant(ctx, active.ID)
+	if !errors.Is(err, grants.ErrGrantExpired) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantExpired, storer, err)
+	}
+
+	// used grants can't go back to pending
+	_, err = storer.TransitionGrant(ctx, pending.ID, grants.GrantStatePending)
+	if !errors.Is(err, grants.ErrGrantAlreadyUsed) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantAlreadyUsed, storer, err)
+	}
+	_, err = storer.TransitionGrant(ctx, uuidOrFail(t), grants.GrantStateRevoked)
+	if !errors.Is(err, grants.ErrGrantNotFound) {
+		t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+	}
+}
@@ -0,0 +1,209 @@
+package storertest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"lockbox.dev/grants"
+)
+
+// racers is the number of goroutines each race test runs at once.
+const racers = 10
+
+// race calls `f` from `racers` goroutines at as close to the same time as it
+// can, passing each its index, and returns the error each call returned.
+func race(f func(i int) error) []error {
+	errs := make([]error, racers)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < racers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			errs[i] = f(i)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return errs
+}
+
+// winner returns the index of the only nil error in `errs`, failing the test
+// if there isn't exactly one, or if any other error isn't `expected`.
+func winner(t *testing.T, storer grants.Storer, errs []error, expected error) int {
+	t.Helper()
+	won := -1
+	for i, err := range errs {
+		if err == nil {
+			if won >= 0 {
+				t.Errorf("Expected only one call to succeed in %T, but %d and %d both did", storer, won, i)
+			}
+			won = i
+			continue
+		}
+		if !errors.Is(err, expected) {
+			t.Errorf("Expected error to be %v, %T returned %v\n", expected, storer, err)
+		}
+	}
+	if won < 0 {
+		t.Fatalf("Expected one call to succeed in %T, but none did", storer)
+	}
+	return won
+}
+
+func createRaceGrantOrFail(ctx context.Context, t *testing.T, storer grants.Storer, sourceID string) grants.Grant {
+	t.Helper()
+	grant := grants.Grant{
+		ID:          uuidOrFail(t),
+		SourceType:  "manual",
+		SourceID:    sourceID,
+		AncestorIDs: []string{},
+		Scopes:      []string{"https://scopes.impractical.co/test"},
+		ProfileID:   "tester",
+		ClientID:    "testrunner",
+		State:       grants.GrantStateActive,
+		CreatedAt:   time.Now().Round(time.Millisecond),
+		CreateIP:    "192.168.1.2",
+	}
+	err := storer.CreateGrant(ctx, grant)
+	if err != nil {
+		t.Fatalf("Unexpected error creating grant in %T: %+v\n", storer, err)
+	}
+	return grant
+}
+
+func testConcurrentExchange(ctx context.Context, t *testing.T, storer grants.Storer) {
+	grant := createRaceGrantOrFail(ctx, t, storer, "TestConcurrentExchange")
+
+	uses := make([]grants.GrantUse, racers)
+	for i := range uses {
+		uses[i] = grants.GrantUse{Grant: grant.ID, IP: fmt.Sprintf("10.0.0.%d", i), Time: time.Now().Round(time.Millisecond)}
+	}
+	errs := race(func(i int) error {
+		_, err := storer.ExchangeGrant(ctx, uses[i])
+		return err
+	})
+	won := winner(t, storer, errs, grants.ErrGrantAlreadyUsed)
+
+	// the grant records the use that won
+	stored, err := storer.GetGrant(ctx, grant.ID)
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	if !stored.Used() || stored.UseIP != uses[won].IP {
+		t.Errorf("Expected grant to be used from %s, got %+v\n", uses[won].IP, stored)
+	}
+}
+
+func testConcurrentRotate(ctx context.Context, t *testing.T, storer grants.Storer) {
+	parent := createRaceGrantOrFail(ctx, t, storer, "TestConcurrentRotate")
+
+	children := make([]grants.Grant, racers)
+	for i := range children {
+		child := parent
+		child.ID = uuidOrFail(t)
+		child.SourceType = "refresh_token"
+		child.SourceID = fmt.Sprintf("TestConcurrentRotate-%d", i)
+		child.AncestorIDs = nil
+		children[i] = child
+	}
+	errs := race(func(i int) error {
+		use := grants.GrantUse{Grant: parent.ID, IP: "8.8.8.8", Time: time.Now().Round(time.Millisecond)}
+		_, err := storer.RotateGrant(ctx, use, children[i])
+		return err
+	})
+	won := winner(t, storer, errs, grants.ErrGrantAlreadyUsed)
+
+	// only the winning child exists
+	for i, child := range children {
+		_, err := storer.GetGrant(ctx, child.ID)
+		if i == won && err != nil {
+			t.Errorf("Unexpected error retrieving winning child from %T: %+v\n", storer, err)
+		}
+		if i != won && !errors.Is(err, grants.ErrGrantNotFound) {
+			t.Errorf("Expected error to be %v for losing child, %T returned %v\n", grants.ErrGrantNotFound, storer, err)
+		}
+	}
+}
+
+func testConcurrentExchangeAndRevoke(ctx context.Context, t *testing.T, storer grants.Storer) {
+	// each grant gets exchanged and revoked at the same time; whichever
+	// goes first, the other has to notice
+	for i := 0; i < racers; i++ {
+		grant := createRaceGrantOrFail(ctx, t, storer, fmt.Sprintf("TestConcurrentExchangeAndRevoke-%d", i))
+
+		var exchangeErr, revokeErr error
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		wg.Add(2) //nolint:gomnd // the exchange and the revoke
+		go func() {
+			defer wg.Done()
+			<-start
+			_, exchangeErr = storer.ExchangeGrant(ctx, grants.GrantUse{Grant: grant.ID, IP: "8.8.8.8", Time: time.Now().Round(time.Millisecond)})
+		}()
+		go func() {
+			defer wg.Done()
+			<-start
+			_, revokeErr = storer.RevokeGrant(ctx, grant.ID)
+		}()
+		close(start)
+		wg.Wait()
+
+		stored, err := storer.GetGrant(ctx, grant.ID)
+		if err != nil {
+			t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+		}
+		switch {
+		case exchangeErr == nil && revokeErr == nil:
+			t.Errorf("Expected only one of exchange and revoke to succeed in %T, both did", storer)
+		case exchangeErr == nil:
+			if !errors.Is(revokeErr, grants.ErrGrantAlreadyUsed) {
+				t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantAlreadyUsed, storer, revokeErr)
+			}
+			if stored.State != grants.GrantStateUsed {
+				t.Errorf("Expected grant to be %q, got %q", grants.GrantStateUsed, stored.State)
+			}
+		case revokeErr == nil:
+			if !errors.Is(exchangeErr, grants.ErrGrantRevoked) {
+				t.Errorf("Expected error to be %v, %T returned %v\n", grants.ErrGrantRevoked, storer, exchangeErr)
+			}
+			if stored.State != grants.GrantStateRevoked {
+				t.Errorf("Expected grant to be %q, got %q", grants.GrantStateRevoked, stored.State)
+			}
+		default:
+			t.Errorf("Expected one of exchange and revoke to succeed in %T, got %v and %v", storer, exchangeErr, revokeErr)
+		}
+	}
+}
+
+func testConcurrentCreateSameSource(ctx context.Context, t *testing.T, storer grants.Storer) {
+	candidates := make([]grants.Grant, racers)
+	for i := range candidates {
+		candidates[i] = grants.Grant{
+			ID:          uuidOrFail(t),
+			SourceType:  "manual",
+			SourceID:    "TestConcurrentCreateSameSource",
+			AncestorIDs: []string{},
+			ProfileID:   "tester",
+			ClientID:    "testrunner",
+			State:       grants.GrantStateActive,
+		}
+	}
+	errs := race(func(i int) error {
+		return storer.CreateGrant(ctx, candidates[i])
+	})
+	won := winner(t, storer, errs, grants.ErrGrantSourceAlreadyUsed)
+
+	stored, err := storer.GetGrantBySource(ctx, "manual", "TestConcurrentCreateSameSource")
+	if err != nil {
+		t.Fatalf("Unexpected error retrieving grant from %T: %+v\n", storer, err)
+	}
+	if stored.ID != candidates[won].ID {
+		t.Errorf("Expected the source to belong to grant %s, got %s", candidates[won].ID, stored.ID)
+	}
+}
@@ -0,0 +1,117 @@
+// Package storertest provides a conformance test suite for implementations of
+// grants.Storer, so every backend can be held to the same behavior.
+//
+// To run it against a Storer, implement Factory and call RunConformance from
+// a test:
+//
+//	func TestConformance(t *testing.T) {
+//		factory := mystorer.NewFactory()
+//		t.Cleanup(func() {
+//			if err := factory.TeardownStorers(); err != nil {
+//				t.Error(err)
+//			}
+//		})
+//		storertest.RunConformance(t, factory)
+//	}
+//
+// Every Storer method is covered, including the errors each is expected to
+// return and how they behave when called concurrently. The optional
+// interfaces Storers can implement, like grants.FamilyRevoker,
+// grants.IPRetainer, grants.DeviceStorer, and grants.PendingExchanger, are
+// tested when the Storer implements them, and skipped otherwise.
+package storertest
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	uuid "github.com/hashicorp/go-uuid"
+	yall "yall.in"
+	"yall.in/colour"
+
+	"lockbox.dev/grants"
+)
+
+// Factory creates the Storers under test. Each test gets its own Storer, so
+// tests can run in parallel without seeing each other's Grants.
+type Factory interface {
+	// NewStorer returns a new, empty Storer.
+	NewStorer(ctx context.Context) (grants.Storer, error)
+
+	// TeardownStorers cleans up every Storer NewStorer returned.
+	// RunConformance doesn't call it, as the Factory may be shared with
+	// other tests; call it once they've all finished, like from TestMain.
+	TeardownStorers() error
+}
+
+type conformanceTest struct {
+	name string
+	test func(ctx context.Context, t *testing.T, storer grants.Storer)
+}
+
+//nolint:gochecknoglobals // a lookup table, never modified
+var conformanceTests = []conformanceTest{
+	{name: "CreateAndExchangeGrant", test: testCreateAndExchangeGrant},
+	{name: "CreateAndGetGrant", test: testCreateAndGetGrant},
+	{name: "CreateAndGetRootGrant", test: testCreateAndGetRootGrant},
+	{name: "CreateAndGetGrantBySource", test: testCreateAndGetGrantBySource},
+	{name: "CreateAndExchangeUsedGrant", test: testCreateAndExchangeUsedGrant},
+	{name: "CreateAndExchangeRevokedGrant", test: testCreateAndExchangeRevokedGrant},
+	{name: "ExchangeNonExistentGrant", test: testExchangeNonExistentGrant},
+	{name: "GetNonExistentGrant", test: testGetNonExistentGrant},
+	{name: "GetNonExistentGrantBySource", test: testGetNonExistentGrantBySource},
+	{name: "CreateDuplicateGrant", test: testCreateDuplicateGrant},
+	{name: "CreateDuplicateSourceGrant", test: testCreateDuplicateSourceGrant},
+	{name: "CreateAndRevokeGrant", test: testCreateAndRevokeGrant},
+	{name: "CreateAndRevokeUsedGrant", test: testCreateAndRevokeUsedGrant},
+	{name: "RevokeNonExistentGrant", test: testRevokeNonExistentGrant},
+	{name: "HashedSources", test: testHashedSources},
+	{name: "AnonymizeProfile", test: testAnonymizeProfile},
+	{name: "ListGrantsByProfile", test: testListGrantsByProfile},
+	{name: "ApplyIPRetention", test: testApplyIPRetention},
+	{name: "TenantIsolation", test: testTenantIsolation},
+	{name: "ExplicitTenant", test: testExplicitTenant},
+	{name: "RotateGrant", test: testRotateGrant},
+	{name: "RotateGrantExplicitTenant", test: testRotateGrantExplicitTenant},
+	{name: "RotateGrantFailedCreate", test: testRotateGrantFailedCreate},
+	{name: "RevokeGrantFamily", test: testRevokeGrantFamily},
+	{name: "ExchangePendingGrant", test: testExchangePendingGrant},
+	{name: "DeviceAuthorizations", test: testDeviceAuthorizations},
+	{name: "PurgeDeviceAuthorizations", test: testPurgeDeviceAuthorizations},
+	{name: "GrantStates", test: testGrantStates},
+	{name: "ConcurrentExchange", test: testConcurrentExchange},
+	{name: "ConcurrentRotate", test: testConcurrentRotate},
+	{name: "ConcurrentExchangeAndRevoke", test: testConcurrentExchangeAndRevoke},
+	{name: "ConcurrentCreateSameSource", test: testConcurrentCreateSameSource},
+}
+
+// RunConformance runs the conformance test suite as parallel subtests of `t`,
+// each against a new Storer from `factory`.
+func RunConformance(t *testing.T, factory Factory) {
+	t.Helper()
+
+	logger := yall.New(colour.New(os.Stdout, yall.Debug))
+	for _, test := range conformanceTests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctx := yall.InContext(context.Background(), logger)
+			storer, err := factory.NewStorer(ctx)
+			if err != nil {
+				t.Fatalf("Error creating Storer from %T: %+v\n", factory, err)
+			}
+			test.test(ctx, t, storer)
+		})
+	}
+}
+
+func uuidOrFail(t *testing.T) string {
+	t.Helper()
+	id, err := uuid.GenerateUUID()
+	if err != nil {
+		t.Fatalf("Unexpected error generating ID: %s", err.Error())
+	}
+	return id
+}
@@ -0,0 +1,58 @@
+package grants
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrTenantMismatch is returned when a Grant is being stored with a TenantID
+// that doesn't match the tenant its context is scoped to. This usually
+// indicates a programming error.
+var ErrTenantMismatch = errors.New("grant belongs to a different tenant than the request")
+
+type tenantContextKey struct{}
+
+// WithTenant returns a copy of `ctx` scoped to `tenantID`. Every Storer method
+// called with the returned context only sees and modifies Grants belonging to
+// that tenant.
+//
+// Contexts that were never scoped to a tenant belong to the default tenant,
+// identified by an empty TenantID, so single-tenant deployments don't need to
+// call WithTenant at all.
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the ID of the tenant `ctx` is scoped to, or an
+// empty string for the default tenant.
+func TenantFromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantContextKey{}).(string)
+	return tenantID
+}
+
+// ResolveTenant returns `grant` with its TenantID set to the tenant it should
+// be stored under. A TenantID set explicitly on `grant` is used as-is when
+// `ctx` isn't scoped to a tenant; otherwise the tenant from `ctx` is used, and
+// an ErrTenantMismatch error is returned if `grant` names a different one.
+func ResolveTenant(ctx context.Context, grant Grant) (Grant, error) {
+	tenantID, err := ResolveTenantID(ctx, grant.TenantID)
+	if err != nil {
+		return Grant{}, err
+	}
+	grant.TenantID = tenantID
+	return grant, nil
+}
+
+// ResolveTenantID returns the tenant something explicitly set to belong to
+// `tenantID` should be stored under, following the same rules as
+// ResolveTenant.
+func ResolveTenantID(ctx context.Context, tenantID string) (string, error) {
+	fromCtx := TenantFromContext(ctx)
+	if fromCtx == "" {
+		return tenantID, nil
+	}
+	if tenantID != "" && tenantID != fromCtx {
+		return "", ErrTenantMismatch
+	}
+	return fromCtx, nil
+}